@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/simota/md/internal/app"
+)
+
+func runDiff(args []string) {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	var (
+		style string
+		width int
+		pager bool
+		rev   string
+	)
+	fs.StringVar(&style, "style", "auto", "render style: auto|dark|light")
+	fs.StringVar(&style, "s", "auto", "alias for --style")
+	fs.IntVar(&width, "width", 0, "render width (0 = auto)")
+	fs.IntVar(&width, "w", 0, "alias for --width")
+	fs.BoolVar(&pager, "p", false, "open the diff in the pager")
+	fs.StringVar(&rev, "rev", "", "compare the file with its version at this git revision")
+
+	fs.Usage = func() {
+		out := fs.Output()
+		fmt.Fprintf(out, "Usage: %s diff [options] old new\n", os.Args[0])
+		fmt.Fprintf(out, "       %s diff [options] --rev REV file\n\n", os.Args[0])
+		fmt.Fprintln(out, "Show what changed between two documents, rendered. Added blocks are marked +,")
+		fmt.Fprintln(out, "removed ones - and edited ones ~ with the changed words highlighted.")
+		fmt.Fprintln(out, "Exits 1 when the documents differ.")
+		fmt.Fprintln(out, "")
+		fmt.Fprintln(out, "Options:")
+		fmt.Fprintln(out, "  -p             open in the pager (]c/[c jump between changes)")
+		fmt.Fprintln(out, "  -s, --style    auto|dark|light (default: auto)")
+		fmt.Fprintln(out, "  -w, --width    render width (default: terminal width)")
+		fmt.Fprintln(out, "  --rev          compare with the file as of a git revision")
+		fmt.Fprintln(out, "\nExamples:")
+		fmt.Fprintf(out, "  %s diff old.md new.md\n", os.Args[0])
+		fmt.Fprintf(out, "  %s diff -p --rev HEAD~1 README.md\n", os.Args[0])
+		fmt.Fprintf(out, "  %s diff git:v1.0:docs/api.md docs/api.md\n", os.Args[0])
+	}
+	_ = fs.Parse(args)
+
+	changed, err := app.RunDiff(app.DiffOptions{
+		Style:  style,
+		Width:  width,
+		Pager:  pager,
+		Rev:    rev,
+		Args:   fs.Args(),
+		Stdin:  os.Stdin,
+		Stdout: os.Stdout,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(2)
+	}
+	if changed {
+		os.Exit(1)
+	}
+}
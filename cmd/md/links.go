@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/simota/md/internal/app"
+)
+
+func runCheckLinks(args []string) {
+	fs := flag.NewFlagSet("check-links", flag.ExitOnError)
+	var (
+		root        string
+		external    bool
+		concurrency int
+		timeout     time.Duration
+	)
+	fs.StringVar(&root, "root", "", "directory for site-absolute links like /docs/x.md (default: working directory)")
+	fs.BoolVar(&external, "external", false, "also check http(s) URLs")
+	fs.IntVar(&concurrency, "concurrency", 8, "parallel requests for --external")
+	fs.DurationVar(&timeout, "timeout", 10*time.Second, "per-request timeout for --external")
+
+	fs.Usage = func() {
+		out := fs.Output()
+		fmt.Fprintf(out, "Usage: %s check-links [options] [file|dir]...\n\n", os.Args[0])
+		fmt.Fprintln(out, "Report broken relative links and #anchors. Exits 1 when any are found.")
+		fmt.Fprintln(out, "")
+		fmt.Fprintln(out, "Options:")
+		fmt.Fprintln(out, "  --root         directory for /absolute links (default: working directory)")
+		fmt.Fprintln(out, "  --external     also check http(s) URLs (HEAD, falling back to GET)")
+		fmt.Fprintln(out, "  --concurrency  parallel requests (default: 8)")
+		fmt.Fprintln(out, "  --timeout      per-request timeout (default: 10s)")
+		fmt.Fprintln(out, "\nExamples:")
+		fmt.Fprintf(out, "  %s check-links docs/\n", os.Args[0])
+		fmt.Fprintf(out, "  %s check-links --external README.md\n", os.Args[0])
+	}
+	_ = fs.Parse(args)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	broken, err := app.RunCheckLinks(ctx, app.CheckLinksOptions{
+		Root:        root,
+		External:    external,
+		Concurrency: concurrency,
+		Timeout:     timeout,
+		Args:        fs.Args(),
+		Stdout:      os.Stdout,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(2)
+	}
+	if broken > 0 {
+		os.Exit(1)
+	}
+}
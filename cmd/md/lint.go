@@ -0,0 +1,57 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/simota/md/internal/app"
+	"github.com/simota/md/internal/lint"
+)
+
+func runLint(args []string) {
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	var (
+		fix    bool
+		config string
+	)
+	fs.BoolVar(&fix, "fix", false, "fix what can be fixed automatically, in place")
+	fs.StringVar(&config, "config", "", "config file (default: nearest "+lint.ConfigFile+")")
+
+	fs.Usage = func() {
+		out := fs.Output()
+		fmt.Fprintf(out, "Usage: %s lint [options] [file|dir|-]...\n\n", os.Args[0])
+		fmt.Fprintln(out, "Report common Markdown problems. Exits 1 when any are found.")
+		fmt.Fprintln(out, "")
+		fmt.Fprintln(out, "Options:")
+		fmt.Fprintln(out, "  --fix          fix trailing whitespace, bare URLs and list markers in place")
+		fmt.Fprintf(out, "  --config       config file (default: nearest %s)\n", lint.ConfigFile)
+		fmt.Fprintln(out, "\nRules:")
+		for _, r := range lint.Rules {
+			fixable := ""
+			if r.Fixable() {
+				fixable = " (fixable)"
+			}
+			fmt.Fprintf(out, "  %-20s %s%s\n", r.ID, r.Description, fixable)
+		}
+		fmt.Fprintln(out, "\nExamples:")
+		fmt.Fprintf(out, "  %s lint README.md docs/\n", os.Args[0])
+		fmt.Fprintf(out, "  %s lint --fix docs/\n", os.Args[0])
+	}
+	_ = fs.Parse(args)
+
+	problems, err := app.RunLint(app.LintOptions{
+		Fix:    fix,
+		Config: config,
+		Args:   fs.Args(),
+		Stdin:  os.Stdin,
+		Stdout: os.Stdout,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(2)
+	}
+	if problems > 0 {
+		os.Exit(1)
+	}
+}
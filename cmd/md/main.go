@@ -9,34 +9,98 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "toc":
+			runTOC(os.Args[2:])
+			return
+		case "lint":
+			runLint(os.Args[2:])
+			return
+		case "check-links":
+			runCheckLinks(os.Args[2:])
+			return
+		case "stats":
+			runStats(os.Args[2:])
+			return
+		case "serve":
+			runServe(os.Args[2:])
+			return
+		case "diff":
+			runDiff(os.Args[2:])
+			return
+		}
+	}
+
 	var (
 		style       string
+		format      string
 		width       int
 		pager       string
 		pagerAlways bool
+		images      string
+		linkURLs    string
+		slides      bool
+		slideSplit  string
+		section     string
+		sectionPath string
+		configPath  string
+		rev         string
+		encoding    string
 	)
 
 	flag.StringVar(&style, "style", "auto", "render style: auto|dark|light")
 	flag.StringVar(&style, "s", "auto", "alias for --style")
+	flag.StringVar(&format, "format", "terminal", "output format: terminal|json")
+	flag.StringVar(&format, "f", "terminal", "alias for --format")
 	flag.IntVar(&width, "width", 0, "render width (0 = auto)")
 	flag.IntVar(&width, "w", 0, "alias for --width")
 	flag.StringVar(&pager, "pager", "never", "pager mode: auto|always|never")
 	flag.BoolVar(&pagerAlways, "p", false, "open interactive pager (same as --pager=always)")
+	flag.StringVar(&images, "images", "auto", "image display: auto|kitty|iterm|sixel|blocks|off")
+	flag.StringVar(&linkURLs, "link-urls", "show", "show or hide the URL after link text: show|hide")
+	flag.BoolVar(&slides, "slides", false, "present the document as slides")
+	flag.StringVar(&slideSplit, "slide-split", "hr", "start a new slide at: hr|h1|h2")
+	flag.StringVar(&section, "section", "", "show only the section under this heading")
+	flag.StringVar(&sectionPath, "section-path", "", "show only a nested section, e.g. \"API > Auth\"")
+	flag.StringVar(&rev, "rev", "", "show the file as of this git revision (same as git:REV:path)")
+	flag.StringVar(&encoding, "encoding", "auto", "input encoding: auto|utf-8|utf-16le|utf-16be|shift_jis|euc-jp|windows-1252|...")
+	flag.StringVar(&configPath, "config", "", "config file (default: $MD_CONFIG or ~/.config/md/config.json)")
 
 	flag.Usage = func() {
 		out := flag.CommandLine.Output()
 
-		fmt.Fprintf(out, "Usage: %s [options] [file|-]\n\n", os.Args[0])
+		fmt.Fprintf(out, "Usage: %s [options] [file|-]\n", os.Args[0])
+		fmt.Fprintf(out, "       %s toc [options] [file|-]\n", os.Args[0])
+		fmt.Fprintf(out, "       %s lint [options] [file|dir|-]...\n", os.Args[0])
+		fmt.Fprintf(out, "       %s check-links [options] [file|dir]...\n", os.Args[0])
+		fmt.Fprintf(out, "       %s stats [options] [file|-]\n", os.Args[0])
+		fmt.Fprintf(out, "       %s serve [options] [dir|file]\n", os.Args[0])
+		fmt.Fprintf(out, "       %s diff [options] old new\n\n", os.Args[0])
 		fmt.Fprintln(out, "Options:")
 		fmt.Fprintln(out, "  -p             open interactive pager (TUI)")
 		fmt.Fprintln(out, "  -s, --style    auto|dark|light (default: auto)")
+		fmt.Fprintln(out, "  -f, --format   terminal|json (default: terminal)")
+		fmt.Fprintln(out, "  --slides       present as slides (split on ---)")
+		fmt.Fprintln(out, "  --section      show only the section under a heading")
+		fmt.Fprintln(out, "  --section-path show a nested section, e.g. \"API > Auth\"")
+		fmt.Fprintln(out, "  --rev          show the file at a git revision, e.g. HEAD~3")
 		fmt.Fprintln(out, "")
 		fmt.Fprintln(out, "Advanced:")
 		fmt.Fprintln(out, "  --pager        auto|always|never (default: never)")
 		fmt.Fprintln(out, "  -w, --width    render width (0 = auto)")
+		fmt.Fprintln(out, "  --images       auto|kitty|iterm|sixel|blocks|off (default: auto)")
+		fmt.Fprintln(out, "  --link-urls    show|hide URLs after link text (default: show)")
+		fmt.Fprintln(out, "  --slide-split  hr|h1|h2: where --slides starts a new slide (default: hr)")
+		fmt.Fprintln(out, "  --encoding     input encoding, e.g. shift_jis|euc-jp|windows-1252 (default: auto)")
+		fmt.Fprintln(out, "  --config       config file (default: $MD_CONFIG or ~/.config/md/config.json)")
 		fmt.Fprintln(flag.CommandLine.Output(), "\nExamples:")
 		fmt.Fprintf(out, "  %s README.md\n", os.Args[0])
 		fmt.Fprintf(out, "  %s -p README.md\n", os.Args[0])
+		fmt.Fprintf(out, "  %s --section Installation README.md\n", os.Args[0])
+		fmt.Fprintf(out, "  %s --slides deck.md\n", os.Args[0])
+		fmt.Fprintf(out, "  %s --format json README.md\n", os.Args[0])
+		fmt.Fprintf(out, "  %s git:v1.2.0:docs/api.md\n", os.Args[0])
 		fmt.Fprintf(out, "  cat README.md | %s\n", os.Args[0])
 	}
 	flag.Parse()
@@ -50,9 +114,23 @@ func main() {
 	}
 
 	opts := app.Options{
-		Style:  style,
-		Width:  width,
-		Pager:  pager,
+		Style:    style,
+		Format:   format,
+		Width:    width,
+		Pager:    pager,
+		Images:   images,
+		LinkURLs: linkURLs,
+
+		Slides:     slides,
+		SlideSplit: slideSplit,
+
+		Section:     section,
+		SectionPath: sectionPath,
+
+		Rev:      rev,
+		Encoding: encoding,
+		Config:   configPath,
+
 		Args:   flag.Args(),
 		Stdin:  os.Stdin,
 		Stdout: os.Stdout,
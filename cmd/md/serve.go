@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/simota/md/internal/app"
+)
+
+func runServe(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	var (
+		addr  string
+		style string
+	)
+	fs.StringVar(&addr, "addr", "127.0.0.1:7070", "address to listen on")
+	fs.StringVar(&style, "style", "auto", "page style: auto|dark|light")
+	fs.StringVar(&style, "s", "auto", "alias for --style")
+
+	fs.Usage = func() {
+		out := fs.Output()
+		fmt.Fprintf(out, "Usage: %s serve [options] [dir|file]\n\n", os.Args[0])
+		fmt.Fprintln(out, "Preview Markdown in the browser. Pages reload when their file changes.")
+		fmt.Fprintln(out, "")
+		fmt.Fprintln(out, "Options:")
+		fmt.Fprintln(out, "  --addr         address to listen on (default: 127.0.0.1:7070)")
+		fmt.Fprintln(out, "  -s, --style    auto|dark|light (default: auto, follows the browser)")
+		fmt.Fprintln(out, "\nExamples:")
+		fmt.Fprintf(out, "  %s serve docs/\n", os.Args[0])
+		fmt.Fprintf(out, "  %s serve --addr :8080 README.md\n", os.Args[0])
+	}
+	_ = fs.Parse(args)
+	if fs.NArg() > 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	err := app.RunServe(ctx, app.ServeOptions{
+		Addr:   addr,
+		Style:  style,
+		Arg:    fs.Arg(0),
+		Stdout: os.Stdout,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+}
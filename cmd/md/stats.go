@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/simota/md/internal/app"
+)
+
+func runStats(args []string) {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	var format string
+	fs.StringVar(&format, "format", "text", "output format: text|json")
+	fs.StringVar(&format, "f", "text", "alias for --format")
+
+	fs.Usage = func() {
+		out := fs.Output()
+		fmt.Fprintf(out, "Usage: %s stats [options] [file|-]\n\n", os.Args[0])
+		fmt.Fprintln(out, "Print word count, reading time and structure of the document.")
+		fmt.Fprintln(out, "")
+		fmt.Fprintln(out, "Options:")
+		fmt.Fprintln(out, "  -f, --format   text|json (default: text)")
+		fmt.Fprintln(out, "\nExamples:")
+		fmt.Fprintf(out, "  %s stats post.md\n", os.Args[0])
+		fmt.Fprintf(out, "  %s stats -f json README.md\n", os.Args[0])
+	}
+	_ = fs.Parse(args)
+
+	err := app.RunStats(app.StatsOptions{
+		Format: format,
+		Args:   fs.Args(),
+		Stdin:  os.Stdin,
+		Stdout: os.Stdout,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/simota/md/internal/app"
+)
+
+func runTOC(args []string) {
+	fs := flag.NewFlagSet("toc", flag.ExitOnError)
+	var (
+		format   string
+		minLevel int
+		maxLevel int
+		inject   bool
+	)
+	fs.StringVar(&format, "format", "markdown", "output format: markdown|text|json")
+	fs.StringVar(&format, "f", "markdown", "alias for --format")
+	fs.IntVar(&minLevel, "min-level", 1, "shallowest heading level to include")
+	fs.IntVar(&maxLevel, "max-level", 6, "deepest heading level to include")
+	fs.BoolVar(&inject, "inject", false, "rewrite the region between <!-- toc --> and <!-- tocstop --> in the file")
+
+	fs.Usage = func() {
+		out := fs.Output()
+		fmt.Fprintf(out, "Usage: %s toc [options] [file|-]\n\n", os.Args[0])
+		fmt.Fprintln(out, "Print the document's table of contents.")
+		fmt.Fprintln(out, "")
+		fmt.Fprintln(out, "Options:")
+		fmt.Fprintln(out, "  -f, --format   markdown|text|json (default: markdown)")
+		fmt.Fprintln(out, "  --min-level    shallowest heading level (default: 1)")
+		fmt.Fprintln(out, "  --max-level    deepest heading level (default: 6)")
+		fmt.Fprintln(out, "  --inject       update the <!-- toc --> ... <!-- tocstop --> region in place")
+		fmt.Fprintln(out, "\nExamples:")
+		fmt.Fprintf(out, "  %s toc README.md\n", os.Args[0])
+		fmt.Fprintf(out, "  %s toc --max-level 3 --inject README.md\n", os.Args[0])
+	}
+	_ = fs.Parse(args)
+
+	err := app.RunTOC(app.TOCOptions{
+		Format:   format,
+		MinLevel: minLevel,
+		MaxLevel: maxLevel,
+		Inject:   inject,
+		Args:     fs.Args(),
+		Stdin:    os.Stdin,
+		Stdout:   os.Stdout,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+}
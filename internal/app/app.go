@@ -5,16 +5,43 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
 
+	"github.com/simota/md/internal/config"
+	"github.com/simota/md/internal/export"
 	"github.com/simota/md/internal/input"
+	"github.com/simota/md/internal/outline"
 	"github.com/simota/md/internal/render"
 	"github.com/simota/md/internal/tui"
 )
 
 type Options struct {
-	Style  string
-	Width  int
-	Pager  string
+	Style    string
+	Format   string
+	Width    int
+	Pager    string
+	Images   string
+	LinkURLs string
+
+	// Slides shows the document one slide at a time, split on SlideSplit
+	// (hr|h1|h2).
+	Slides     bool
+	SlideSplit string
+
+	// Section and SectionPath restrict output to one heading's section.
+	Section     string
+	SectionPath string
+
+	// Rev shows the file argument as of this git revision.
+	Rev string
+
+	// Encoding is the input's character encoding; "" or "auto" detects it.
+	Encoding string
+
+	// Config is the user config file; "" = $MD_CONFIG or the default
+	// location, where a missing file is fine.
+	Config string
+
 	Args   []string
 	Stdin  *os.File
 	Stdout *os.File
@@ -26,9 +53,17 @@ func Run(opts Options) error {
 		return errors.New("internal error: stdio is nil")
 	}
 
-	src, err := input.ResolveSource(opts.Args, opts.Stdin)
-	if err != nil {
-		return err
+	var src input.Source
+	if opts.Rev != "" {
+		if len(opts.Args) != 1 || opts.Args[0] == "-" {
+			return errors.New("--rev needs one file path")
+		}
+		src = input.GitSource(opts.Rev, opts.Args[0])
+	} else {
+		var err error
+		if src, err = input.ResolveSource(opts.Args, opts.Stdin); err != nil {
+			return err
+		}
 	}
 	if src == nil {
 		return errors.New("no input: provide a file path or pipe markdown via stdin")
@@ -38,36 +73,126 @@ func Run(opts Options) error {
 	if err != nil {
 		return err
 	}
+	if md, err = input.Decode(md, opts.Encoding); err != nil {
+		return err
+	}
+	if ct := src.ContentType(); ct == input.TypeCSV || ct == input.TypeTSV {
+		md = []byte(render.CSVDocument(string(md), ct == input.TypeTSV))
+	}
+
+	if opts.Section != "" || opts.SectionPath != "" {
+		if md, err = extractSection(md, opts.Section, opts.SectionPath); err != nil {
+			return err
+		}
+	}
+
+	switch strings.ToLower(strings.TrimSpace(opts.Format)) {
+	case "", "terminal":
+	case "json":
+		out, err := export.ASTJSON(string(md))
+		if err != nil {
+			return err
+		}
+		if _, err := opts.Stdout.Write(out); err != nil {
+			return fmt.Errorf("write stdout: %w", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid --format=%q (use terminal|json)", opts.Format)
+	}
 
 	pagerMode, err := input.ParsePagerMode(opts.Pager)
 	if err != nil {
 		return err
 	}
 
+	imagesMode, err := input.ParseImagesMode(opts.Images)
+	if err != nil {
+		return err
+	}
+
+	var hideURLs bool
+	switch strings.ToLower(strings.TrimSpace(opts.LinkURLs)) {
+	case "", "show":
+	case "hide":
+		hideURLs = true
+	default:
+		return fmt.Errorf("invalid --link-urls=%q (use show|hide)", opts.LinkURLs)
+	}
+
 	// If stdout is not a TTY, avoid interactive pager (print-only).
 	stdoutIsTTY := input.IsTerminal(opts.Stdout)
 	usePager := pagerMode.ShouldUsePager(stdoutIsTTY)
 
+	cfgPath := opts.Config
+	if cfgPath == "" {
+		cfgPath = config.DefaultPath()
+	}
+	cfg, err := config.Load(cfgPath, opts.Config != "")
+	if err != nil {
+		return err
+	}
+
+	// Asking the terminal about graphics can take a moment, so only do it
+	// when the document has an image to draw.
+	var images input.ImageSupport
+	if render.HasLocalImages(string(md), src.Dir()) {
+		images = input.ResolveImages(imagesMode, opts.Stdout)
+	}
+	renderOpts := render.Options{
+		Style:      opts.Style,
+		Width:      opts.Width,
+		Images:     images.Protocol,
+		BaseDir:    src.Dir(),
+		CellWidth:  images.CellWidth,
+		CellHeight: images.CellHeight,
+		// Escape sequences for clickable links only make sense on a terminal.
+		Hyperlinks: stdoutIsTTY,
+		HideURLs:   hideURLs,
+
+		FenceCommands: cfg.FenceCommands(),
+	}
+
+	if opts.Slides {
+		split := strings.ToLower(strings.TrimSpace(opts.SlideSplit))
+		switch split {
+		case "":
+			split = tui.SplitRule
+		case tui.SplitRule, tui.SplitH1, tui.SplitH2:
+		default:
+			return fmt.Errorf("invalid --slide-split=%q (use hr|h1|h2)", opts.SlideSplit)
+		}
+		if !stdoutIsTTY {
+			return errors.New("--slides needs a terminal")
+		}
+		title := src.Title()
+		if title == "" {
+			title = "md"
+		}
+		return tui.ViewSlides(title, string(md), split, renderOpts, opts.Stdout)
+	}
+
 	if usePager {
 		title := src.Title()
 		if title == "" {
 			title = "md"
 		}
-		return tui.ViewMarkdown(title, string(md), render.Options{
-			Style: opts.Style,
-			Width: opts.Width, // 0 means auto; TUI will choose based on window size.
-		}, opts.Stdout)
+		// Width 0 means auto; TUI will choose based on window size.
+		return tui.ViewMarkdown(title, string(md), renderOpts, opts.Stdout)
 	}
 
-	w := opts.Width
-	if w <= 0 {
-		w = input.DetectTerminalWidth(opts.Stdout, 80)
+	if renderOpts.Width <= 0 {
+		renderOpts.Width = input.DetectTerminalWidth(opts.Stdout, 80)
 	}
 
-	out, err := render.RenderMarkdown(string(md), render.Options{
-		Style: opts.Style,
-		Width: w,
-	})
+	// Front matter would otherwise render as a thematic break followed by
+	// key/value soup; show it as a compact table instead.
+	doc := string(md)
+	if meta, body, ok := render.SplitFrontMatter(doc); ok {
+		doc = meta.Markdown() + body
+	}
+
+	out, err := render.RenderMarkdown(doc, renderOpts)
 	if err != nil {
 		return err
 	}
@@ -77,3 +202,24 @@ func Run(opts Options) error {
 	}
 	return nil
 }
+
+// extractSection keeps only the requested section. Front matter is dropped:
+// it belongs to the document, not the section.
+func extractSection(md []byte, section, sectionPath string) ([]byte, error) {
+	if section != "" && sectionPath != "" {
+		return nil, errors.New("use either --section or --section-path, not both")
+	}
+	path := []string{section}
+	if sectionPath != "" {
+		path = outline.ParseSectionPath(sectionPath)
+		if len(path) == 0 {
+			return nil, fmt.Errorf("invalid --section-path=%q (use \"Parent > Child\")", sectionPath)
+		}
+	}
+	_, body, _ := render.SplitFrontMatter(string(md))
+	out, err := outline.SectionPath(body, path)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(out), nil
+}
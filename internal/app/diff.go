@@ -0,0 +1,93 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/simota/md/internal/diff"
+	"github.com/simota/md/internal/input"
+	"github.com/simota/md/internal/render"
+	"github.com/simota/md/internal/tui"
+)
+
+type DiffOptions struct {
+	Style  string // auto|dark|light
+	Width  int    // 0 = terminal width
+	Pager  bool   // open the diff in the pager
+	Rev    string // compare the one file argument with its version at Rev
+	Args   []string
+	Stdin  *os.File
+	Stdout *os.File
+}
+
+// RunDiff implements `md diff`. It reports whether the documents differ.
+func RunDiff(opts DiffOptions) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(opts.Style)) {
+	case "", "auto", "dark", "light":
+	default:
+		return false, fmt.Errorf("invalid --style=%q (use auto|dark|light)", opts.Style)
+	}
+
+	var oldSrc, newSrc input.Source
+	switch {
+	case opts.Rev != "" && len(opts.Args) == 1 && opts.Args[0] != "-":
+		oldSrc = input.GitSource(opts.Rev, opts.Args[0])
+	case opts.Rev != "":
+		return false, errors.New("--rev needs one file path")
+	case len(opts.Args) == 2 && !(opts.Args[0] == "-" && opts.Args[1] == "-"):
+		src, err := input.ResolveSource(opts.Args[:1], opts.Stdin)
+		if err != nil {
+			return false, err
+		}
+		oldSrc = src
+	default:
+		return false, errors.New("provide two files to compare, or --rev and one file")
+	}
+	newSrc, err := input.ResolveSource(opts.Args[len(opts.Args)-1:], opts.Stdin)
+	if err != nil {
+		return false, err
+	}
+
+	oldMD, err := readDecoded(oldSrc)
+	if err != nil {
+		return false, err
+	}
+	newMD, err := readDecoded(newSrc)
+	if err != nil {
+		return false, err
+	}
+	d := diff.Compare(string(oldMD), string(newMD))
+
+	renderOpts := render.Options{Style: opts.Style, Width: opts.Width, BaseDir: newSrc.Dir()}
+	if opts.Pager {
+		if !input.IsTerminal(opts.Stdout) {
+			return false, errors.New("-p needs a terminal")
+		}
+		title := oldSrc.Title() + " → " + newSrc.Title()
+		return d.HasChanges(), tui.ViewDiff(title, d, string(newMD), renderOpts, opts.Stdout)
+	}
+
+	if renderOpts.Width <= 0 {
+		renderOpts.Width = input.DetectTerminalWidth(opts.Stdout, 80)
+	}
+	out, _, err := d.Render(renderOpts)
+	if err != nil {
+		return false, err
+	}
+	if _, err := io.WriteString(opts.Stdout, out); err != nil {
+		return false, fmt.Errorf("write stdout: %w", err)
+	}
+	return d.HasChanges(), nil
+}
+
+// readDecoded reads src as UTF-8, detecting its encoding.
+func readDecoded(src input.Source) ([]byte, error) {
+	raw, err := src.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	return input.Decode(raw, "")
+}
@@ -0,0 +1,49 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/simota/md/internal/linkcheck"
+)
+
+type CheckLinksOptions struct {
+	Root        string // for site-absolute links; "" = working directory
+	External    bool   // also check http(s) URLs
+	Concurrency int
+	Timeout     time.Duration
+	Args        []string // files or directories; none = "."
+	Stdout      *os.File
+}
+
+// RunCheckLinks implements `md check-links`. It returns the number of broken
+// links.
+func RunCheckLinks(ctx context.Context, opts CheckLinksOptions) (int, error) {
+	args := opts.Args
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+	files, err := markdownFiles(args)
+	if err != nil {
+		return 0, err
+	}
+
+	c := &linkcheck.Checker{
+		Root:        opts.Root,
+		External:    opts.External,
+		Concurrency: opts.Concurrency,
+		Timeout:     opts.Timeout,
+	}
+	problems, err := c.Check(ctx, files)
+	if err != nil {
+		return 0, err
+	}
+	for _, p := range problems {
+		if _, err := fmt.Fprintln(opts.Stdout, p.String()); err != nil {
+			return len(problems), fmt.Errorf("write stdout: %w", err)
+		}
+	}
+	return len(problems), nil
+}
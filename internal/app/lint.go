@@ -0,0 +1,129 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/simota/md/internal/input"
+	"github.com/simota/md/internal/lint"
+)
+
+type LintOptions struct {
+	Fix    bool   // rewrite files with fixable problems corrected
+	Config string // config file; "" = look for .mdlint.json
+	Args   []string
+	Stdin  *os.File
+	Stdout *os.File
+}
+
+// RunLint implements `md lint`. It returns the number of problems left.
+func RunLint(opts LintOptions) (int, error) {
+	cfg, err := loadLintConfig(opts.Config)
+	if err != nil {
+		return 0, err
+	}
+
+	files, err := markdownFiles(opts.Args)
+	if err != nil {
+		return 0, err
+	}
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	problems := 0
+	for _, path := range files {
+		var (
+			raw  []byte
+			name = path
+		)
+		if path == "-" {
+			if opts.Fix {
+				return problems, errors.New("--fix needs file paths")
+			}
+			name = "<stdin>"
+			raw, err = io.ReadAll(opts.Stdin)
+		} else {
+			raw, err = os.ReadFile(path)
+		}
+		if err != nil {
+			return problems, fmt.Errorf("read %s: %w", name, err)
+		}
+		if opts.Fix && !utf8.Valid(raw) {
+			// Writing fixes back would silently convert the file.
+			return problems, fmt.Errorf("--fix needs UTF-8 input; %s is not", name)
+		}
+		if raw, err = input.Decode(raw, ""); err != nil {
+			return problems, fmt.Errorf("read %s: %w", name, err)
+		}
+
+		md := string(raw)
+		if opts.Fix {
+			if fixed := lint.Fix(md, cfg); fixed != md {
+				if err := writeFilePreservingMode(path, []byte(fixed)); err != nil {
+					return problems, err
+				}
+				md = fixed
+			}
+		}
+		for _, d := range lint.Lint(name, md, cfg) {
+			problems++
+			if _, err := fmt.Fprintln(opts.Stdout, d.String()); err != nil {
+				return problems, fmt.Errorf("write stdout: %w", err)
+			}
+		}
+	}
+	return problems, nil
+}
+
+func loadLintConfig(path string) (lint.Config, error) {
+	if path == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return lint.Config{}, nil
+		}
+		if path = lint.FindConfig(wd); path == "" {
+			return lint.Config{}, nil
+		}
+	}
+	return lint.LoadConfig(path)
+}
+
+// markdownFiles expands directories in args to the Markdown files below
+// them, skipping hidden directories. Other arguments are kept as given.
+func markdownFiles(args []string) ([]string, error) {
+	var out []string
+	for _, arg := range args {
+		st, err := os.Stat(arg)
+		if err != nil || !st.IsDir() {
+			out = append(out, arg)
+			continue
+		}
+		err = filepath.WalkDir(arg, func(p string, e fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if e.IsDir() {
+				if p != arg && strings.HasPrefix(e.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			switch strings.ToLower(filepath.Ext(p)) {
+			case ".md", ".markdown", ".mdown", ".mkd":
+				out = append(out, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("walk %s: %w", arg, err)
+		}
+	}
+	return out, nil
+}
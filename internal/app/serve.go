@@ -0,0 +1,78 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/simota/md/internal/serve"
+)
+
+type ServeOptions struct {
+	Addr   string // host:port; default 127.0.0.1:7070
+	Style  string // auto|dark|light
+	Arg    string // directory to serve, or a file to open in its directory; "" = "."
+	Stdout *os.File
+}
+
+// RunServe implements `md serve`. It serves until ctx is canceled.
+func RunServe(ctx context.Context, opts ServeOptions) error {
+	switch strings.ToLower(strings.TrimSpace(opts.Style)) {
+	case "", "auto", "dark", "light":
+	default:
+		return fmt.Errorf("invalid --style=%q (use auto|dark|light)", opts.Style)
+	}
+
+	root, page := opts.Arg, "/"
+	if root == "" {
+		root = "."
+	}
+	st, err := os.Stat(root)
+	if err != nil {
+		return err
+	}
+	if !st.IsDir() {
+		page = "/" + (&url.URL{Path: filepath.Base(root)}).EscapedPath()
+		root = filepath.Dir(root)
+	}
+
+	addr := opts.Addr
+	if addr == "" {
+		addr = "127.0.0.1:7070"
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{
+		Handler:           &serve.Server{Root: root, Style: opts.Style},
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	if _, err := fmt.Fprintf(opts.Stdout, "Serving %s at http://%s%s (Ctrl+C to stop)\n", root, ln.Addr(), page); err != nil {
+		ln.Close()
+		return fmt.Errorf("write stdout: %w", err)
+	}
+
+	errc := make(chan error, 1)
+	go func() { errc <- srv.Serve(ln) }()
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	// Event streams only end when the browser goes away, so don't wait long.
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	return nil
+}
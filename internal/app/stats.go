@@ -0,0 +1,51 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/simota/md/internal/input"
+	"github.com/simota/md/internal/stats"
+)
+
+type StatsOptions struct {
+	Format string // text|json
+	Args   []string
+	Stdin  *os.File
+	Stdout *os.File
+}
+
+// RunStats implements `md stats`.
+func RunStats(opts StatsOptions) error {
+	src, err := input.ResolveSource(opts.Args, opts.Stdin)
+	if err != nil {
+		return err
+	}
+	if src == nil {
+		return errors.New("no input: provide a file path or pipe markdown via stdin")
+	}
+	raw, err := readDecoded(src)
+	if err != nil {
+		return err
+	}
+
+	s := stats.Compute(string(raw))
+	var out string
+	switch strings.ToLower(strings.TrimSpace(opts.Format)) {
+	case "", "text", "plain":
+		out = s.Text()
+	case "json":
+		if out, err = s.JSON(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("invalid --format=%q (use text|json)", opts.Format)
+	}
+	if _, err := io.WriteString(opts.Stdout, out); err != nil {
+		return fmt.Errorf("write stdout: %w", err)
+	}
+	return nil
+}
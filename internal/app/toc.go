@@ -0,0 +1,128 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/simota/md/internal/input"
+	"github.com/simota/md/internal/outline"
+	"github.com/simota/md/internal/render"
+)
+
+type TOCOptions struct {
+	Format   string // markdown|text|json
+	MinLevel int
+	MaxLevel int
+	Inject   bool // rewrite the <!-- toc --> region of the file in place
+	Args     []string
+	Stdin    *os.File
+	Stdout   *os.File
+}
+
+// RunTOC implements `md toc`.
+func RunTOC(opts TOCOptions) error {
+	if opts.MinLevel < 1 || opts.MaxLevel > 6 || opts.MinLevel > opts.MaxLevel {
+		return fmt.Errorf("invalid heading levels %d-%d (use 1 <= --min-level <= --max-level <= 6)", opts.MinLevel, opts.MaxLevel)
+	}
+	if opts.Inject && (len(opts.Args) != 1 || opts.Args[0] == "-") {
+		return errors.New("--inject needs a file path")
+	}
+
+	var (
+		raw []byte
+		err error
+	)
+	if opts.Inject {
+		// The file is written back as is, so it must be read as is too.
+		if raw, err = readInjectTarget(opts.Args[0]); err != nil {
+			return err
+		}
+	} else {
+		var src input.Source
+		if src, err = input.ResolveSource(opts.Args, opts.Stdin); err != nil {
+			return err
+		}
+		if src == nil {
+			return errors.New("no input: provide a file path or pipe markdown via stdin")
+		}
+		if raw, err = src.ReadAll(); err != nil {
+			return err
+		}
+		if raw, err = input.Decode(raw, ""); err != nil {
+			return err
+		}
+	}
+
+	// Front matter lines are blanked, so "# comments" in YAML are not headings.
+	_, body, _ := render.SplitFrontMatter(string(raw))
+	entries := outline.Tree(outline.Parse(body), opts.MinLevel, opts.MaxLevel)
+
+	if opts.Inject {
+		path := opts.Args[0]
+		doc, ok := outline.Inject(string(raw), outline.Markdown(entries))
+		if !ok {
+			return fmt.Errorf("%s: no %s ... %s markers found", path, outline.InjectStart, outline.InjectStop)
+		}
+		if doc == string(raw) {
+			return nil
+		}
+		return writeFilePreservingMode(path, []byte(doc))
+	}
+
+	var out string
+	switch strings.ToLower(strings.TrimSpace(opts.Format)) {
+	case "", "markdown", "md":
+		out = outline.Markdown(entries)
+	case "text", "plain":
+		out = outline.Text(entries)
+	case "json":
+		if out, err = outline.JSON(entries); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("invalid --format=%q (use markdown|text|json)", opts.Format)
+	}
+	if _, err := io.WriteString(opts.Stdout, out); err != nil {
+		return fmt.Errorf("write stdout: %w", err)
+	}
+	return nil
+}
+
+// readInjectTarget reads the file --inject rewrites. Git revisions, archive
+// members and compressed or non-UTF-8 content are refused: the table could
+// not be written back where it came from without converting it.
+func readInjectTarget(path string) ([]byte, error) {
+	src, err := input.ResolveSource([]string{path}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !input.IsFile(src) {
+		return nil, fmt.Errorf("--inject needs a file on disk; %s is a git revision or archive member", path)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read file %q: %w", path, err)
+	}
+	if input.IsCompressed(raw) {
+		return nil, fmt.Errorf("--inject needs an uncompressed file; %s is compressed", path)
+	}
+	if !utf8.Valid(raw) {
+		return nil, fmt.Errorf("--inject needs UTF-8 input; %s is not", path)
+	}
+	return input.Decode(raw, "")
+}
+
+func writeFilePreservingMode(path string, data []byte) error {
+	mode := os.FileMode(0o644)
+	if st, err := os.Stat(path); err == nil {
+		mode = st.Mode().Perm()
+	}
+	if err := os.WriteFile(path, data, mode); err != nil {
+		return fmt.Errorf("write file %q: %w", path, err)
+	}
+	return nil
+}
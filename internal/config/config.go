@@ -0,0 +1,112 @@
+// Package config loads md's user configuration file.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/simota/md/internal/render"
+)
+
+// EnvVar overrides the config file location.
+const EnvVar = "MD_CONFIG"
+
+// Config is the user configuration:
+//
+//	{
+//	  "renderers": {
+//	    "dot": "graph-easy --as=boxart",
+//	    "csv": {"command": "column -s, -t", "timeout": "2s"}
+//	  }
+//	}
+type Config struct {
+	// Renderers maps a fenced code block language to an external command
+	// that renders it.
+	Renderers map[string]Renderer `json:"renderers"`
+}
+
+// Renderer is an external fence renderer. In JSON it is either the command
+// string or an object with "command" and an optional "timeout".
+type Renderer struct {
+	Command string
+	Timeout time.Duration
+}
+
+func (r *Renderer) UnmarshalJSON(data []byte) error {
+	var cmd string
+	if err := json.Unmarshal(data, &cmd); err == nil {
+		*r = Renderer{Command: cmd}
+		return nil
+	}
+	var obj struct {
+		Command string `json:"command"`
+		Timeout string `json:"timeout"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return errors.New(`renderer must be a command string or {"command": ..., "timeout": ...}`)
+	}
+	*r = Renderer{Command: obj.Command}
+	if obj.Timeout != "" {
+		d, err := time.ParseDuration(obj.Timeout)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid timeout %q (use e.g. \"3s\")", obj.Timeout)
+		}
+		r.Timeout = d
+	}
+	return nil
+}
+
+// DefaultPath returns $MD_CONFIG, or md/config.json in the user config
+// directory (~/.config on Linux), or "" when neither is known.
+func DefaultPath() string {
+	if p := os.Getenv(EnvVar); p != "" {
+		return p
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "md", "config.json")
+}
+
+// Load reads the config file at path. A missing file is an empty config
+// unless required is set (the path was given explicitly).
+func Load(path string, required bool) (Config, error) {
+	if path == "" {
+		return Config{}, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !required {
+		return Config{}, nil
+	}
+	if err != nil {
+		return Config{}, fmt.Errorf("read config: %w", err)
+	}
+	var c Config
+	if err := json.Unmarshal(data, &c); err != nil {
+		return Config{}, fmt.Errorf("parse %s: %w", path, err)
+	}
+	for lang, r := range c.Renderers {
+		if strings.TrimSpace(r.Command) == "" {
+			return Config{}, fmt.Errorf("%s: renderer %q has no command", path, lang)
+		}
+	}
+	return c, nil
+}
+
+// FenceCommands returns the renderers in the form render.Options takes.
+func (c Config) FenceCommands() map[string]render.FenceCommand {
+	if len(c.Renderers) == 0 {
+		return nil
+	}
+	out := make(map[string]render.FenceCommand, len(c.Renderers))
+	for lang, r := range c.Renderers {
+		out[strings.ToLower(lang)] = render.FenceCommand{Command: r.Command, Timeout: r.Timeout}
+	}
+	return out
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, body string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(p, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestLoad_Renderers(t *testing.T) {
+	p := writeConfig(t, `{"renderers": {"DOT": "graph-easy --as=boxart", "csv": {"command": "column -t", "timeout": "2s"}}}`)
+	c, err := Load(p, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fc := c.FenceCommands()
+	if fc["dot"].Command != "graph-easy --as=boxart" || fc["dot"].Timeout != 0 {
+		t.Fatalf("dot = %+v", fc["dot"])
+	}
+	if fc["csv"].Command != "column -t" || fc["csv"].Timeout != 2*time.Second {
+		t.Fatalf("csv = %+v", fc["csv"])
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	for body, want := range map[string]string{
+		`{"renderers": {"dot": {"command": "x", "timeout": "soon"}}}`: `invalid timeout "soon"`,
+		`{"renderers": {"dot": ""}}`:                                  `renderer "dot" has no command`,
+		`{"renderers": {"dot": 3}}`:                                   "command string",
+	} {
+		_, err := Load(writeConfig(t, body), true)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: got %v, want %q", body, err, want)
+		}
+	}
+
+	missing := filepath.Join(t.TempDir(), "none.json")
+	if _, err := Load(missing, false); err != nil {
+		t.Errorf("missing default config: %v", err)
+	}
+	if _, err := Load(missing, true); err == nil {
+		t.Error("missing explicit config: no error")
+	}
+}
@@ -0,0 +1,310 @@
+// Package diff compares two Markdown documents block by block and renders
+// the result: unchanged blocks as usual, added and removed blocks with a
+// colored gutter, and edited paragraphs with the changed words marked.
+package diff
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/simota/md/internal/export"
+	"github.com/simota/md/internal/render"
+)
+
+// Op says how a block changed.
+type Op int
+
+const (
+	Equal   Op = iota
+	Added      // only in the new document
+	Removed    // only in the old document
+	Changed    // edited in place, shown with the changed words marked
+)
+
+// Block is a top-level block of a document. Lists are split into their
+// items, so one edited item does not mark the whole list.
+type Block struct {
+	Kind string // export.Node kind: Paragraph, Heading, ListItem, ...
+	Text string // Markdown source
+	List int    // for list items, which list of the document (1-based)
+	Line int    // 0-based line of the document where the block starts
+}
+
+// Entry is one block of the diff. Old is set unless Op is Added, New unless
+// Op is Removed.
+type Entry struct {
+	Op       Op
+	Old, New *Block
+}
+
+// Diff is the block-level difference between two documents.
+type Diff struct {
+	Entries []Entry
+
+	// Link reference definitions of each document, appended to its blocks
+	// when they are rendered one by one.
+	oldRefs, newRefs string
+}
+
+// Compare splits both documents into blocks and matches them up. A removed
+// block followed by a similar added one (a paragraph, heading or one-line
+// list item with at least half of its words kept) becomes one Changed entry.
+func Compare(oldMD, newMD string) Diff {
+	a, b := Blocks(oldMD), Blocks(newMD)
+	ka, kb := make([]string, len(a)), make([]string, len(b))
+	for i := range a {
+		ka[i] = blockKey(a[i])
+	}
+	for i := range b {
+		kb[i] = blockKey(b[i])
+	}
+
+	var out, removed, added []Entry
+	flush := func() {
+		out = append(out, pairChanges(removed, added)...)
+		removed, added = nil, nil
+	}
+	for _, e := range lcs(ka, kb) {
+		switch e.op {
+		case Equal:
+			flush()
+			out = append(out, Entry{Op: Equal, Old: &a[e.i], New: &b[e.j]})
+		case Removed:
+			removed = append(removed, Entry{Op: Removed, Old: &a[e.i]})
+		case Added:
+			added = append(added, Entry{Op: Added, New: &b[e.j]})
+		}
+	}
+	flush()
+	return Diff{Entries: out, oldRefs: refDefs(oldMD), newRefs: refDefs(newMD)}
+}
+
+// HasChanges reports whether the documents differ.
+func (d Diff) HasChanges() bool {
+	for _, e := range d.Entries {
+		if e.Op != Equal {
+			return true
+		}
+	}
+	return false
+}
+
+// pairChanges merges removed and added blocks of one run into Changed
+// entries where they are edits of each other. Other blocks replaced by one
+// of the same kind (a table by a table) are kept next to it.
+func pairChanges(removed, added []Entry) []Entry {
+	var out []Entry
+	j := 0
+	for _, r := range removed {
+		switch {
+		case j < len(added) && similar(r.Old, added[j].New):
+			out = append(out, Entry{Op: Changed, Old: r.Old, New: added[j].New})
+			j++
+		case j < len(added) && r.Old.Kind == added[j].New.Kind:
+			out = append(out, r, added[j])
+			j++
+		default:
+			out = append(out, r)
+		}
+	}
+	return append(out, added[j:]...)
+}
+
+func similar(a, b *Block) bool {
+	if a.Kind != b.Kind {
+		return false
+	}
+	pa, wa, ok := splitWords(*a)
+	if !ok {
+		return false
+	}
+	pb, wb, ok := splitWords(*b)
+	if !ok || normalizeMarker(pa) != normalizeMarker(pb) {
+		return false
+	}
+	same := 0
+	for _, e := range lcs(wa, wb) {
+		if e.op == Equal {
+			same++
+		}
+	}
+	// At least half of the words, on average, are kept.
+	return 4*same >= len(wa)+len(wb)
+}
+
+var (
+	refDefRe      = regexp.MustCompile(`^ {0,3}\[[^\]]+\]:\s*\S`)
+	headingRe     = regexp.MustCompile(`^ {0,3}#{1,6}(\s|$)`)
+	listMarkerRe  = regexp.MustCompile(`^ {0,3}([-+*]|\d{1,9}[.)])(\s+\[[ xX]\])?(\s|$)`)
+	orderedItemRe = regexp.MustCompile(`^\d+`)
+)
+
+// Blocks splits md into blocks. Front matter becomes a table block, as it
+// is shown in print mode; link reference definitions are left out.
+func Blocks(md string) []Block {
+	md = strings.ReplaceAll(strings.TrimPrefix(md, "\ufeff"), "\r\n", "\n")
+	// The front matter's lines are blanked in body, so shift only counts
+	// the lines of the table put in front of it.
+	shift := 0
+	if meta, body, ok := render.SplitFrontMatter(md); ok {
+		table := meta.Markdown()
+		md, shift = table+body, strings.Count(table, "\n")
+	}
+	lines := strings.Split(md, "\n")
+	text := func(n *export.Node) string {
+		start, end := max(n.StartLine, 1), min(n.EndLine, len(lines))
+		if start > end {
+			return ""
+		}
+		return strings.Trim(strings.Join(lines[start-1:end], "\n"), "\n")
+	}
+
+	var out []Block
+	add := func(n *export.Node, list int) {
+		t := text(n)
+		if t == "" || onlyRefDefs(t) {
+			return
+		}
+		out = append(out, Block{Kind: n.Kind, Text: t, List: list, Line: max(n.StartLine-1-shift, 0)})
+	}
+	lists := 0
+	for _, n := range export.ParseAST(md).Document.Children {
+		if n.Kind == "List" {
+			lists++
+			for _, item := range n.Children {
+				add(item, lists)
+			}
+			continue
+		}
+		add(n, 0)
+	}
+	return out
+}
+
+func onlyRefDefs(text string) bool {
+	for _, ln := range strings.Split(text, "\n") {
+		if strings.TrimSpace(ln) != "" && !refDefRe.MatchString(ln) {
+			return false
+		}
+	}
+	return true
+}
+
+// refDefs returns the link reference definitions of md, so a block rendered
+// on its own still resolves [text][ref] links.
+func refDefs(md string) string {
+	var b strings.Builder
+	for _, ln := range strings.Split(md, "\n") {
+		if refDefRe.MatchString(ln) {
+			b.WriteString(strings.TrimRight(ln, "\r") + "\n")
+		}
+	}
+	return b.String()
+}
+
+// blockKey is what two blocks must share to count as equal: the same text
+// up to whitespace and the numbers of ordered list items, which shift when
+// an item is inserted above.
+func blockKey(b Block) string {
+	text := strings.Join(strings.Fields(b.Text), " ")
+	if b.Kind == "ListItem" {
+		text = normalizeMarker(text)
+	}
+	return b.Kind + "\x00" + text
+}
+
+func normalizeMarker(s string) string {
+	return orderedItemRe.ReplaceAllString(s, "1")
+}
+
+// splitWords splits a block that can be diffed word by word into its
+// marker (heading hashes, list bullet) and words. ok is false for other
+// blocks: tables, code, quotes and multi-line headings or list items.
+func splitWords(b Block) (prefix string, words []string, ok bool) {
+	switch b.Kind {
+	case "Paragraph":
+		return "", strings.Fields(b.Text), true
+	case "Heading":
+		if strings.Contains(b.Text, "\n") {
+			return "", nil, false
+		}
+		prefix = headingRe.FindString(b.Text)
+	case "ListItem":
+		if strings.Contains(b.Text, "\n") {
+			return "", nil, false
+		}
+		prefix = listMarkerRe.FindString(b.Text)
+	default:
+		return "", nil, false
+	}
+	if prefix == "" {
+		return "", nil, false
+	}
+	return strings.TrimSpace(prefix), strings.Fields(b.Text[len(prefix):]), true
+}
+
+type edit struct {
+	op   Op
+	i, j int // index into a (Equal, Removed) and b (Equal, Added)
+}
+
+// lcs returns the edits that turn a into b, keeping a longest common
+// subsequence. Removals come before additions within a run.
+func lcs(a, b []string) []edit {
+	// Common prefix and suffix are cheap and keep the table small.
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+	ma, mb := a[pre:len(a)-suf], b[pre:len(b)-suf]
+
+	// n[i][j] is the LCS length of ma[i:] and mb[j:].
+	n := make([][]int32, len(ma)+1)
+	for i := range n {
+		n[i] = make([]int32, len(mb)+1)
+	}
+	for i := len(ma) - 1; i >= 0; i-- {
+		for j := len(mb) - 1; j >= 0; j-- {
+			if ma[i] == mb[j] {
+				n[i][j] = n[i+1][j+1] + 1
+			} else {
+				n[i][j] = max(n[i+1][j], n[i][j+1])
+			}
+		}
+	}
+
+	out := make([]edit, 0, len(a)+len(b))
+	for k := 0; k < pre; k++ {
+		out = append(out, edit{op: Equal, i: k, j: k})
+	}
+	var removed, added []edit
+	flush := func() {
+		out = append(append(out, removed...), added...)
+		removed, added = removed[:0], added[:0]
+	}
+	i, j := 0, 0
+	for i < len(ma) || j < len(mb) {
+		switch {
+		case i < len(ma) && j < len(mb) && ma[i] == mb[j]:
+			flush()
+			out = append(out, edit{op: Equal, i: pre + i, j: pre + j})
+			i++
+			j++
+		case j == len(mb) || (i < len(ma) && n[i+1][j] >= n[i][j+1]):
+			removed = append(removed, edit{op: Removed, i: pre + i})
+			i++
+		default:
+			added = append(added, edit{op: Added, j: pre + j})
+			j++
+		}
+	}
+	flush()
+	for k := 0; k < suf; k++ {
+		out = append(out, edit{op: Equal, i: len(a) - suf + k, j: len(b) - suf + k})
+	}
+	return out
+}
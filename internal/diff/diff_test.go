@@ -0,0 +1,107 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	xansi "github.com/charmbracelet/x/ansi"
+
+	"github.com/simota/md/internal/render"
+)
+
+func ops(d Diff) string {
+	var b strings.Builder
+	for _, e := range d.Entries {
+		b.WriteByte(" +-~"[e.Op])
+	}
+	return b.String()
+}
+
+func TestCompare_Blocks(t *testing.T) {
+	oldMD := "# Title\n\nThe quick brown fox jumps over the lazy dog.\n\n- a\n- b\n\nGone.\n"
+	newMD := "# Title\n\nThe quick red fox jumps over the lazy dog.\n\n- a\n- b\n- c\n"
+	d := Compare(oldMD, newMD)
+	if got, want := ops(d), " ~  -+"; got != want {
+		t.Fatalf("ops = %q, want %q", got, want)
+	}
+	if !d.HasChanges() || Compare(oldMD, oldMD).HasChanges() {
+		t.Fatal("HasChanges is wrong")
+	}
+}
+
+func TestCompare_RenumberedItemsAreEqual(t *testing.T) {
+	d := Compare("1. one\n2. two\n", "1. zero\n2. one\n3. two\n")
+	if got, want := ops(d), "+  "; got != want {
+		t.Fatalf("ops = %q, want %q", got, want)
+	}
+}
+
+func TestCompare_RewrittenParagraphIsNotChanged(t *testing.T) {
+	d := Compare("Completely different text here.\n", "Nothing in common at all.\n")
+	if got, want := ops(d), "-+"; got != want {
+		t.Fatalf("ops = %q, want %q", got, want)
+	}
+}
+
+func TestMergeWords(t *testing.T) {
+	got := mergeWords(Block{Kind: "Heading", Text: "## Old name"}, Block{Kind: "Heading", Text: "## New name"})
+	want := "## " + delMark + "Old" + endMark + " " + insMark + "New" + endMark + " name"
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestMarkWords_RestylesAfterGlamourResets(t *testing.T) {
+	in := "\x1b[1mon" + insMark + "e\x1b[0m two" + endMark + "\n"
+	want := "\x1b[1mon" + insStyle + "e\x1b[0m" + insStyle + " two" + endStyle + "\n"
+	if got := markWords(in); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestRender_GuttersAndChanges(t *testing.T) {
+	d := Compare("Intro.\n\nKeep me.\n\nOld end.\n", "Intro.\n\nKeep me.\n\nNew end.\n\nMore.\n")
+	out, layout, err := d.Render(render.Options{Style: "dark", Width: 40})
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes := layout.Changes
+	lines := strings.Split(xansi.Strip(out), "\n")
+	if len(changes) != 1 {
+		t.Fatalf("changes = %v, want one", changes)
+	}
+	if got := lines[changes[0]]; !strings.HasPrefix(got, "~ ") || !strings.Contains(got, "Old New end.") {
+		t.Fatalf("change starts at %q", got)
+	}
+	for _, ln := range lines {
+		switch {
+		case strings.Contains(ln, "Keep me."):
+			if !strings.HasPrefix(ln, "  ") {
+				t.Fatalf("unchanged line %q has a gutter", ln)
+			}
+		case strings.Contains(ln, "More."):
+			if !strings.HasPrefix(ln, "+ ") {
+				t.Fatalf("added line %q", ln)
+			}
+		}
+		if w := xansi.StringWidth(ln); w > 40 {
+			t.Fatalf("line %q is %d wide", ln, w)
+		}
+	}
+}
+
+func TestRender_HeadingLines(t *testing.T) {
+	oldMD := "---\ntitle: T\n---\n# Intro\n\nText.\n\n## Old Setup\n\nSteps.\n"
+	newMD := "---\ntitle: T\n---\n# Intro\n\nMore text here.\n\nText.\n\n## New Setup\n\nSteps.\n"
+	out, layout, err := Compare(oldMD, newMD).Render(render.Options{Style: "dark", Width: 40})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(xansi.Strip(out), "\n")
+	for mdLine, want := range map[int]string{3: "Intro", 9: "Old New Setup"} {
+		got, ok := layout.Headings[mdLine]
+		if !ok || !strings.Contains(lines[got], want) {
+			t.Errorf("heading at source line %d: %v %q, want %q", mdLine, ok, lines[got], want)
+		}
+	}
+}
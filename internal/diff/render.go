@@ -0,0 +1,191 @@
+package diff
+
+import (
+	"strings"
+
+	xansi "github.com/charmbracelet/x/ansi"
+
+	"github.com/simota/md/internal/render"
+)
+
+// Gutters in front of every rendered line, in the terminal's own green, red
+// and yellow so they read as diff colors in any theme.
+const (
+	gutterWidth   = 2
+	gutterEqual   = "  "
+	gutterAdded   = "\x1b[1;32m+\x1b[0m "
+	gutterRemoved = "\x1b[1;31m-\x1b[0m "
+	gutterChanged = "\x1b[1;33m~\x1b[0m "
+)
+
+// Changed words are wrapped in private-use runes before rendering; glamour
+// passes them through and markWords turns them into styles afterwards.
+const (
+	insMark = "\ue000"
+	delMark = "\ue001"
+	endMark = "\ue002"
+
+	insStyle = "\x1b[32;4m" // green, underlined
+	delStyle = "\x1b[31;9m" // red, struck through
+	endStyle = "\x1b[39;24;29m"
+)
+
+// Layout says where parts of a rendered diff landed, as 0-based line
+// numbers of the output.
+type Layout struct {
+	Changes  []int       // first line of each change (a run of changed blocks), in order
+	Headings map[int]int // source line of each heading of the new document -> its line
+}
+
+// Render renders the diff at opts.Width, gutter included.
+func (d Diff) Render(opts render.Options) (string, Layout, error) {
+	w := opts.Width
+	if w <= 0 {
+		w = 80
+	}
+	opts.Width = max(w-gutterWidth, 20)
+
+	var lines []string
+	layout := Layout{Headings: map[int]int{}}
+	for k, e := range d.Entries {
+		var src, refs, gutter string
+		switch e.Op {
+		case Equal:
+			src, refs, gutter = e.New.Text, d.newRefs, gutterEqual
+		case Added:
+			src, refs, gutter = e.New.Text, d.newRefs, gutterAdded
+		case Removed:
+			src, refs, gutter = e.Old.Text, d.oldRefs, gutterRemoved
+		case Changed:
+			src, refs, gutter = mergeWords(*e.Old, *e.New), d.newRefs+d.oldRefs, gutterChanged
+		}
+		out, err := render.RenderMarkdown(src+"\n\n"+refs, opts)
+		if err != nil {
+			return "", Layout{}, err
+		}
+		if e.Op == Changed {
+			out = markWords(out)
+		}
+
+		// Items of one list stay together; other blocks are a blank line
+		// apart, as in the rendered document.
+		if k == 0 || !sameList(d.Entries[k-1], e) {
+			lines = append(lines, "")
+		}
+		if e.Op != Equal && (k == 0 || d.Entries[k-1].Op == Equal) {
+			layout.Changes = append(layout.Changes, len(lines))
+		}
+		if e.Op != Removed && e.New.Kind == "Heading" {
+			layout.Headings[e.New.Line] = len(lines)
+		}
+		for _, ln := range trimBlankLines(strings.Split(out, "\n")) {
+			lines = append(lines, gutter+ln)
+		}
+	}
+	return strings.Join(lines, "\n") + "\n\n", layout, nil
+}
+
+// sameList reports whether a and b are items of one list. An item only in
+// the old document next to one only in the new one is taken to be.
+func sameList(a, b Entry) bool {
+	switch {
+	case a.New != nil && b.New != nil:
+		return a.New.List > 0 && a.New.List == b.New.List
+	case a.Old != nil && b.Old != nil:
+		return a.Old.List > 0 && a.Old.List == b.Old.List
+	case a.Old != nil:
+		return a.Old.List > 0 && b.New.List > 0
+	default:
+		return a.New.List > 0 && b.Old.List > 0
+	}
+}
+
+// mergeWords writes the new block with the words only in the old one and
+// those only in the new one wrapped in delMark and insMark.
+func mergeWords(old, new Block) string {
+	_, wa, _ := splitWords(old)
+	prefix, wb, _ := splitWords(new)
+
+	var parts, del, ins []string
+	if prefix != "" {
+		parts = append(parts, prefix)
+	}
+	flush := func() {
+		if len(del) > 0 {
+			parts = append(parts, delMark+strings.Join(del, " ")+endMark)
+		}
+		if len(ins) > 0 {
+			parts = append(parts, insMark+strings.Join(ins, " ")+endMark)
+		}
+		del, ins = nil, nil
+	}
+	for _, e := range lcs(wa, wb) {
+		switch e.op {
+		case Equal:
+			flush()
+			parts = append(parts, wb[e.j])
+		case Removed:
+			del = append(del, wa[e.i])
+		case Added:
+			ins = append(ins, wb[e.j])
+		}
+	}
+	flush()
+	return strings.Join(parts, " ")
+}
+
+// markWords replaces the marks left by mergeWords with styles. Glamour
+// styles every word and resets after it, so the style is set again after
+// each SGR sequence while a mark is open, and closed at the end of a line.
+func markWords(s string) string {
+	var b strings.Builder
+	active := ""
+	for i := 0; i < len(s); {
+		switch {
+		case strings.HasPrefix(s[i:], insMark):
+			active = insStyle
+			b.WriteString(active)
+			i += len(insMark)
+		case strings.HasPrefix(s[i:], delMark):
+			active = delStyle
+			b.WriteString(active)
+			i += len(delMark)
+		case strings.HasPrefix(s[i:], endMark):
+			active = ""
+			b.WriteString(endStyle)
+			i += len(endMark)
+		case s[i] == '\n' && active != "":
+			b.WriteString(endStyle + "\n" + active)
+			i++
+		case s[i] == '\x1b' && i+1 < len(s) && s[i+1] == '[':
+			j := i + 2
+			for j < len(s) && (s[j] < 0x40 || s[j] > 0x7e) {
+				j++
+			}
+			if j == len(s) {
+				b.WriteString(s[i:])
+				return b.String()
+			}
+			b.WriteString(s[i : j+1])
+			if s[j] == 'm' && active != "" {
+				b.WriteString(active)
+			}
+			i = j + 1
+		default:
+			b.WriteByte(s[i])
+			i++
+		}
+	}
+	return b.String()
+}
+
+func trimBlankLines(lines []string) []string {
+	blank := func(ln string) bool { return strings.TrimSpace(xansi.Strip(ln)) == "" }
+	for len(lines) > 0 && blank(lines[0]) {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && blank(lines[len(lines)-1]) {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
@@ -0,0 +1,308 @@
+// Package export converts Markdown documents to machine-readable formats.
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/simota/md/internal/mdblock"
+	"github.com/simota/md/internal/outline"
+	"github.com/simota/md/internal/render"
+	"github.com/yuin/goldmark"
+	gast "github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	east "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// ASTVersion is bumped whenever the JSON shape changes incompatibly.
+const ASTVersion = 1
+
+// AST is the JSON form of a parsed document.
+type AST struct {
+	Version     int          `json:"version"`
+	FrontMatter *FrontMatter `json:"front_matter,omitempty"`
+	Document    *Node        `json:"document"`
+}
+
+type FrontMatter struct {
+	Format string             `json:"format"`
+	Fields []FrontMatterField `json:"fields"`
+}
+
+type FrontMatterField struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Node is one goldmark AST node. Only the fields relevant to its kind are
+// set; line numbers are 1-based and refer to the original source.
+type Node struct {
+	Kind      string `json:"kind"`
+	StartLine int    `json:"start_line,omitempty"`
+	EndLine   int    `json:"end_line,omitempty"`
+
+	Level       int    `json:"level,omitempty"` // headings, emphasis
+	Slug        string `json:"slug,omitempty"`  // headings, GitHub-style anchor
+	Text        string `json:"text,omitempty"`
+	LineBreak   string `json:"line_break,omitempty"` // soft|hard, after a text node
+	Destination string `json:"destination,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Language    string `json:"language,omitempty"`
+	Info        string `json:"info,omitempty"`
+	Label       string `json:"label,omitempty"` // footnotes
+	Index       int    `json:"index,omitempty"` // footnotes
+
+	Ordered   bool   `json:"ordered,omitempty"`
+	ListStart int    `json:"list_start,omitempty"`
+	Marker    string `json:"marker,omitempty"`
+	Tight     bool   `json:"tight,omitempty"`
+	Checked   *bool  `json:"checked,omitempty"`
+	Align     string `json:"align,omitempty"`
+
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Children   []*Node           `json:"children,omitempty"`
+}
+
+// ASTJSON parses md with the same extensions the renderer uses (plus
+// footnotes) and returns the tree as indented JSON.
+func ASTJSON(md string) ([]byte, error) {
+	out, err := json.MarshalIndent(ParseAST(md), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encode ast: %w", err)
+	}
+	return append(out, '\n'), nil
+}
+
+// ParseAST parses md into its JSON-ready tree. Front matter is reported
+// separately and does not show up as nodes.
+func ParseAST(md string) *AST {
+	md = strings.ReplaceAll(strings.TrimPrefix(md, "\ufeff"), "\r\n", "\n")
+	out := &AST{Version: ASTVersion}
+	if meta, body, ok := render.SplitFrontMatter(md); ok {
+		fm := &FrontMatter{Format: meta.Format, Fields: []FrontMatterField{}}
+		for _, f := range meta.Fields {
+			fm.Fields = append(fm.Fields, FrontMatterField{Key: f.Key, Value: f.Value})
+		}
+		out.FrontMatter = fm
+		md = body
+	}
+
+	src := []byte(md)
+	gm := goldmark.New(goldmark.WithExtensions(extension.GFM, extension.DefinitionList, extension.Footnote))
+	doc := gm.Parser().Parse(text.NewReader(src))
+
+	c := &converter{src: src, slugger: outline.NewSlugger()}
+	for i, b := range src {
+		if b == '\n' {
+			c.lineStarts = append(c.lineStarts, i+1)
+		}
+	}
+	c.lineStarts = append([]int{0}, c.lineStarts...)
+	out.Document = c.node(doc)
+	out.Document.StartLine, out.Document.EndLine = 1, len(c.lineStarts)
+	if strings.HasSuffix(md, "\n") {
+		out.Document.EndLine--
+	}
+	return out
+}
+
+type converter struct {
+	src        []byte
+	lineStarts []int // byte offset of each line
+	slugger    *outline.Slugger
+	last       int // last line seen, for nodes without a source position
+}
+
+// line returns the 1-based line containing byte offset off.
+func (c *converter) line(off int) int {
+	return sort.SearchInts(c.lineStarts, off+1)
+}
+
+func (c *converter) lineText(n int) string {
+	if n < 1 || n > len(c.lineStarts) {
+		return ""
+	}
+	end := len(c.src)
+	if n < len(c.lineStarts) {
+		end = c.lineStarts[n] - 1
+	}
+	return string(c.src[c.lineStarts[n-1]:end])
+}
+
+func (c *converter) node(n gast.Node) *Node {
+	out := &Node{Kind: n.Kind().String()}
+	c.fill(out, n)
+	if out.StartLine > 0 {
+		c.last = max(c.last, out.StartLine)
+	}
+
+	for ch := n.FirstChild(); ch != nil; ch = ch.NextSibling() {
+		child := c.node(ch)
+		out.Children = append(out.Children, child)
+		if child.StartLine == 0 {
+			continue
+		}
+		if out.StartLine == 0 || child.StartLine < out.StartLine {
+			out.StartLine = child.StartLine
+		}
+		out.EndLine = max(out.EndLine, child.EndLine)
+	}
+	if out.StartLine == 0 && n.Type() == gast.TypeBlock && !n.HasChildren() {
+		// Thematic breaks keep no segments: take the next non-blank line
+		// after what came before.
+		l := c.last + 1
+		for l < len(c.lineStarts) && strings.TrimSpace(c.lineText(l)) == "" {
+			l++
+		}
+		out.StartLine, out.EndLine = l, l
+	}
+	if out.EndLine > 0 {
+		c.last = max(c.last, out.EndLine)
+	}
+
+	if attrs := n.Attributes(); len(attrs) > 0 {
+		out.Attributes = map[string]string{}
+		for _, a := range attrs {
+			switch v := a.Value.(type) {
+			case []byte:
+				out.Attributes[string(a.Name)] = string(v)
+			default:
+				out.Attributes[string(a.Name)] = fmt.Sprint(v)
+			}
+		}
+	}
+	return out
+}
+
+// fill sets the kind-specific fields and, where the node has its own
+// segments, the line range.
+func (c *converter) fill(out *Node, n gast.Node) {
+	if n.Type() == gast.TypeBlock && n.Lines().Len() > 0 {
+		lines := n.Lines()
+		out.StartLine = c.line(lines.At(0).Start)
+		out.EndLine = c.line(lines.At(lines.Len() - 1).Start)
+	}
+
+	switch n := n.(type) {
+	case *gast.Heading:
+		raw := string(n.Lines().Value(c.src))
+		out.Level = n.Level
+		out.Slug = c.slugger.Slug(raw)
+		out.Text = c.plainText(n)
+		if out.EndLine > 0 && !strings.HasPrefix(strings.TrimLeft(c.lineText(out.StartLine), " "), "#") {
+			out.EndLine++ // setext underline
+		}
+	case *gast.FencedCodeBlock:
+		out.Language = string(n.Language(c.src))
+		if n.Info != nil {
+			out.Info = string(n.Info.Segment.Value(c.src))
+			out.StartLine = c.line(n.Info.Segment.Start)
+			if out.EndLine == 0 {
+				out.EndLine = out.StartLine
+			}
+		} else if out.StartLine > 0 {
+			out.StartLine--
+		}
+		out.Text = string(n.Lines().Value(c.src))
+		if out.StartLine > 0 && mdblock.IsFence(strings.TrimLeft(c.lineText(out.EndLine+1), " ")) {
+			out.EndLine++
+		}
+	case *gast.CodeBlock:
+		out.Text = string(n.Lines().Value(c.src))
+	case *gast.HTMLBlock:
+		out.Text = string(n.Lines().Value(c.src))
+		if n.HasClosure() {
+			out.Text += string(n.ClosureLine.Value(c.src))
+			out.EndLine = c.line(n.ClosureLine.Start)
+		}
+	case *gast.List:
+		out.Ordered = n.IsOrdered()
+		if n.IsOrdered() {
+			out.ListStart = n.Start
+		}
+		out.Marker = string(n.Marker)
+		out.Tight = n.IsTight
+	case *gast.Text:
+		c.segment(out, n.Segment)
+		out.Text = string(n.Value(c.src))
+		switch {
+		case n.HardLineBreak():
+			out.LineBreak = "hard"
+		case n.SoftLineBreak():
+			out.LineBreak = "soft"
+		}
+	case *gast.String:
+		out.Text = string(n.Value)
+	case *gast.CodeSpan:
+		out.Text = c.plainText(n)
+	case *gast.RawHTML:
+		var b strings.Builder
+		for i := 0; i < n.Segments.Len(); i++ {
+			seg := n.Segments.At(i)
+			if i == 0 {
+				c.segment(out, seg)
+			}
+			b.Write(seg.Value(c.src))
+		}
+		out.Text = b.String()
+	case *gast.Emphasis:
+		out.Level = n.Level
+	case *gast.Link:
+		out.Destination = string(n.Destination)
+		out.Title = string(n.Title)
+		out.Text = c.plainText(n)
+	case *gast.Image:
+		out.Destination = string(n.Destination)
+		out.Title = string(n.Title)
+		out.Text = c.plainText(n)
+	case *gast.AutoLink:
+		out.Destination = string(n.URL(c.src))
+		out.Text = string(n.Label(c.src))
+	case *east.TaskCheckBox:
+		checked := n.IsChecked
+		out.Checked = &checked
+	case *east.TableCell:
+		if n.Alignment != east.AlignNone {
+			out.Align = n.Alignment.String()
+		}
+	case *east.Footnote:
+		out.Label = string(n.Ref)
+		out.Index = n.Index
+	case *east.FootnoteLink:
+		out.Index = n.Index
+	}
+}
+
+func (c *converter) segment(out *Node, seg text.Segment) {
+	if seg.Start < seg.Stop || seg.Start > 0 {
+		out.StartLine = c.line(seg.Start)
+		out.EndLine = c.line(max(seg.Start, seg.Stop-1))
+	}
+}
+
+// plainText concatenates the text below n, with soft line breaks as spaces.
+func (c *converter) plainText(n gast.Node) string {
+	var b bytes.Buffer
+	_ = gast.Walk(n, func(ch gast.Node, entering bool) (gast.WalkStatus, error) {
+		if !entering {
+			return gast.WalkContinue, nil
+		}
+		switch t := ch.(type) {
+		case *gast.Text:
+			b.Write(t.Value(c.src))
+			if t.SoftLineBreak() || t.HardLineBreak() {
+				b.WriteByte(' ')
+			}
+		case *gast.String:
+			b.Write(t.Value)
+		case *gast.AutoLink:
+			b.Write(t.Label(c.src))
+		}
+		return gast.WalkContinue, nil
+	})
+	return strings.TrimSpace(b.String())
+}
@@ -0,0 +1,89 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestASTJSON_Golden(t *testing.T) {
+	md := "# Hi\n\n```sh\necho\n```\n"
+	got, err := ASTJSON(md)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "version": 1,
+  "document": {
+    "kind": "Document",
+    "start_line": 1,
+    "end_line": 5,
+    "children": [
+      {
+        "kind": "Heading",
+        "start_line": 1,
+        "end_line": 1,
+        "level": 1,
+        "slug": "hi",
+        "text": "Hi",
+        "children": [
+          {
+            "kind": "Text",
+            "start_line": 1,
+            "end_line": 1,
+            "text": "Hi"
+          }
+        ]
+      },
+      {
+        "kind": "FencedCodeBlock",
+        "start_line": 3,
+        "end_line": 5,
+        "text": "echo\n",
+        "language": "sh",
+        "info": "sh"
+      }
+    ]
+  }
+}
+`
+	if string(got) != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestParseAST_KeepsSourceLines(t *testing.T) {
+	md := "---\ntitle: T\n---\n# A\n\n---\n\nSee [docs](../x.md).\n\n## A\n\nSetext\n------\n"
+	ast := ParseAST(md)
+	if ast.FrontMatter == nil || ast.FrontMatter.Fields[0].Value != "T" {
+		t.Fatalf("front matter = %+v", ast.FrontMatter)
+	}
+
+	var got []string
+	var walk func(n *Node)
+	walk = func(n *Node) {
+		switch n.Kind {
+		case "Heading":
+			got = append(got, fmt.Sprintf("%s %s %d-%d", n.Kind, n.Slug, n.StartLine, n.EndLine))
+		case "ThematicBreak":
+			got = append(got, fmt.Sprintf("%s %d", n.Kind, n.StartLine))
+		case "Link":
+			got = append(got, fmt.Sprintf("%s %s %d", n.Kind, n.Destination, n.StartLine))
+		}
+		for _, c := range n.Children {
+			walk(c)
+		}
+	}
+	walk(ast.Document)
+
+	want := []string{
+		"Heading a 4-4",
+		"ThematicBreak 6",
+		"Link ../x.md 8",
+		"Heading a-1 10-10",
+		"Heading setext 12-13",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,177 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"html/template"
+	"regexp"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/simota/md/internal/outline"
+	"github.com/simota/md/internal/render"
+	"github.com/yuin/goldmark"
+	gast "github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	gmhtml "github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+)
+
+// HTMLOptions configures HTML pages.
+type HTMLOptions struct {
+	Title string
+	// Style is auto|dark|light; auto follows the browser's color scheme.
+	Style string
+	// Head is extra markup for <head> (e.g. a live reload script).
+	Head template.HTML
+}
+
+// HTMLBody renders md to an HTML fragment. Headings get GitHub-style ids, so
+// #anchors work as they do on GitHub; alerts become styled callouts; front
+// matter becomes a table. Raw HTML is kept but sanitized as on GitHub:
+// scripts, event handlers, forms and javascript: URLs are dropped.
+func HTMLBody(md string) (string, error) {
+	meta, body, _ := render.SplitFrontMatter(md)
+	md = meta.Markdown() + body
+	md = render.RewriteAlerts(md, alertHTML)
+
+	gm := goldmark.New(
+		goldmark.WithExtensions(extension.GFM, extension.DefinitionList, extension.Footnote),
+		goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+		goldmark.WithRendererOptions(gmhtml.WithUnsafe()),
+	)
+	ctx := parser.NewContext(parser.WithIDs(&headingIDs{outline.NewSlugger()}))
+	src := []byte(md)
+	doc := gm.Parser().Parse(text.NewReader(src), parser.WithContext(ctx))
+
+	var buf bytes.Buffer
+	if err := gm.Renderer().Render(&buf, src, doc); err != nil {
+		return "", fmt.Errorf("render html: %w", err)
+	}
+	return htmlPolicy.Sanitize(buf.String()), nil
+}
+
+// htmlPolicy is what pages may contain: user-generated content markup plus
+// the classes, heading ids and task list checkboxes the renderer emits.
+var htmlPolicy = func() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.AllowStyling()
+	p.AllowAttrs("id").Globally()
+	p.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	p.AllowAttrs("checked", "disabled").OnElements("input")
+	p.RequireNoFollowOnLinks(false)
+	return p
+}()
+
+// headingIDs hands goldmark the same slugs `md toc` links to.
+type headingIDs struct{ s *outline.Slugger }
+
+func (h *headingIDs) Generate(value []byte, _ gast.NodeKind) []byte {
+	return []byte(h.s.Slug(string(value)))
+}
+
+func (h *headingIDs) Put([]byte) {}
+
+// alertHTML wraps an alert's body in a div. The blank lines let goldmark
+// parse the body as Markdown between the raw HTML lines.
+func alertHTML(kind, title, body string) string {
+	_, icon, label, _ := render.AlertLabel(kind)
+	if strings.TrimSpace(title) != "" {
+		label = title
+	}
+	return fmt.Sprintf("<div class=\"alert alert-%s\">\n<p class=\"alert-title\">%s %s</p>\n\n%s\n\n</div>",
+		kind, icon, html.EscapeString(label), body)
+}
+
+// HTML renders md as a complete, self-contained page.
+func HTML(md string, opts HTMLOptions) (string, error) {
+	body, err := HTMLBody(md)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	err = pageTemplate.Execute(&buf, struct {
+		Title string
+		CSS   template.CSS
+		Head  template.HTML
+		Body  template.HTML
+	}{opts.Title, template.CSS(PageCSS(opts.Style)), opts.Head, template.HTML(body)})
+	if err != nil {
+		return "", fmt.Errorf("render html: %w", err)
+	}
+	return buf.String(), nil
+}
+
+var pageTemplate = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>{{.CSS}}</style>
+{{.Head}}
+</head>
+<body>
+<main>
+{{.Body}}
+</main>
+</body>
+</html>
+`))
+
+// PageCSS styles pages with the editorial palette. "auto" switches between
+// light and dark with the browser's preference.
+func PageCSS(style string) string {
+	vars := func(dark bool) string {
+		p := render.EditorialPalette(dark)
+		bg, border := "#FFFFFF", "#E2E2E2"
+		if dark {
+			bg, border = "#111111", "#2A2A2A"
+		}
+		var b strings.Builder
+		fmt.Fprintf(&b, "--bg:%s;--fg:%s;--muted:%s;--accent:%s;--code-bg:%s;--border:%s;",
+			bg, p.Fg, p.Muted, p.Accent, p.CodeBg, border)
+		for _, k := range []string{"note", "tip", "important", "warning", "caution"} {
+			fmt.Fprintf(&b, "--alert-%s:%s;", k, p.Alerts[k])
+		}
+		return b.String()
+	}
+
+	var css strings.Builder
+	switch strings.ToLower(strings.TrimSpace(style)) {
+	case "dark":
+		fmt.Fprintf(&css, ":root{%s}\n", vars(true))
+	case "light":
+		fmt.Fprintf(&css, ":root{%s}\n", vars(false))
+	default:
+		fmt.Fprintf(&css, ":root{%s}\n@media (prefers-color-scheme: dark){:root{%s}}\n", vars(false), vars(true))
+	}
+	css.WriteString(baseCSS)
+	return css.String()
+}
+
+const baseCSS = `body{margin:0;background:var(--bg);color:var(--fg);font:16px/1.65 -apple-system,BlinkMacSystemFont,"Segoe UI",Helvetica,Arial,sans-serif}
+main{max-width:46rem;margin:0 auto;padding:2.5rem 1.25rem 4rem}
+h1,h2,h3,h4,h5{color:var(--accent);line-height:1.3;margin:2rem 0 .75rem}
+h6{color:var(--muted)}
+h1{font-size:2rem}h2{font-size:1.5rem;border-bottom:1px solid var(--border);padding-bottom:.25rem}
+a{color:var(--accent)}
+code,pre{font-family:ui-monospace,SFMono-Regular,Menlo,Consolas,monospace;font-size:.9em;background:var(--code-bg)}
+code{padding:.1em .35em;border-radius:4px}
+pre{padding:1rem;overflow-x:auto;border-radius:6px;color:var(--muted)}
+pre code{padding:0;background:none;color:var(--fg)}
+blockquote{margin:1rem 0;padding:0 1rem;border-left:3px solid var(--border);color:var(--muted)}
+table{border-collapse:collapse;margin:1rem 0}
+th,td{border:1px solid var(--border);padding:.35rem .75rem}
+hr{border:0;border-top:1px solid var(--border);margin:2rem 0}
+img{max-width:100%}
+.alert{margin:1rem 0;padding:.5rem 1rem;border-left:4px solid;border-radius:4px;background:var(--code-bg)}
+.alert-title{font-weight:600;margin:.25rem 0}
+.alert-note{border-color:var(--alert-note)}.alert-note .alert-title{color:var(--alert-note)}
+.alert-tip{border-color:var(--alert-tip)}.alert-tip .alert-title{color:var(--alert-tip)}
+.alert-important{border-color:var(--alert-important)}.alert-important .alert-title{color:var(--alert-important)}
+.alert-warning{border-color:var(--alert-warning)}.alert-warning .alert-title{color:var(--alert-warning)}
+.alert-caution{border-color:var(--alert-caution)}.alert-caution .alert-title{color:var(--alert-caution)}
+`
@@ -0,0 +1,67 @@
+package export
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHTMLBody_SlugsAlertsFrontMatter(t *testing.T) {
+	md := "---\ntitle: Doc\n---\n# Hello, World!\n\n## Hello, World!\n\n> [!WARNING]\n> Be **careful**.\n\n<span id=\"raw\">raw</span>\n"
+	got, err := HTMLBody(md)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`<h1 id="hello-world">`,
+		`<h2 id="hello-world-1">`,
+		`<div class="alert alert-warning">`,
+		`<strong>careful</strong>`,
+		`<span id="raw">`,
+		"Doc",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "[!WARNING]") {
+		t.Errorf("alert marker left in output:\n%s", got)
+	}
+}
+
+func TestHTML_Page(t *testing.T) {
+	got, err := HTML("# Hi\n", HTMLOptions{Title: "a <b>", Style: "dark", Head: "<script>x()</script>"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"<title>a &lt;b&gt;</title>",
+		"<script>x()</script>",
+		"--bg:#111111",
+		`<h1 id="hi">Hi</h1>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+	if strings.Contains(got, "prefers-color-scheme") {
+		t.Error("dark style should not follow the browser scheme")
+	}
+}
+
+func TestHTMLBody_SanitizesRawHTML(t *testing.T) {
+	md := "# 見出し\n\n- [x] done\n\n<script>alert(1)</script>\n\n<img src=\"a.png\" onerror=\"fetch('/')\">\n\n<details><summary>More</summary>body</details>\n\n[x](javascript:alert(1))\n"
+	got, err := HTMLBody(md)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`<h1 id="見出し">`, `<input checked="" disabled="" type="checkbox"`, `<img src="a.png">`, "<details><summary>More</summary>"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+	for _, bad := range []string{"<script", "onerror", "javascript:"} {
+		if strings.Contains(got, bad) {
+			t.Errorf("%q left in:\n%s", bad, got)
+		}
+	}
+}
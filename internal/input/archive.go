@@ -0,0 +1,173 @@
+package input
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// archiveExts are the archive types that can be opened as "archive:member".
+var archiveExts = []string{".zip", ".tar", ".tar.gz", ".tgz", ".tar.bz2", ".tbz2", ".tar.zst", ".tzst"}
+
+func isArchive(name string) bool {
+	lower := strings.ToLower(name)
+	for _, ext := range archiveExts {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitArchiveArg splits "docs.zip:guide/intro.md" into the archive and the
+// member path. It only matches when the archive exists, so plain file names
+// containing ':' keep working.
+func splitArchiveArg(arg string) (archive, member string, ok bool) {
+	for i := len(arg) - 1; i > 0; i-- {
+		if arg[i] != ':' {
+			continue
+		}
+		archive, member = arg[:i], arg[i+1:]
+		if member == "" || !isArchive(archive) {
+			continue
+		}
+		if st, err := os.Stat(archive); err == nil && !st.IsDir() {
+			return archive, member, true
+		}
+	}
+	return "", "", false
+}
+
+// archiveSource is one file inside a zip or tar archive.
+type archiveSource struct {
+	archive string
+	member  string
+}
+
+func (s archiveSource) Title() string {
+	return filepath.Base(s.archive) + ":" + s.member
+}
+
+// Dir is the archive's directory; relative images inside the archive are
+// not extracted.
+func (s archiveSource) Dir() string { return filepath.Dir(s.archive) }
+
+func (s archiveSource) ContentType() string { return ContentTypeOf(s.member) }
+
+func (s archiveSource) ReadAll() ([]byte, error) {
+	want := cleanMember(s.member)
+	var (
+		b     []byte
+		names []string
+		err   error
+	)
+	if strings.HasSuffix(strings.ToLower(s.archive), ".zip") {
+		b, names, err = readZipMember(s.archive, want)
+	} else {
+		b, names, err = readTarMember(s.archive, want)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", s.archive, err)
+	}
+	if b == nil {
+		return nil, notInArchiveError(s.archive, s.member, names)
+	}
+	return b, nil
+}
+
+func cleanMember(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(name)), "/")
+}
+
+// readZipMember returns the member's content, or nil and the Markdown files
+// in the archive when it is missing.
+func readZipMember(archive, want string) ([]byte, []string, error) {
+	zr, err := zip.OpenReader(archive)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer zr.Close()
+	var names []string
+	for _, f := range zr.File {
+		name := cleanMember(f.Name)
+		if name != want || f.FileInfo().IsDir() {
+			names = append(names, name)
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, nil, err
+		}
+		defer rc.Close()
+		b, err := readMaybeCompressed(rc)
+		return b, nil, err
+	}
+	return nil, names, nil
+}
+
+func readTarMember(archive, want string) ([]byte, []string, error) {
+	f, err := os.Open(archive)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	r, closeFn, err := decompressReader(f)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer closeFn()
+
+	tr := tar.NewReader(r)
+	var names []string
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil, names, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		name := cleanMember(hdr.Name)
+		if name != want || hdr.Typeflag == tar.TypeDir {
+			names = append(names, name)
+			continue
+		}
+		b, err := readMaybeCompressed(tr)
+		return b, nil, err
+	}
+}
+
+func notInArchiveError(archive, member string, names []string) error {
+	var md []string
+	for _, n := range names {
+		if isMarkdownName(n) {
+			md = append(md, n)
+		}
+	}
+	sort.Strings(md)
+	if len(md) == 0 {
+		return fmt.Errorf("%s: no file %q", archive, member)
+	}
+	const maxListed = 10
+	more := ""
+	if len(md) > maxListed {
+		more = fmt.Sprintf(", ... (%d more)", len(md)-maxListed)
+		md = md[:maxListed]
+	}
+	return fmt.Errorf("%s: no file %q (Markdown files: %s%s)", archive, member, strings.Join(md, ", "), more)
+}
+
+func isMarkdownName(name string) bool {
+	switch strings.ToLower(filepath.Ext(stripCompressedExt(name))) {
+	case ".md", ".markdown", ".mdown", ".mkd":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,144 @@
+package input
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func readSource(t *testing.T, arg string) (Source, string) {
+	t.Helper()
+	src, err := ResolveSource([]string{arg}, os.Stdin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := src.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return src, string(b)
+}
+
+func gzipBytes(t *testing.T, s string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(s)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestResolveSource_Compressed(t *testing.T) {
+	dir := t.TempDir()
+	bz2, _ := hex.DecodeString("425a6839314159265359e6708384000001598000104800100010000010200021800c0247aee2ee48a70a121cce107080")
+	enc, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{
+		"a.md.gz":  gzipBytes(t, "# gzip\n"),
+		"b.md.bz2": bz2,
+		"c.md.zst": enc.EncodeAll([]byte("# zstd\n"), nil),
+		// Sniffed by content, not by name.
+		"d.md": gzipBytes(t, "# sniffed\n"),
+		// Starts like bzip2 but is text.
+		"e.md": []byte("BZh91 is a model number.\n"),
+	}
+	want := map[string]string{"a.md.gz": "# gzip\n", "b.md.bz2": "# bz2\n", "c.md.zst": "# zstd\n", "d.md": "# sniffed\n", "e.md": "BZh91 is a model number.\n"}
+	for name, data := range files {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, got := readSource(t, p); got != want[name] {
+			t.Errorf("%s: got %q, want %q", name, got, want[name])
+		}
+		if IsCompressed(data) != (name != "e.md") {
+			t.Errorf("IsCompressed(%s) = %v", name, IsCompressed(data))
+		}
+	}
+	if got := ContentTypeOf("data.csv.gz"); got != TypeCSV {
+		t.Errorf("ContentTypeOf(data.csv.gz) = %q", got)
+	}
+}
+
+func TestReadMaybeCompressed_Limit(t *testing.T) {
+	defer func(n int) { maxDecompressed = n }(maxDecompressed)
+	maxDecompressed = 1 << 10
+	if _, err := readMaybeCompressed(bytes.NewReader(gzipBytes(t, strings.Repeat("a", 1<<10)))); err != nil {
+		t.Fatalf("at the limit: %v", err)
+	}
+	if _, err := readMaybeCompressed(bytes.NewReader(gzipBytes(t, strings.Repeat("a", 1<<10+1)))); err == nil {
+		t.Fatal("expected an error above the limit")
+	}
+}
+
+func TestResolveSource_ArchiveMember(t *testing.T) {
+	dir := t.TempDir()
+
+	zipPath := filepath.Join(dir, "docs.zip")
+	var zbuf bytes.Buffer
+	zw := zip.NewWriter(&zbuf)
+	for name, body := range map[string]string{"guide/intro.md": "# Intro\n", "README.md": "# Top\n"} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(body))
+	}
+	zw.Close()
+	if err := os.WriteFile(zipPath, zbuf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tgzPath := filepath.Join(dir, "docs.tar.gz")
+	var tbuf bytes.Buffer
+	tw := tar.NewWriter(&tbuf)
+	body := "# Packed\n"
+	tw.WriteHeader(&tar.Header{Name: "./man/tool.md", Mode: 0o644, Size: int64(len(body))})
+	tw.Write([]byte(body))
+	tw.Close()
+	if err := os.WriteFile(tgzPath, gzipBytes(t, tbuf.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	src, got := readSource(t, zipPath+":guide/intro.md")
+	if got != "# Intro\n" || src.Title() != "docs.zip:guide/intro.md" {
+		t.Errorf("zip: %q, title %q", got, src.Title())
+	}
+	if _, got := readSource(t, tgzPath+":man/tool.md"); got != "# Packed\n" {
+		t.Errorf("tar.gz: %q", got)
+	}
+
+	src, err := ResolveSource([]string{zipPath + ":nope.md"}, os.Stdin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = src.ReadAll()
+	if err == nil || !strings.Contains(err.Error(), `no file "nope.md" (Markdown files: README.md, guide/intro.md)`) {
+		t.Errorf("missing member: %v", err)
+	}
+}
+
+func TestResolveSource_ColonInFileName(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, "notes.zip:draft.md")
+	if err := os.WriteFile(p, []byte("# plain\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if src, got := readSource(t, p); got != "# plain\n" || !IsFile(src) {
+		t.Errorf("got %q from %T", got, src)
+	}
+}
@@ -0,0 +1,96 @@
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// maxDecompressed bounds what a compressed input may expand to, so a small
+// compression bomb cannot exhaust memory.
+var maxDecompressed = 256 << 20
+
+// isBzip2 reports whether head starts a bzip2 stream: "BZh", the block size
+// digit and the magic of the first block (or of the end of an empty
+// stream). Text that merely starts with "BZh" is not taken for one.
+func isBzip2(head []byte) bool {
+	if len(head) < 10 || string(head[:3]) != "BZh" || head[3] < '1' || head[3] > '9' {
+		return false
+	}
+	magic := string(head[4:10])
+	return magic == "\x31\x41\x59\x26\x53\x59" || magic == "\x17\x72\x45\x38\x50\x90"
+}
+
+// IsCompressed reports whether data starts with gzip, bzip2 or zstd magic
+// bytes, i.e. whether reading it as a source would decompress it.
+func IsCompressed(data []byte) bool {
+	return bytes.HasPrefix(data, gzipMagic) || isBzip2(data) || bytes.HasPrefix(data, zstdMagic)
+}
+
+// compressedExts are stripped before looking at a file's real extension.
+var compressedExts = []string{".gz", ".bz2", ".zst"}
+
+// stripCompressedExt turns "README.md.gz" into "README.md".
+func stripCompressedExt(name string) string {
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, c := range compressedExts {
+		if ext == c {
+			return strings.TrimSuffix(name, name[len(name)-len(ext):])
+		}
+	}
+	return name
+}
+
+// decompressReader returns a reader for the decompressed stream when r
+// starts with gzip, bzip2 or zstd magic bytes, and r itself otherwise.
+func decompressReader(r io.Reader) (io.Reader, func(), error) {
+	br := bufio.NewReader(r)
+	head, _ := br.Peek(10)
+	switch {
+	case bytes.HasPrefix(head, gzipMagic):
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, nil, fmt.Errorf("gzip: %w", err)
+		}
+		return zr, func() { zr.Close() }, nil
+	case isBzip2(head):
+		return bzip2.NewReader(br), func() {}, nil
+	case bytes.HasPrefix(head, zstdMagic):
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, nil, fmt.Errorf("zstd: %w", err)
+		}
+		return zr, zr.Close, nil
+	}
+	return br, func() {}, nil
+}
+
+// readMaybeCompressed reads r to the end, decompressing it if needed. It
+// fails when the (decompressed) content exceeds maxDecompressed.
+func readMaybeCompressed(r io.Reader) ([]byte, error) {
+	dr, closeFn, err := decompressReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer closeFn()
+	b, err := io.ReadAll(io.LimitReader(dr, int64(maxDecompressed)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) > maxDecompressed {
+		return nil, fmt.Errorf("input is larger than %d MiB", maxDecompressed>>20)
+	}
+	return b, nil
+}
@@ -0,0 +1,129 @@
+package input
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/japanese"
+	xunicode "golang.org/x/text/encoding/unicode"
+)
+
+// sniffLen bounds how much of the input encoding detection looks at.
+const sniffLen = 64 << 10
+
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// Decode converts data to UTF-8 and drops a byte order mark. name is an
+// encoding label such as "shift_jis", "euc-jp", "windows-1252" or
+// "utf-16le" (WHATWG names and aliases are accepted); "" or "auto" detects
+// it: a byte order mark wins, valid UTF-8 is kept, UTF-16 is recognized by
+// its zero bytes, and anything else is read as Shift_JIS or EUC-JP when that
+// decodes cleanly to Japanese text, and as Windows-1252 otherwise.
+func Decode(data []byte, name string) ([]byte, error) {
+	var enc encoding.Encoding
+	switch name = strings.ToLower(strings.TrimSpace(name)); name {
+	case "", "auto":
+		enc = detectEncoding(data)
+	default:
+		var err error
+		if enc, err = htmlindex.Get(name); err != nil {
+			return nil, fmt.Errorf("invalid --encoding=%q (use auto|utf-8|utf-16le|utf-16be|shift_jis|euc-jp|windows-1252|...)", name)
+		}
+	}
+	if enc == nil || enc == xunicode.UTF8 {
+		return bytes.TrimPrefix(data, utf8BOM), nil
+	}
+	out, err := enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return nil, fmt.Errorf("decode input: %w", err)
+	}
+	return bytes.TrimPrefix(out, utf8BOM), nil
+}
+
+// detectEncoding guesses the encoding of data; nil means UTF-8.
+func detectEncoding(data []byte) encoding.Encoding {
+	switch {
+	case bytes.HasPrefix(data, utf8BOM):
+		return nil
+	case bytes.HasPrefix(data, []byte{0xff, 0xfe}):
+		return xunicode.UTF16(xunicode.LittleEndian, xunicode.UseBOM)
+	case bytes.HasPrefix(data, []byte{0xfe, 0xff}):
+		return xunicode.UTF16(xunicode.BigEndian, xunicode.UseBOM)
+	}
+
+	sample := data[:min(len(data), sniffLen)]
+	if utf8.Valid(sample) || (len(data) > sniffLen && validUTF8Prefix(sample)) {
+		return nil
+	}
+
+	// Text in UTF-16 without a byte order mark: mostly-ASCII content has a
+	// zero byte in every other position.
+	var zeros [2]int
+	for i, b := range sample {
+		if b == 0 {
+			zeros[i%2]++
+		}
+	}
+	if pairs := len(sample) / 2; pairs > 0 {
+		switch {
+		case zeros[1]*10 >= pairs*4:
+			return xunicode.UTF16(xunicode.LittleEndian, xunicode.IgnoreBOM)
+		case zeros[0]*10 >= pairs*4:
+			return xunicode.UTF16(xunicode.BigEndian, xunicode.IgnoreBOM)
+		}
+	}
+
+	var best encoding.Encoding
+	bestScore := 0
+	for _, enc := range []encoding.Encoding{japanese.ShiftJIS, japanese.EUCJP} {
+		if score, ok := japaneseScore(enc, sample); ok && (best == nil || score < bestScore) {
+			best, bestScore = enc, score
+		}
+	}
+	if best != nil {
+		return best
+	}
+	return charmap.Windows1252
+}
+
+// validUTF8Prefix reports whether sample, cut from longer input, is UTF-8
+// apart from a rune cut off at its end.
+func validUTF8Prefix(sample []byte) bool {
+	for i := 1; i < utf8.UTFMax && i < len(sample); i++ {
+		if utf8.Valid(sample[:len(sample)-i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// japaneseScore decodes sample with enc. ok is false unless it decodes
+// without errors to text with kana or kanji in it. Lower scores are more
+// plausible: half-width katakana is rare in prose, but it is what EUC-JP
+// looks like when read as Shift_JIS.
+func japaneseScore(enc encoding.Encoding, sample []byte) (score int, ok bool) {
+	out, err := enc.NewDecoder().Bytes(sample)
+	if err != nil {
+		return 0, false
+	}
+	// The sample may end in the middle of a character.
+	s := strings.TrimRight(string(out), "\ufffd")
+	hasJapanese := false
+	for _, r := range s {
+		switch {
+		case r == utf8.RuneError:
+			return 0, false
+		case r >= 0xff61 && r <= 0xff9f:
+			score++
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana):
+			hasJapanese = true
+		}
+	}
+	return score, hasJapanese
+}
@@ -0,0 +1,58 @@
+package input
+
+import (
+	"testing"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	xunicode "golang.org/x/text/encoding/unicode"
+)
+
+func encode(t *testing.T, enc encoding.Encoding, s string) []byte {
+	t.Helper()
+	out, err := enc.NewEncoder().Bytes([]byte(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestDecode_Detects(t *testing.T) {
+	const ja = "# 設計メモ\n\n日本語のドキュメントです。カタカナとひらがな。\n"
+	const latin = "# Café\n\nNaïve façade, “quoted”.\n"
+	cases := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"utf-8", []byte(ja), ja},
+		{"utf-8 bom", append([]byte("\xef\xbb\xbf"), ja...), ja},
+		{"utf-16le bom", encode(t, xunicode.UTF16(xunicode.LittleEndian, xunicode.UseBOM), ja), ja},
+		{"utf-16be bom", encode(t, xunicode.UTF16(xunicode.BigEndian, xunicode.UseBOM), ja), ja},
+		{"utf-16le", encode(t, xunicode.UTF16(xunicode.LittleEndian, xunicode.IgnoreBOM), latin), latin},
+		{"shift_jis", encode(t, japanese.ShiftJIS, ja), ja},
+		{"euc-jp", encode(t, japanese.EUCJP, ja), ja},
+		{"windows-1252", encode(t, charmap.Windows1252, latin), latin},
+	}
+	for _, c := range cases {
+		got, err := Decode(c.data, "auto")
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if string(got) != c.want {
+			t.Errorf("%s: got %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestDecode_Override(t *testing.T) {
+	data := encode(t, charmap.Windows1252, "café")
+	got, err := Decode(data, "latin1")
+	if err != nil || string(got) != "café" {
+		t.Fatalf("got %q, %v", got, err)
+	}
+	if _, err := Decode(data, "klingon"); err == nil {
+		t.Fatal("want an error for an unknown encoding")
+	}
+}
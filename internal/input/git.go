@@ -0,0 +1,78 @@
+package input
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// GitPrefix marks a "git:REV:path" argument.
+const GitPrefix = "git:"
+
+// gitSource is a file as it was at a git revision, read with
+// `git cat-file blob`.
+type gitSource struct {
+	rev  string
+	path string // as given: relative to the working directory, or absolute
+}
+
+// GitSource returns the file at path as of revision rev (a commit, tag,
+// branch or anything else git accepts, e.g. HEAD~3 or v1.2.0).
+func GitSource(rev, path string) Source {
+	return gitSource{rev: rev, path: path}
+}
+
+// parseGitArg splits "git:REV:path". The revision ends at the first ':'.
+func parseGitArg(arg string) (rev, path string, ok bool) {
+	rest, ok := strings.CutPrefix(arg, GitPrefix)
+	if !ok {
+		return "", "", false
+	}
+	rev, path, ok = strings.Cut(rest, ":")
+	if !ok || rev == "" || path == "" {
+		return "", "", false
+	}
+	return rev, path, true
+}
+
+func (s gitSource) Title() string { return filepath.Base(s.path) + " @ " + s.rev }
+
+// Dir is the file's directory in the working tree; images are shown as
+// they are now, not as they were at the revision.
+func (s gitSource) Dir() string { return filepath.Dir(s.path) }
+
+func (s gitSource) ContentType() string { return ContentTypeOf(s.path) }
+
+func (s gitSource) ReadAll() ([]byte, error) {
+	// The revision starts git's last argument; one starting with "-" would
+	// be taken for an option.
+	if strings.HasPrefix(s.rev, "-") {
+		return nil, fmt.Errorf("invalid revision %q", s.rev)
+	}
+	// "REV:./path" is relative to the directory git runs in. Run it in the
+	// file's directory when that still exists, so absolute paths work too.
+	dir, object := "", s.rev+":./"+filepath.ToSlash(s.path)
+	if st, err := os.Stat(filepath.Dir(s.path)); err == nil && st.IsDir() {
+		dir, object = filepath.Dir(s.path), s.rev+":./"+filepath.Base(s.path)
+	}
+	cmd := exec.Command("git", "cat-file", "blob", object)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if errors.Is(err, exec.ErrNotFound) {
+		return nil, errors.New("git:REV:path needs git in PATH")
+	}
+	if err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return nil, fmt.Errorf("read %s at %s: %s", s.path, s.rev, msg)
+	}
+	return readMaybeCompressed(bytes.NewReader(out))
+}
@@ -0,0 +1,78 @@
+package input
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func gitRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=t", "-c", "user.email=t@example.com"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(body string) {
+		t.Helper()
+		p := filepath.Join(dir, "docs", "api.md")
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git("init", "-q")
+	write("# API v1\n")
+	git("add", ".")
+	git("commit", "-q", "-m", "v1")
+	git("tag", "v1")
+	write("# API v2\n")
+	git("commit", "-q", "-am", "v2")
+	return dir
+}
+
+func TestResolveSource_GitRevision(t *testing.T) {
+	dir := gitRepo(t)
+	t.Chdir(dir)
+
+	src, got := readSource(t, "git:HEAD~1:docs/api.md")
+	if got != "# API v1\n" || src.Title() != "api.md @ HEAD~1" || IsFile(src) {
+		t.Errorf("got %q, title %q", got, src.Title())
+	}
+	// Absolute paths and tags work too.
+	if _, got := readSource(t, "git:v1:"+filepath.Join(dir, "docs", "api.md")); got != "# API v1\n" {
+		t.Errorf("absolute path: %q", got)
+	}
+	if got, _ := GitSource("HEAD", "docs/api.md").ReadAll(); string(got) != "# API v2\n" {
+		t.Errorf("HEAD: %q", got)
+	}
+
+	_, err := GitSource("HEAD", "docs/missing.md").ReadAll()
+	if err == nil || !strings.Contains(err.Error(), "read docs/missing.md at HEAD:") {
+		t.Errorf("missing file: %v", err)
+	}
+}
+
+func TestGitSource_RejectsOptionRevisions(t *testing.T) {
+	dir := gitRepo(t)
+	t.Chdir(dir)
+	out := filepath.Join(t.TempDir(), "x")
+	_, err := GitSource("--output="+out, "docs/api.md").ReadAll()
+	if err == nil || !strings.Contains(err.Error(), "invalid revision") {
+		t.Errorf("err = %v", err)
+	}
+	if _, err := os.Stat(out); err == nil {
+		t.Error("git wrote the --output file")
+	}
+}
@@ -0,0 +1,152 @@
+package input
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// ImageSupport describes how the terminal can show images.
+type ImageSupport struct {
+	Protocol   string // kitty|iterm|sixel|blocks, "" = leave images as links
+	CellWidth  int    // pixels per cell, 0 if unknown
+	CellHeight int
+}
+
+// ParseImagesMode validates the --images flag.
+func ParseImagesMode(v string) (string, error) {
+	switch s := strings.ToLower(strings.TrimSpace(v)); s {
+	case "", "auto":
+		return "auto", nil
+	case "kitty", "iterm", "sixel", "blocks", "off":
+		return s, nil
+	default:
+		return "", fmt.Errorf("invalid --images=%q (use auto|kitty|iterm|sixel|blocks|off)", v)
+	}
+}
+
+// ResolveImages turns an --images mode into the protocol to use. "auto"
+// detects the terminal on out; images are left as links when out is not a
+// terminal.
+func ResolveImages(mode string, out *os.File) ImageSupport {
+	switch mode {
+	case "off":
+		return ImageSupport{}
+	case "auto":
+		if !IsTerminal(out) {
+			return ImageSupport{}
+		}
+		return DetectImageSupport()
+	}
+	s := ImageSupport{Protocol: mode}
+	if IsTerminal(out) {
+		s.CellWidth, s.CellHeight = queryTerminal().cellSize()
+	}
+	return s
+}
+
+// DetectImageSupport checks well-known environment variables first and then
+// asks the terminal directly (kitty graphics query, cell size and device
+// attributes). It falls back to half blocks.
+func DetectImageSupport() ImageSupport {
+	proto := imageProtocolFromEnv(os.Getenv)
+	reply := queryTerminal()
+	if proto == "" {
+		switch {
+		case reply.kitty:
+			proto = "kitty"
+		case reply.sixel:
+			proto = "sixel"
+		default:
+			proto = "blocks"
+		}
+	}
+	cw, ch := reply.cellSize()
+	return ImageSupport{Protocol: proto, CellWidth: cw, CellHeight: ch}
+}
+
+func imageProtocolFromEnv(getenv func(string) string) string {
+	termName := getenv("TERM")
+	prog := getenv("TERM_PROGRAM")
+	switch {
+	case getenv("KITTY_WINDOW_ID") != "", termName == "xterm-kitty",
+		termName == "xterm-ghostty", prog == "ghostty":
+		return "kitty"
+	case prog == "iTerm.app", getenv("LC_TERMINAL") == "iTerm2", prog == "WezTerm":
+		return "iterm"
+	case strings.Contains(termName, "foot"), prog == "mlterm":
+		return "sixel"
+	}
+	return ""
+}
+
+type terminalReply struct {
+	kitty        bool
+	sixel        bool
+	cellW, cellH int
+}
+
+func (r terminalReply) cellSize() (int, int) { return r.cellW, r.cellH }
+
+var (
+	kittyOKRe   = regexp.MustCompile(`\x1b_Gi=31;OK`)
+	cellSizeRe  = regexp.MustCompile(`\x1b\[6;(\d+);(\d+)t`)
+	deviceAttrs = regexp.MustCompile(`\x1b\[\?([\d;]*)c`)
+)
+
+// queryTerminal sends the graphics and size queries followed by a primary
+// device attributes request, which every terminal answers, so reading can
+// stop at that reply (or after a short timeout).
+func queryTerminal() terminalReply {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return terminalReply{}
+	}
+	defer tty.Close()
+
+	state, err := term.MakeRaw(int(tty.Fd()))
+	if err != nil {
+		return terminalReply{}
+	}
+	defer func() { _ = term.Restore(int(tty.Fd()), state) }()
+
+	if err := tty.SetReadDeadline(time.Now().Add(300 * time.Millisecond)); err != nil {
+		// Without a deadline a silent terminal would block forever.
+		return terminalReply{}
+	}
+	if _, err := tty.WriteString("\x1b_Gi=31,s=1,v=1,a=q,t=d,f=24;AAAA\x1b\\\x1b[16t\x1b[c"); err != nil {
+		return terminalReply{}
+	}
+
+	var buf []byte
+	chunk := make([]byte, 256)
+	for !deviceAttrs.Match(buf) {
+		n, err := tty.Read(chunk)
+		buf = append(buf, chunk[:n]...)
+		if err != nil {
+			break
+		}
+	}
+	return parseTerminalReply(string(buf))
+}
+
+func parseTerminalReply(s string) terminalReply {
+	r := terminalReply{kitty: kittyOKRe.MatchString(s)}
+	if m := cellSizeRe.FindStringSubmatch(s); m != nil {
+		r.cellH, _ = strconv.Atoi(m[1])
+		r.cellW, _ = strconv.Atoi(m[2])
+	}
+	if m := deviceAttrs.FindStringSubmatch(s); m != nil {
+		for _, p := range strings.Split(m[1], ";") {
+			if p == "4" {
+				r.sixel = true
+			}
+		}
+	}
+	return r
+}
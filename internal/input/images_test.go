@@ -0,0 +1,23 @@
+package input
+
+import "testing"
+
+func TestParseTerminalReply(t *testing.T) {
+	r := parseTerminalReply("\x1b_Gi=31;OK\x1b\\\x1b[6;20;10t\x1b[?62;4;22c")
+	if !r.kitty || !r.sixel || r.cellW != 10 || r.cellH != 20 {
+		t.Fatalf("unexpected reply: %+v", r)
+	}
+	if r := parseTerminalReply("\x1b[?1;2c"); r.kitty || r.sixel {
+		t.Fatalf("plain VT100 reply should support nothing: %+v", r)
+	}
+}
+
+func TestImageProtocolFromEnv(t *testing.T) {
+	env := map[string]string{"TERM_PROGRAM": "iTerm.app"}
+	if got := imageProtocolFromEnv(func(k string) string { return env[k] }); got != "iterm" {
+		t.Fatalf("expected iterm, got %q", got)
+	}
+	if got := imageProtocolFromEnv(func(string) string { return "" }); got != "" {
+		t.Fatalf("expected no protocol, got %q", got)
+	}
+}
@@ -6,37 +6,83 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"golang.org/x/term"
 )
 
 type Source interface {
 	Title() string
+	// Dir is the directory relative references (images, links) resolve
+	// against; "" means the working directory.
+	Dir() string
+	// ContentType is TypeMarkdown, TypeCSV or TypeTSV.
+	ContentType() string
 	ReadAll() ([]byte, error)
 }
 
+// Content types, detected from the file extension.
+const (
+	TypeMarkdown = "markdown"
+	TypeCSV      = "csv"
+	TypeTSV      = "tsv"
+)
+
+// ContentTypeOf returns the content type for a file name; a compression
+// suffix (data.csv.gz) is ignored.
+func ContentTypeOf(name string) string {
+	switch strings.ToLower(filepath.Ext(stripCompressedExt(name))) {
+	case ".csv":
+		return TypeCSV
+	case ".tsv", ".tab":
+		return TypeTSV
+	}
+	return TypeMarkdown
+}
+
 type fileSource struct {
 	path string
 }
 
 func (s fileSource) Title() string { return filepath.Base(s.path) }
 
+func (s fileSource) Dir() string { return filepath.Dir(s.path) }
+
+func (s fileSource) ContentType() string { return ContentTypeOf(s.path) }
+
+// ReadAll reads the file, decompressing gzip, bzip2 and zstd content.
 func (s fileSource) ReadAll() ([]byte, error) {
-	b, err := os.ReadFile(s.path)
+	f, err := os.Open(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("read file %q: %w", s.path, err)
+	}
+	defer f.Close()
+	b, err := readMaybeCompressed(f)
 	if err != nil {
 		return nil, fmt.Errorf("read file %q: %w", s.path, err)
 	}
 	return b, nil
 }
 
+// IsFile reports whether src reads a file on disk, as opposed to stdin, a
+// git revision or an archive member.
+func IsFile(src Source) bool {
+	_, ok := src.(fileSource)
+	return ok
+}
+
 type stdinSource struct {
 	r io.Reader
 }
 
 func (s stdinSource) Title() string { return "stdin" }
 
+func (s stdinSource) Dir() string { return "" }
+
+func (s stdinSource) ContentType() string { return TypeMarkdown }
+
 func (s stdinSource) ReadAll() ([]byte, error) {
-	b, err := io.ReadAll(s.r)
+	b, err := readMaybeCompressed(s.r)
 	if err != nil {
 		return nil, fmt.Errorf("read stdin: %w", err)
 	}
@@ -54,6 +100,14 @@ func ResolveSource(args []string, stdin *os.File) (Source, error) {
 			}
 			return stdinSource{r: stdin}, nil
 		}
+		if _, err := os.Stat(args[0]); err != nil {
+			if rev, path, ok := parseGitArg(args[0]); ok {
+				return gitSource{rev: rev, path: path}, nil
+			}
+			if archive, member, ok := splitArchiveArg(args[0]); ok {
+				return archiveSource{archive: archive, member: member}, nil
+			}
+		}
 		return fileSource{path: args[0]}, nil
 	}
 
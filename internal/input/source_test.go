@@ -28,3 +28,17 @@ func TestResolveSource_File(t *testing.T) {
 		t.Fatalf("unexpected content: %q", string(b))
 	}
 }
+
+func TestContentTypeOf(t *testing.T) {
+	for name, want := range map[string]string{
+		"README.md":      TypeMarkdown,
+		"data.CSV":       TypeCSV,
+		"sheet.tsv":      TypeTSV,
+		"notes":          TypeMarkdown,
+		"dir.csv/doc.md": TypeMarkdown,
+	} {
+		if got := ContentTypeOf(name); got != want {
+			t.Errorf("ContentTypeOf(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
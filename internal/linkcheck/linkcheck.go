@@ -0,0 +1,326 @@
+// Package linkcheck finds broken links in Markdown files: relative file
+// links, #anchors (against GitHub-style heading slugs) and, optionally,
+// external URLs.
+package linkcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/simota/md/internal/export"
+	"github.com/simota/md/internal/input"
+)
+
+// Problem is a broken link.
+type Problem struct {
+	File   string
+	Line   int // 1-based
+	Target string
+	Reason string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s:%d: %s: %s", p.File, p.Line, p.Target, p.Reason)
+}
+
+// Checker checks links. The zero value checks local links only.
+type Checker struct {
+	// Root resolves site-absolute links ("/docs/x.md"); "" = working directory.
+	Root string
+
+	// External also checks http(s) URLs with HEAD requests (falling back to
+	// GET when HEAD is not allowed).
+	External    bool
+	Concurrency int           // parallel requests, default 8
+	Timeout     time.Duration // per request, default 10s
+	Client      *http.Client  // default http.DefaultClient
+
+	mu      sync.Mutex
+	anchors map[string]map[string]bool // file -> anchors, nil if unreadable
+}
+
+type link struct {
+	file   string
+	line   int
+	target string
+}
+
+// Check parses files and reports every broken link, sorted by file and line.
+func (c *Checker) Check(ctx context.Context, files []string) ([]Problem, error) {
+	var (
+		problems []Problem
+		external []link
+	)
+	for _, f := range files {
+		links, err := c.links(f)
+		if err != nil {
+			return nil, err
+		}
+		for _, l := range links {
+			u, err := url.Parse(l.target)
+			switch {
+			case err != nil:
+				problems = append(problems, Problem{l.file, l.line, l.target, "invalid URL"})
+			case u.Scheme == "http" || u.Scheme == "https":
+				if c.External {
+					external = append(external, l)
+				}
+			case u.Scheme != "" || u.Host != "":
+				// mailto:, tel:, ... cannot be checked.
+			default:
+				if reason := c.checkLocal(l.file, u); reason != "" {
+					problems = append(problems, Problem{l.file, l.line, l.target, reason})
+				}
+			}
+		}
+	}
+	problems = append(problems, c.checkExternal(ctx, external)...)
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		if problems[i].File != problems[j].File {
+			return problems[i].File < problems[j].File
+		}
+		return problems[i].Line < problems[j].Line
+	})
+	return problems, nil
+}
+
+// readDecoded reads a Markdown file as UTF-8, detecting its encoding.
+func readDecoded(file string) ([]byte, error) {
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return input.Decode(raw, "")
+}
+
+// links returns the link and image destinations of a Markdown file.
+func (c *Checker) links(file string) ([]link, error) {
+	raw, err := readDecoded(file)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", file, err)
+	}
+	ast := export.ParseAST(string(raw))
+
+	var out []link
+	var walk func(n *export.Node, line int)
+	walk = func(n *export.Node, line int) {
+		if n.StartLine > 0 {
+			line = n.StartLine
+		}
+		switch n.Kind {
+		case "Link", "Image", "AutoLink":
+			if n.Destination != "" {
+				out = append(out, link{file: file, line: line, target: n.Destination})
+			}
+		}
+		for _, ch := range n.Children {
+			walk(ch, line)
+		}
+	}
+	walk(ast.Document, 1)
+
+	c.mu.Lock()
+	if c.anchors == nil {
+		c.anchors = map[string]map[string]bool{}
+	}
+	c.anchors[filepath.Clean(file)] = anchorsOf(ast, string(raw))
+	c.mu.Unlock()
+	return out, nil
+}
+
+// htmlAnchorRe matches explicit anchors in raw HTML: <a name="x">, id="x".
+var htmlAnchorRe = regexp.MustCompile(`(?i)\s(?:id|name)\s*=\s*["']([^"']+)["']`)
+
+func anchorsOf(ast *export.AST, raw string) map[string]bool {
+	anchors := map[string]bool{}
+	var walk func(n *export.Node)
+	walk = func(n *export.Node) {
+		if n.Kind == "Heading" && n.Slug != "" {
+			anchors[n.Slug] = true
+		}
+		for _, ch := range n.Children {
+			walk(ch)
+		}
+	}
+	walk(ast.Document)
+	for _, m := range htmlAnchorRe.FindAllStringSubmatch(raw, -1) {
+		anchors[strings.ToLower(m[1])] = true
+	}
+	return anchors
+}
+
+// anchorsFor returns the anchors of a Markdown file, parsing it on first use.
+func (c *Checker) anchorsFor(file string) map[string]bool {
+	file = filepath.Clean(file)
+	c.mu.Lock()
+	a, ok := c.anchors[file]
+	c.mu.Unlock()
+	if ok {
+		return a
+	}
+	if raw, err := readDecoded(file); err == nil {
+		a = anchorsOf(export.ParseAST(string(raw)), string(raw))
+	}
+	c.mu.Lock()
+	if c.anchors == nil {
+		c.anchors = map[string]map[string]bool{}
+	}
+	c.anchors[file] = a
+	c.mu.Unlock()
+	return a
+}
+
+// checkLocal returns why a relative link from file is broken, or "".
+func (c *Checker) checkLocal(file string, u *url.URL) string {
+	target := file
+	if u.Path != "" {
+		switch {
+		case strings.HasPrefix(u.Path, "/"):
+			root := c.Root
+			if root == "" {
+				root = "."
+			}
+			target = filepath.Join(root, filepath.FromSlash(u.Path))
+		default:
+			target = filepath.Join(filepath.Dir(file), filepath.FromSlash(u.Path))
+		}
+		st, err := os.Stat(target)
+		if err != nil {
+			return "file not found"
+		}
+		if st.IsDir() {
+			return "" // anchors into directories cannot be checked
+		}
+	}
+	if u.Fragment == "" || !isMarkdown(target) {
+		return ""
+	}
+	anchors := c.anchorsFor(target)
+	if anchors == nil {
+		return "file not readable"
+	}
+	frag := strings.ToLower(u.Fragment)
+	if anchors[frag] {
+		return ""
+	}
+	if u.Path == "" {
+		return fmt.Sprintf("no heading for #%s", u.Fragment)
+	}
+	return fmt.Sprintf("no heading for #%s in %s", u.Fragment, filepath.ToSlash(u.Path))
+}
+
+func isMarkdown(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".markdown", ".mdown", ".mkd":
+		return true
+	}
+	return false
+}
+
+// checkExternal requests each distinct URL once, at most Concurrency at a
+// time, and reports every link to a URL that failed.
+func (c *Checker) checkExternal(ctx context.Context, links []link) []Problem {
+	if len(links) == 0 {
+		return nil
+	}
+	byURL := map[string][]link{}
+	var urls []string
+	for _, l := range links {
+		u := l.target
+		if i := strings.IndexByte(u, '#'); i >= 0 {
+			u = u[:i]
+		}
+		if _, ok := byURL[u]; !ok {
+			urls = append(urls, u)
+		}
+		byURL[u] = append(byURL[u], l)
+	}
+
+	n := c.Concurrency
+	if n <= 0 {
+		n = 8
+	}
+	reasons := make([]string, len(urls))
+	sem := make(chan struct{}, n)
+	var wg sync.WaitGroup
+	for i, u := range urls {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			reasons[i] = c.fetch(ctx, u)
+		}()
+	}
+	wg.Wait()
+
+	var out []Problem
+	for i, u := range urls {
+		if reasons[i] == "" {
+			continue
+		}
+		for _, l := range byURL[u] {
+			out = append(out, Problem{l.file, l.line, l.target, reasons[i]})
+		}
+	}
+	return out
+}
+
+// fetch returns why u is unreachable, or "".
+func (c *Checker) fetch(ctx context.Context, u string) string {
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	status, err := c.request(ctx, client, http.MethodHead, u, timeout)
+	if err == nil && (status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented) {
+		status, err = c.request(ctx, client, http.MethodGet, u, timeout)
+	}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timed out"
+	case err != nil:
+		var ue *url.Error
+		if errors.As(err, &ue) {
+			err = ue.Err
+		}
+		return err.Error()
+	case status >= 400:
+		return fmt.Sprintf("HTTP %d", status)
+	}
+	return ""
+}
+
+func (c *Checker) request(ctx context.Context, client *http.Client, method, u string, timeout time.Duration) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", "md-check-links")
+	resp, err := client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,117 @@
+package linkcheck
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/text/encoding/japanese"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, body := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func problemLines(dir string, ps []Problem) string {
+	var out []string
+	for _, p := range ps {
+		rel, _ := filepath.Rel(dir, p.File)
+		p.File = filepath.ToSlash(rel)
+		out = append(out, p.String())
+	}
+	return strings.Join(out, "\n")
+}
+
+func TestCheck_LocalLinksAndAnchors(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"README.md": "# Top\n\n## Sub Part\n\n" +
+			"[ok](docs/a.md#intro) [bad](docs/a.md#nope) [gone](missing.md)\n" +
+			"[self](#sub-part) [self-bad](#nah) [mail](mailto:a@b.c) ![img](img.png)\n",
+		"docs/a.md": "# Intro\n\n<a name=\"legacy\"></a>\n\n[up](../README.md#top) [old](#legacy)\n\n```\n[not a link](x.md)\n```\n",
+	})
+	c := &Checker{}
+	ps, err := c.Check(context.Background(), []string{filepath.Join(dir, "README.md"), filepath.Join(dir, "docs/a.md")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{
+		"README.md:5: docs/a.md#nope: no heading for #nope in docs/a.md",
+		"README.md:5: missing.md: file not found",
+		"README.md:6: #nah: no heading for #nah",
+		"README.md:6: img.png: file not found",
+	}, "\n")
+	if got := problemLines(dir, ps); got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestCheck_ShiftJISAnchors(t *testing.T) {
+	sjis, err := japanese.ShiftJIS.NewEncoder().String("# 概要\n\n[ok](#概要) [bad](#詳細)\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := writeFiles(t, map[string]string{"ja.md": sjis})
+	ps, err := (&Checker{}).Check(context.Background(), []string{filepath.Join(dir, "ja.md")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := problemLines(dir, ps), "ja.md:3: #詳細: no heading for #詳細"; got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestCheck_ExternalURLs(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+		case "/get-only":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		case "/slow":
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	dir := writeFiles(t, map[string]string{
+		"a.md": "[a](" + srv.URL + "/ok) [b](" + srv.URL + "/get-only)\n\n" +
+			"[c](" + srv.URL + "/gone) and again <" + srv.URL + "/gone>\n\n[d](" + srv.URL + "/slow)\n",
+	})
+	c := &Checker{External: true, Concurrency: 2, Timeout: 100 * time.Millisecond}
+	ps, err := c.Check(context.Background(), []string{filepath.Join(dir, "a.md")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{
+		"a.md:3: " + srv.URL + "/gone: HTTP 404",
+		"a.md:3: " + srv.URL + "/gone: HTTP 404",
+		"a.md:5: " + srv.URL + "/slow: timed out",
+	}, "\n")
+	if got := problemLines(dir, ps); got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,62 @@
+package lint
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ConfigFile is looked up in the working directory and its parents.
+const ConfigFile = ".mdlint.json"
+
+// Config turns rules on or off by ID:
+//
+//	{"rules": {"trailing-whitespace": false}}
+//
+// Rules not mentioned are enabled.
+type Config struct {
+	Rules map[string]bool `json:"rules"`
+}
+
+func (c Config) Enabled(id string) bool {
+	on, ok := c.Rules[id]
+	return !ok || on
+}
+
+// LoadConfig reads a config file and rejects unknown rule IDs, which are
+// almost always typos.
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("read config: %w", err)
+	}
+	var c Config
+	if err := json.Unmarshal(data, &c); err != nil {
+		return Config{}, fmt.Errorf("parse %s: %w", path, err)
+	}
+	for id := range c.Rules {
+		if _, ok := lookupRule(id); !ok {
+			return Config{}, fmt.Errorf("%s: unknown rule %q", path, id)
+		}
+	}
+	return c, nil
+}
+
+// FindConfig returns the nearest ConfigFile in dir or its parents, or "".
+func FindConfig(dir string) string {
+	for {
+		p := filepath.Join(dir, ConfigFile)
+		if _, err := os.Stat(p); err == nil {
+			return p
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return ""
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
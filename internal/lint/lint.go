@@ -0,0 +1,171 @@
+// Package lint reports common Markdown problems and fixes the mechanical ones.
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	gast "github.com/yuin/goldmark/ast"
+
+	"github.com/simota/md/internal/mdblock"
+	"github.com/simota/md/internal/outline"
+	"github.com/simota/md/internal/render"
+)
+
+// Diagnostic is one problem found in a document. Line and Col are 1-based;
+// Col counts characters, not bytes.
+type Diagnostic struct {
+	File    string
+	Line    int
+	Col     int
+	Rule    string
+	Message string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s (%s)", d.File, d.Line, d.Col, d.Message, d.Rule)
+}
+
+// Lint checks md with every rule enabled in cfg. file is only used to label
+// the diagnostics.
+func Lint(file, md string, cfg Config) []Diagnostic {
+	d := newDocument(md)
+	var out []Diagnostic
+	for _, r := range Rules {
+		if !cfg.Enabled(r.ID) {
+			continue
+		}
+		for _, p := range r.check(d) {
+			out = append(out, Diagnostic{File: file, Line: p.line + 1, Col: d.col(p.line, p.off), Rule: r.ID, Message: p.msg})
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Line != out[j].Line {
+			return out[i].Line < out[j].Line
+		}
+		return out[i].Col < out[j].Col
+	})
+	return out
+}
+
+// Fix applies the fixes of every enabled, fixable rule and returns the new
+// document. Line endings are preserved.
+func Fix(md string, cfg Config) string {
+	crlf := strings.Contains(md, "\r\n")
+	for _, r := range Rules {
+		if r.fix == nil || !cfg.Enabled(r.ID) {
+			continue
+		}
+		d := newDocument(md)
+		r.fix(d)
+		md = strings.Join(d.lines, "\n")
+	}
+	if crlf {
+		md = strings.ReplaceAll(md, "\n", "\r\n")
+	}
+	return md
+}
+
+// problem is a rule finding before it is turned into a Diagnostic: a 0-based
+// line and a byte offset into it.
+type problem struct {
+	line int
+	off  int
+	msg  string
+}
+
+// document is md split into lines, with the parts rules should not look at
+// marked: front matter, code blocks and HTML blocks are skipped, and code
+// spans are blanked in text so patterns cannot match inside them.
+type document struct {
+	lines    []string
+	skip     []bool   // front matter, code or HTML blocks (including fence lines)
+	text     []string // lines with code spans replaced by spaces, same byte length
+	headings []outline.Heading
+	unclosed int // 0-based line of a fence that is never closed, or -1
+}
+
+func newDocument(md string) *document {
+	md = strings.ReplaceAll(md, "\r\n", "\n")
+	d := &document{lines: strings.Split(md, "\n")}
+
+	// Front matter lines are blanked in body, keeping line numbers.
+	_, body, _ := render.SplitFrontMatter(md)
+	bodyLines := strings.Split(body, "\n")
+	d.headings = outline.Parse(body)
+	d.unclosed = outline.UnclosedFence(body)
+
+	d.skip = make([]bool, len(d.lines))
+	d.text = make([]string, len(d.lines))
+	blocks := mdblock.Lines(body, gast.KindCodeBlock, gast.KindHTMLBlock)
+	var fence mdblock.FenceTracker
+	for i, ln := range d.lines {
+		if i < len(bodyLines) && bodyLines[i] == "" && ln != "" {
+			d.skip[i] = true
+			continue
+		}
+		if fence.Update(ln) || blocks[i] {
+			d.skip[i] = true
+			continue
+		}
+		d.text[i] = blankCodeSpans(ln)
+	}
+	return d
+}
+
+// col converts a byte offset in line i to a 1-based character column.
+func (d *document) col(i, off int) int {
+	ln := d.lines[i]
+	off = min(max(off, 0), len(ln))
+	return utf8.RuneCountInString(ln[:off]) + 1
+}
+
+// blankCodeSpans replaces `code spans` (delimiters included) with spaces.
+func blankCodeSpans(s string) string {
+	if !strings.Contains(s, "`") {
+		return s
+	}
+	b := []byte(s)
+	for i := 0; i < len(b); {
+		if b[i] != '`' {
+			i++
+			continue
+		}
+		n := 0
+		for i+n < len(b) && b[i+n] == '`' {
+			n++
+		}
+		end := -1
+		for j := i + n; j < len(b); {
+			if b[j] != '`' {
+				j++
+				continue
+			}
+			m := 0
+			for j+m < len(b) && b[j+m] == '`' {
+				m++
+			}
+			if m == n {
+				end = j + m
+				break
+			}
+			j += m
+		}
+		if end < 0 {
+			i += n
+			continue
+		}
+		for k := i; k < end; k++ {
+			b[k] = ' '
+		}
+		i = end
+	}
+	return string(b)
+}
+
+// blank replaces s[start:end] with spaces.
+func blank(s string, start, end int) string {
+	return s[:start] + strings.Repeat(" ", end-start) + s[end:]
+}
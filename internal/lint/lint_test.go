@@ -0,0 +1,94 @@
+package lint
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func lintLines(md string, cfg Config) []string {
+	var out []string
+	for _, d := range Lint("doc.md", md, cfg) {
+		out = append(out, d.String())
+	}
+	return out
+}
+
+func TestLint_ReportsPositions(t *testing.T) {
+	md := "# A\n\n### B\n\nSee https://x.io, `https://code` and <https://ok.io>.\n\n[](https://x) ![](a.png)\n\n# A\n"
+	got := lintLines(md, Config{})
+	want := []string{
+		"doc.md:3:1: heading level skips from H1 to H3 (heading-increment)",
+		"doc.md:5:5: bare URL https://x.io (use <url> or [text](url)) (bare-url)",
+		"doc.md:7:1: link has no text (empty-link)",
+		"doc.md:7:15: image has no alt text (missing-alt)",
+		"doc.md:9:1: duplicate heading \"A\" (first on line 1) (duplicate-heading)",
+		"doc.md:9:1: multiple top-level headings (first on line 1) (single-h1)",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestLint_SkipsCodeAndFrontMatter(t *testing.T) {
+	md := "---\ntitle: T \n---\n# T\n\n```\nhttps://x.io   \n# T\n```\n\nhard  \nbreak\n"
+	if got := lintLines(md, Config{}); len(got) != 0 {
+		t.Fatalf("unexpected diagnostics:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func TestLint_UnclosedFence(t *testing.T) {
+	got := lintLines("# A\n\n  ```go\ncode\n", Config{})
+	want := "doc.md:3:3: code fence is never closed (unclosed-fence)"
+	if len(got) != 1 || got[0] != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestFix_AppliesFixableRules(t *testing.T) {
+	md := "# A \r\n\r\n- a\r\n* b\r\nGo to https://x.io.\r\n"
+	got := Fix(md, Config{})
+	want := "# A\r\n\r\n- a\r\n- b\r\nGo to <https://x.io>.\r\n"
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestFix_LeavesCodeAndHTMLBlocks(t *testing.T) {
+	md := "# A\n\n    * code\n    http://x.com\n\n<div>\nhttp://x.com\n* item\n</div>\n\n- a\n* b\n"
+	got := Fix(md, Config{})
+	want := "# A\n\n    * code\n    http://x.com\n\n<div>\nhttp://x.com\n* item\n</div>\n\n- a\n- b\n"
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestConfig_DisablesRules(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ConfigFile)
+	if err := os.WriteFile(path, []byte(`{"rules": {"single-h1": false}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sub := filepath.Join(dir, "docs")
+	if err := os.Mkdir(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if got := FindConfig(sub); got != path {
+		t.Fatalf("FindConfig = %q, want %q", got, path)
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := lintLines("# A\n\n# B\n", cfg); len(got) != 0 {
+		t.Fatalf("unexpected diagnostics: %q", got)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"rules": {"single-h2": false}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(path); err == nil || !strings.Contains(err.Error(), `unknown rule "single-h2"`) {
+		t.Fatalf("err = %v", err)
+	}
+}
@@ -0,0 +1,270 @@
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/simota/md/internal/outline"
+)
+
+// Rule is one lint check. Rules with a fix are applied by Fix.
+type Rule struct {
+	ID          string
+	Description string
+	check       func(*document) []problem
+	fix         func(*document)
+}
+
+func (r Rule) Fixable() bool { return r.fix != nil }
+
+// Rules lists every rule in the order diagnostics are produced.
+var Rules = []Rule{
+	{ID: "heading-increment", Description: "heading levels only go down one at a time", check: checkHeadingIncrement},
+	{ID: "duplicate-heading", Description: "no two headings with the same text at the same level", check: checkDuplicateHeadings},
+	{ID: "single-h1", Description: "at most one top-level heading", check: checkSingleH1},
+	{ID: "unclosed-fence", Description: "every code fence is closed", check: checkUnclosedFence},
+	{ID: "trailing-whitespace", Description: "no trailing spaces (two spaces for a line break are allowed)", check: checkTrailingWhitespace, fix: fixTrailingWhitespace},
+	{ID: "bare-url", Description: "URLs are written as <url> or [text](url)", check: checkBareURLs, fix: fixBareURLs},
+	{ID: "empty-link", Description: "links have text and a destination", check: checkEmptyLinks},
+	{ID: "missing-alt", Description: "images have alt text", check: checkMissingAlt},
+	{ID: "list-marker", Description: "bullet lists use one marker throughout", check: checkListMarkers, fix: fixListMarkers},
+}
+
+func lookupRule(id string) (Rule, bool) {
+	for _, r := range Rules {
+		if r.ID == id {
+			return r, true
+		}
+	}
+	return Rule{}, false
+}
+
+func checkHeadingIncrement(d *document) []problem {
+	var out []problem
+	prev := 0
+	for _, h := range d.headings {
+		if prev > 0 && h.Level > prev+1 {
+			out = append(out, problem{h.Line, headingOffset(d.lines[h.Line]),
+				fmt.Sprintf("heading level skips from H%d to H%d", prev, h.Level)})
+		}
+		prev = h.Level
+	}
+	return out
+}
+
+func checkDuplicateHeadings(d *document) []problem {
+	var out []problem
+	type key struct {
+		level int
+		text  string
+	}
+	first := map[key]int{}
+	for _, h := range d.headings {
+		k := key{h.Level, strings.ToLower(outline.PlainText(h.Text))}
+		if l, ok := first[k]; ok {
+			out = append(out, problem{h.Line, headingOffset(d.lines[h.Line]),
+				fmt.Sprintf("duplicate heading %q (first on line %d)", outline.PlainText(h.Text), l+1)})
+			continue
+		}
+		first[k] = h.Line
+	}
+	return out
+}
+
+func checkSingleH1(d *document) []problem {
+	var out []problem
+	first := -1
+	for _, h := range d.headings {
+		if h.Level != 1 {
+			continue
+		}
+		if first < 0 {
+			first = h.Line
+			continue
+		}
+		out = append(out, problem{h.Line, headingOffset(d.lines[h.Line]),
+			fmt.Sprintf("multiple top-level headings (first on line %d)", first+1)})
+	}
+	return out
+}
+
+func headingOffset(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
+
+func checkUnclosedFence(d *document) []problem {
+	if d.unclosed < 0 {
+		return nil
+	}
+	return []problem{{d.unclosed, headingOffset(d.lines[d.unclosed]), "code fence is never closed"}}
+}
+
+// trailingWhitespace returns the offset where trailing blanks start, or -1
+// when there are none or they are a two-space hard line break.
+func trailingWhitespace(line string) int {
+	t := strings.TrimRight(line, " \t")
+	if len(t) == len(line) || (t != "" && line[len(t):] == "  ") {
+		return -1
+	}
+	return len(t)
+}
+
+func checkTrailingWhitespace(d *document) []problem {
+	var out []problem
+	for i, ln := range d.lines {
+		if d.skip[i] {
+			continue
+		}
+		if off := trailingWhitespace(ln); off >= 0 {
+			out = append(out, problem{i, off, "trailing whitespace"})
+		}
+	}
+	return out
+}
+
+func fixTrailingWhitespace(d *document) {
+	for i, ln := range d.lines {
+		if d.skip[i] {
+			continue
+		}
+		if off := trailingWhitespace(ln); off >= 0 {
+			d.lines[i] = ln[:off]
+		}
+	}
+}
+
+var (
+	bareURLRe   = regexp.MustCompile(`https?://[^\s<>()\[\]]+`)
+	inlineRe    = regexp.MustCompile(`!?\[[^\]]*\]\([^)]*\)`)
+	angleRe     = regexp.MustCompile(`<[^>]*>`)
+	linkDefRe   = regexp.MustCompile(`^ {0,3}\[[^\]]+\]:`)
+	linkRe      = regexp.MustCompile(`(!?)\[([^\[\]]*)\]\(\s*(<[^>]*>|[^\s)]*)[^)]*\)`)
+	listItemRe  = regexp.MustCompile(`^(\s*)([-*+])[ \t]+\S`)
+	thematicRe  = regexp.MustCompile(`^\s*([-*_])(?:\s*([-*_]))+\s*$`)
+	trailPuncts = ".,;:!?'\""
+)
+
+// bareURLs returns the byte ranges of URLs on line i that are not already
+// part of a link, an autolink or HTML.
+func (d *document) bareURLs(i int) [][]int {
+	s := d.text[i]
+	if d.skip[i] || !strings.Contains(s, "://") || linkDefRe.MatchString(s) {
+		return nil
+	}
+	for _, re := range []*regexp.Regexp{inlineRe, angleRe} {
+		for _, m := range re.FindAllStringIndex(s, -1) {
+			s = blank(s, m[0], m[1])
+		}
+	}
+	var out [][]int
+	for _, m := range bareURLRe.FindAllStringIndex(s, -1) {
+		m[1] = m[0] + len(strings.TrimRight(s[m[0]:m[1]], trailPuncts))
+		out = append(out, m)
+	}
+	return out
+}
+
+func checkBareURLs(d *document) []problem {
+	var out []problem
+	for i := range d.lines {
+		for _, m := range d.bareURLs(i) {
+			out = append(out, problem{i, m[0], fmt.Sprintf("bare URL %s (use <url> or [text](url))", d.lines[i][m[0]:m[1]])})
+		}
+	}
+	return out
+}
+
+func fixBareURLs(d *document) {
+	for i, ln := range d.lines {
+		ms := d.bareURLs(i)
+		for j := len(ms) - 1; j >= 0; j-- {
+			m := ms[j]
+			ln = ln[:m[0]] + "<" + ln[m[0]:m[1]] + ">" + ln[m[1]:]
+		}
+		d.lines[i] = ln
+	}
+}
+
+func checkEmptyLinks(d *document) []problem {
+	var out []problem
+	d.eachLink(func(i, off int, image bool, text, dest string) {
+		if image {
+			return
+		}
+		switch {
+		case strings.TrimSpace(text) == "":
+			out = append(out, problem{i, off, "link has no text"})
+		case dest == "" || dest == "#" || dest == "<>":
+			out = append(out, problem{i, off, "link has no destination"})
+		}
+	})
+	return out
+}
+
+func checkMissingAlt(d *document) []problem {
+	var out []problem
+	d.eachLink(func(i, off int, image bool, text, _ string) {
+		if image && strings.TrimSpace(text) == "" {
+			out = append(out, problem{i, off, "image has no alt text"})
+		}
+	})
+	return out
+}
+
+func (d *document) eachLink(fn func(line, off int, image bool, text, dest string)) {
+	for i, s := range d.text {
+		if d.skip[i] || !strings.Contains(s, "](") {
+			continue
+		}
+		for _, m := range linkRe.FindAllStringSubmatchIndex(s, -1) {
+			text := s[m[4]:m[5]]
+			dest := s[m[6]:m[7]]
+			fn(i, m[0], m[3] > m[2], text, dest)
+		}
+	}
+}
+
+// listMarkers returns, for each bullet list item, its line and the offset of
+// the marker.
+func (d *document) listMarkers() [][2]int {
+	var out [][2]int
+	for i, s := range d.text {
+		if d.skip[i] || thematicRe.MatchString(s) {
+			continue
+		}
+		if m := listItemRe.FindStringSubmatchIndex(s); m != nil {
+			out = append(out, [2]int{i, m[4]})
+		}
+	}
+	return out
+}
+
+func checkListMarkers(d *document) []problem {
+	var out []problem
+	want, first := byte(0), 0
+	for _, lm := range d.listMarkers() {
+		c := d.lines[lm[0]][lm[1]]
+		if want == 0 {
+			want, first = c, lm[0]
+			continue
+		}
+		if c != want {
+			out = append(out, problem{lm[0], lm[1],
+				fmt.Sprintf("list marker %q differs from %q used on line %d", c, want, first+1)})
+		}
+	}
+	return out
+}
+
+func fixListMarkers(d *document) {
+	var want byte
+	for _, lm := range d.listMarkers() {
+		ln := d.lines[lm[0]]
+		if want == 0 {
+			want = ln[lm[1]]
+			continue
+		}
+		d.lines[lm[0]] = ln[:lm[1]] + string(want) + ln[lm[1]+1:]
+	}
+}
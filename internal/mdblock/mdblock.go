@@ -0,0 +1,93 @@
+// Package mdblock finds the parts of Markdown source that line-based passes
+// must leave alone: fenced code, indented code and HTML blocks.
+package mdblock
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	gast "github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// FenceTracker follows fenced code blocks while scanning Markdown line by
+// line. A block only ends at a run of its fence character at least as long
+// as the one that opened it, so ```` can wrap ``` samples.
+type FenceTracker struct {
+	fence string // opening fence run ("```", "~~~~", ...) while inside a block
+}
+
+// Open reports whether the last line seen left a fenced block open.
+func (f *FenceTracker) Open() bool { return f.fence != "" }
+
+// Update consumes one line and reports whether it belongs to a fenced block
+// (including the fence lines themselves).
+func (f *FenceTracker) Update(line string) bool {
+	t := strings.TrimSpace(line)
+	if f.fence != "" {
+		if strings.HasPrefix(t, f.fence) && strings.Trim(t, f.fence[:1]) == "" {
+			f.fence = ""
+		}
+		return true
+	}
+	if IsFence(t) {
+		f.fence = t[:countPrefix(t, t[0])]
+		return true
+	}
+	return false
+}
+
+// IsFence reports whether a trimmed line is a fence line.
+func IsFence(t string) bool {
+	return strings.HasPrefix(t, "```") || strings.HasPrefix(t, "~~~")
+}
+
+// Lines marks the 0-based lines of md that belong to blocks of the given
+// kinds, such as ast.KindCodeBlock or ast.KindHTMLBlock. Whether an indented
+// line is code depends on the list it sits in, so it asks goldmark rather
+// than counting spaces.
+func Lines(md string, kinds ...gast.NodeKind) map[int]bool {
+	src := []byte(strings.ReplaceAll(md, "\r\n", "\n"))
+	starts := []int{0} // byte offset where each line starts
+	for i, c := range src {
+		if c == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	lineOf := func(off int) int { return sort.SearchInts(starts, off+1) - 1 }
+
+	out := map[int]bool{}
+	doc := goldmark.DefaultParser().Parse(text.NewReader(src))
+	_ = gast.Walk(doc, func(n gast.Node, entering bool) (gast.WalkStatus, error) {
+		if !entering || !hasKind(n, kinds) {
+			return gast.WalkContinue, nil
+		}
+		segs := n.Lines()
+		for i := 0; i < segs.Len(); i++ {
+			out[lineOf(segs.At(i).Start)] = true
+		}
+		if h, ok := n.(*gast.HTMLBlock); ok && h.HasClosure() {
+			out[lineOf(h.ClosureLine.Start)] = true
+		}
+		return gast.WalkSkipChildren, nil
+	})
+	return out
+}
+
+func hasKind(n gast.Node, kinds []gast.NodeKind) bool {
+	for _, k := range kinds {
+		if n.Kind() == k {
+			return true
+		}
+	}
+	return false
+}
+
+func countPrefix(s string, ch byte) int {
+	n := 0
+	for i := 0; i < len(s) && s[i] == ch; i++ {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,36 @@
+package mdblock
+
+import (
+	"testing"
+
+	gast "github.com/yuin/goldmark/ast"
+)
+
+func TestFenceTracker_LongerFences(t *testing.T) {
+	lines := []string{"````md", "```go", "x", "```", "````", "after", "~~~", "```", "~~~~", "tail"}
+	want := []bool{true, true, true, true, true, false, true, true, true, false}
+	var f FenceTracker
+	for i, ln := range lines {
+		if got := f.Update(ln); got != want[i] {
+			t.Fatalf("line %d %q: in fence = %v, want %v", i, ln, got, want[i])
+		}
+	}
+	if f.Open() {
+		t.Fatal("fence left open")
+	}
+}
+
+func TestLines_CodeAndHTMLBlocks(t *testing.T) {
+	md := "text\n\n    code\n\n<div>\nhtml\n</div>\n\n<!--\ncomment\n-->\n\n- item\n\n      nested code\n"
+	got := Lines(md, gast.KindCodeBlock, gast.KindHTMLBlock)
+	for _, i := range []int{2, 4, 5, 6, 8, 9, 10, 14} {
+		if !got[i] {
+			t.Errorf("line %d not marked: %v", i, got)
+		}
+	}
+	for _, i := range []int{0, 12} {
+		if got[i] {
+			t.Errorf("line %d marked", i)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+// Package outline extracts the heading structure of a Markdown document and
+// formats it as a table of contents.
+package outline
+
+import (
+	"strings"
+
+	"github.com/simota/md/internal/mdblock"
+)
+
+type Heading struct {
+	Level int
+	Text  string
+	Line  int // 0-based line index in raw markdown
+}
+
+// Parse returns the ATX headings of md, skipping fenced code blocks.
+func Parse(md string) []Heading {
+	hs, _ := scan(md)
+	return hs
+}
+
+// UnclosedFence returns the 0-based line of a code fence that is never
+// closed, or -1. Everything after such a fence is code, so no headings are
+// found there either.
+func UnclosedFence(md string) int {
+	_, open := scan(md)
+	return open
+}
+
+func scan(md string) ([]Heading, int) {
+	lines := strings.Split(strings.ReplaceAll(md, "\r\n", "\n"), "\n")
+
+	var (
+		hs    []Heading
+		fence mdblock.FenceTracker
+	)
+	openLine := -1
+
+	for i, ln := range lines {
+		t := strings.TrimSpace(ln)
+
+		wasOpen := fence.Open()
+		if fence.Update(t) {
+			switch {
+			case !wasOpen:
+				openLine = i
+			case !fence.Open():
+				openLine = -1
+			}
+			continue
+		}
+
+		if !strings.HasPrefix(t, "#") {
+			continue
+		}
+		n := countPrefix(t, '#')
+		if n < 1 || n > 6 {
+			continue
+		}
+		rest := strings.TrimSpace(t[n:])
+		if rest == "" {
+			continue
+		}
+		hs = append(hs, Heading{
+			Level: n,
+			Text:  rest,
+			Line:  i,
+		})
+	}
+
+	return hs, openLine
+}
+
+func countPrefix(s string, ch byte) int {
+	n := 0
+	for i := 0; i < len(s) && s[i] == ch; i++ {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,106 @@
+package outline
+
+import "testing"
+
+func TestSlugger_GitHubCompatible(t *testing.T) {
+	s := NewSlugger()
+	cases := []struct{ in, want string }{
+		{"Hello, World!", "hello-world"},
+		{"`go test` & friends", "go-test--friends"},
+		{"[Links](https://x) are text", "links-are-text"},
+		{"Ünïcödé Heading", "ünïcödé-heading"},
+		{"Usage", "usage"},
+		{"Usage", "usage-1"},
+		{"Usage", "usage-2"},
+		{"Usage 1", "usage-1-1"},
+	}
+	for _, c := range cases {
+		if got := s.Slug(c.in); got != c.want {
+			t.Errorf("Slug(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
+
+func TestTree_NestsAndFiltersLevels(t *testing.T) {
+	md := "# Doc\n\n## A\n\n### A.1\n\n## B\n"
+	entries := Tree(Parse(md), 2, 3)
+
+	got := Markdown(entries)
+	want := "- [A](#a)\n  - [A.1](#a1)\n- [B](#b)\n"
+	if got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestInject_ReplacesMarkedRegion(t *testing.T) {
+	md := "# Doc\n<!-- toc -->\nstale\n<!-- tocstop -->\nbody\n"
+	got, ok := Inject(md, "- [Doc](#doc)\n")
+	if !ok {
+		t.Fatal("expected markers to be found")
+	}
+	want := "# Doc\n<!-- toc -->\n\n- [Doc](#doc)\n\n<!-- tocstop -->\nbody\n"
+	if got != want {
+		t.Fatalf("got:\n%q\nwant:\n%q", got, want)
+	}
+	if _, ok := Inject("# Doc\n", "x"); ok {
+		t.Fatal("expected missing markers to be reported")
+	}
+}
+
+func TestInject_IgnoresMarkersInFences(t *testing.T) {
+	md := "# Doc\n\n```md\n<!-- toc -->\nexample\n<!-- tocstop -->\n```\n\n<!-- toc -->\nstale\n<!-- tocstop -->\n"
+	got, ok := Inject(md, "- [Doc](#doc)\n")
+	if !ok {
+		t.Fatal("expected markers to be found")
+	}
+	want := "# Doc\n\n```md\n<!-- toc -->\nexample\n<!-- tocstop -->\n```\n\n<!-- toc -->\n\n- [Doc](#doc)\n\n<!-- tocstop -->\n"
+	if got != want {
+		t.Fatalf("got:\n%q\nwant:\n%q", got, want)
+	}
+	if _, ok := Inject("```\n<!-- toc -->\n<!-- tocstop -->\n```\n", "x"); ok {
+		t.Fatal("markers inside a fence should not count")
+	}
+}
+
+func TestParse_LongFenceWrapsShortOne(t *testing.T) {
+	md := "# Real\n\n````md\n```\n# Not a heading\n````\n\n## Also real\n"
+	hs := Parse(md)
+	if len(hs) != 2 || hs[1].Text != "Also real" {
+		t.Fatalf("headings = %+v", hs)
+	}
+}
+
+func TestSection_StopsAtSameLevel(t *testing.T) {
+	md := "# Doc\n\n## Install\n\nrun it\n\n```\n## not a heading\n```\n\n### Linux\n\napt\n\n## Usage\n\nmd file\n"
+	got, err := Section(md, "install")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "## Install\n\nrun it\n\n```\n## not a heading\n```\n\n### Linux\n\napt\n"
+	if got != want {
+		t.Fatalf("got:\n%q\nwant:\n%q", got, want)
+	}
+}
+
+func TestSectionPath_SearchesInsideParent(t *testing.T) {
+	md := "# Guide\n\n## Auth\n\nguide auth\n\n# API\n\n## Auth\n\napi auth\n\n## Errors\n"
+	got, err := SectionPath(md, ParseSectionPath("API > Auth"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "## Auth\n\napi auth\n"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestSection_NotFoundSuggests(t *testing.T) {
+	md := "# Installation\n\n# Usage\n\n# License\n"
+	_, err := Section(md, "instalation")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	want := `section "instalation" not found; did you mean: "Installation"`
+	if err.Error() != want {
+		t.Fatalf("got %q, want %q", err.Error(), want)
+	}
+}
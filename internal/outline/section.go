@@ -0,0 +1,130 @@
+package outline
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Section returns the heading matching title and its body, up to the next
+// heading of the same or a higher level. Titles match case-insensitively
+// against the heading's plain text.
+func Section(md, title string) (string, error) {
+	return SectionPath(md, []string{title})
+}
+
+// SectionPath finds nested sections: each title is searched for inside the
+// section found for the previous one ("API", "Auth" matches an "Auth"
+// heading somewhere under "API").
+func SectionPath(md string, path []string) (string, error) {
+	lines := strings.Split(strings.ReplaceAll(md, "\r\n", "\n"), "\n")
+	hs := Parse(md)
+
+	// Headings still in scope and the line range they cover.
+	scope := hs
+	start, end := 0, len(lines)
+	for depth, title := range path {
+		want := normalizeTitle(title)
+		idx := -1
+		for i, h := range scope {
+			if normalizeTitle(PlainText(h.Text)) == want {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			return "", notFoundError(path[:depth+1], scope)
+		}
+		h := scope[idx]
+		start = h.Line
+		rest := scope[idx+1:]
+		scope = nil
+		for i, next := range rest {
+			if next.Level <= h.Level {
+				end = next.Line
+				break
+			}
+			scope = rest[:i+1]
+		}
+	}
+	return strings.TrimRight(strings.Join(lines[start:end], "\n"), "\n") + "\n", nil
+}
+
+// ParseSectionPath splits "API > Auth" into its titles.
+func ParseSectionPath(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ">") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func normalizeTitle(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+func notFoundError(path []string, candidates []Heading) error {
+	title := path[len(path)-1]
+	msg := fmt.Sprintf("section %q not found", strings.Join(path, " > "))
+	if s := closeMatches(title, candidates, 5); len(s) > 0 {
+		msg += "; did you mean: " + strings.Join(s, ", ")
+	}
+	return errors.New(msg)
+}
+
+// closeMatches ranks heading titles by edit distance to title; titles that
+// contain it (or are contained in it) count as close too.
+func closeMatches(title string, hs []Heading, limit int) []string {
+	want := normalizeTitle(title)
+	type scored struct {
+		text string
+		dist int
+	}
+	var cands []scored
+	seen := map[string]bool{}
+	for _, h := range hs {
+		text := PlainText(h.Text)
+		got := normalizeTitle(text)
+		if seen[got] {
+			continue
+		}
+		seen[got] = true
+		d := levenshtein(want, got)
+		if strings.Contains(got, want) || strings.Contains(want, got) {
+			d = min(d, 1)
+		}
+		if d <= max(2, len([]rune(want))/3) {
+			cands = append(cands, scored{text, d})
+		}
+	}
+	sort.SliceStable(cands, func(i, j int) bool { return cands[i].dist < cands[j].dist })
+	var out []string
+	for i := 0; i < len(cands) && i < limit; i++ {
+		out = append(out, fmt.Sprintf("%q", cands[i].text))
+	}
+	return out
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,66 @@
+package outline
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Slugger generates GitHub-compatible heading anchors. Repeated headings get
+// "-1", "-2", ... suffixes in document order, like github-slugger.
+type Slugger struct {
+	seen map[string]int
+}
+
+func NewSlugger() *Slugger { return &Slugger{seen: map[string]int{}} }
+
+// Slug returns the anchor for heading text and records it.
+func (s *Slugger) Slug(text string) string {
+	base := Slug(text)
+	slug := base
+	for {
+		if _, dup := s.seen[slug]; !dup {
+			break
+		}
+		s.seen[base]++
+		slug = base + "-" + strconv.Itoa(s.seen[base])
+	}
+	s.seen[slug] = 0
+	return slug
+}
+
+// Slug converts heading text to an anchor without duplicate tracking:
+// inline Markdown is reduced to its text, letters are lowercased, spaces
+// become hyphens and other punctuation is dropped.
+func Slug(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(PlainText(text)) {
+		switch {
+		case r == ' ':
+			b.WriteByte('-')
+		case r == '-' || r == '_',
+			unicode.IsLetter(r), unicode.IsNumber(r), unicode.Is(unicode.Mn, r):
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+var (
+	closingHashesRe = regexp.MustCompile(`\s+#+\s*$`)
+	inlineImageRe   = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	inlineLinkRe    = regexp.MustCompile(`\[([^\]]*)\](?:\([^)]*\)|\[[^\]]*\])`)
+	htmlTagRe       = regexp.MustCompile(`</?[A-Za-z][^>]*>`)
+)
+
+// PlainText strips the inline Markdown of a heading (closing #s, links,
+// images, code and emphasis markers, HTML tags) leaving the visible text.
+func PlainText(text string) string {
+	text = closingHashesRe.ReplaceAllString(text, "")
+	text = inlineImageRe.ReplaceAllString(text, "$1")
+	text = inlineLinkRe.ReplaceAllString(text, "$1")
+	text = htmlTagRe.ReplaceAllString(text, "")
+	text = strings.NewReplacer("`", "", "**", "", "__", "", "~~", "", "*", "").Replace(text)
+	return strings.TrimSpace(text)
+}
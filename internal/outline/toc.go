@@ -0,0 +1,125 @@
+package outline
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/simota/md/internal/mdblock"
+)
+
+// Entry is a heading in a table of contents, with its anchor and children.
+type Entry struct {
+	Level    int      `json:"level"`
+	Text     string   `json:"text"`
+	Slug     string   `json:"slug"`
+	Line     int      `json:"line"` // 1-based
+	Children []*Entry `json:"children,omitempty"`
+}
+
+// Tree nests headings between minLevel and maxLevel (inclusive) under their
+// closest shallower heading. Slugs are computed over all headings so they
+// match the document's anchors even when some levels are filtered out.
+func Tree(hs []Heading, minLevel, maxLevel int) []*Entry {
+	slugger := NewSlugger()
+	var (
+		roots []*Entry
+		stack []*Entry
+	)
+	for _, h := range hs {
+		slug := slugger.Slug(h.Text)
+		if h.Level < minLevel || h.Level > maxLevel {
+			continue
+		}
+		e := &Entry{Level: h.Level, Text: PlainText(h.Text), Slug: slug, Line: h.Line + 1}
+		for len(stack) > 0 && stack[len(stack)-1].Level >= h.Level {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			roots = append(roots, e)
+		} else {
+			parent := stack[len(stack)-1]
+			parent.Children = append(parent.Children, e)
+		}
+		stack = append(stack, e)
+	}
+	return roots
+}
+
+// Markdown formats entries as a nested list of anchor links.
+func Markdown(entries []*Entry) string {
+	var b strings.Builder
+	walk(entries, 0, func(e *Entry, depth int) {
+		fmt.Fprintf(&b, "%s- [%s](#%s)\n", strings.Repeat("  ", depth), escapeLinkText(e.Text), e.Slug)
+	})
+	return b.String()
+}
+
+// Text formats entries as an indented plain list.
+func Text(entries []*Entry) string {
+	var b strings.Builder
+	walk(entries, 0, func(e *Entry, depth int) {
+		fmt.Fprintf(&b, "%s%s\n", strings.Repeat("  ", depth), e.Text)
+	})
+	return b.String()
+}
+
+// JSON formats entries as an indented JSON array.
+func JSON(entries []*Entry) (string, error) {
+	if entries == nil {
+		entries = []*Entry{}
+	}
+	out, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("encode toc: %w", err)
+	}
+	return string(out) + "\n", nil
+}
+
+func walk(entries []*Entry, depth int, fn func(*Entry, int)) {
+	for _, e := range entries {
+		fn(e, depth)
+		walk(e.Children, depth+1, fn)
+	}
+}
+
+func escapeLinkText(s string) string {
+	return strings.NewReplacer("[", `\[`, "]", `\]`).Replace(s)
+}
+
+// Markers delimiting an injected table of contents.
+const (
+	InjectStart = "<!-- toc -->"
+	InjectStop  = "<!-- tocstop -->"
+)
+
+// Inject replaces the lines between the toc markers in md with toc. Markers
+// inside fenced code (documentation of the syntax) do not count. It reports
+// false when md has no complete marker pair.
+func Inject(md, toc string) (string, bool) {
+	lines := strings.Split(md, "\n")
+	start, stop := -1, -1
+	var fence mdblock.FenceTracker
+	for i, ln := range lines {
+		t := strings.ToLower(strings.TrimSpace(ln))
+		if fence.Update(t) {
+			continue
+		}
+		if start < 0 && t == InjectStart {
+			start = i
+		} else if start >= 0 && t == InjectStop {
+			stop = i
+			break
+		}
+	}
+	if start < 0 || stop < 0 {
+		return md, false
+	}
+
+	out := append([]string{}, lines[:start+1]...)
+	out = append(out, "")
+	out = append(out, strings.Split(strings.TrimRight(toc, "\n"), "\n")...)
+	out = append(out, "")
+	out = append(out, lines[stop:]...)
+	return strings.Join(out, "\n"), true
+}
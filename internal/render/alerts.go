@@ -0,0 +1,190 @@
+package render
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/simota/md/internal/mdblock"
+)
+
+type alertKind string
+
+const (
+	alertNote      alertKind = "note"
+	alertTip       alertKind = "tip"
+	alertImportant alertKind = "important"
+	alertWarning   alertKind = "warning"
+	alertCaution   alertKind = "caution"
+)
+
+var alertLabels = map[alertKind]struct{ icon, title string }{
+	alertNote:      {"ℹ", "Note"},
+	alertTip:       {"✦", "Tip"},
+	alertImportant: {"❖", "Important"},
+	alertWarning:   {"▲", "Warning"},
+	alertCaution:   {"✖", "Caution"},
+}
+
+// Container names accepted after ":::" in addition to the GitHub alert kinds.
+var alertAliases = map[string]alertKind{
+	"info":   alertNote,
+	"hint":   alertTip,
+	"danger": alertCaution,
+	"error":  alertCaution,
+}
+
+var (
+	// "> [!NOTE]" (GitHub alerts). Anything after the marker is an optional title.
+	alertMarkerRe = regexp.MustCompile(`^ {0,3}>\s*\[!([A-Za-z]+)\]\s*(.*)$`)
+	// ":::note Optional title" (container directives).
+	alertContainerRe = regexp.MustCompile(`^ {0,3}:::+\s*([A-Za-z]+)\s*(.*)$`)
+	alertCloseRe     = regexp.MustCompile(`^ {0,3}:::+\s*$`)
+)
+
+func parseAlertKind(s string) (alertKind, bool) {
+	k := alertKind(strings.ToLower(s))
+	if _, ok := alertLabels[k]; ok {
+		return k, true
+	}
+	k, ok := alertAliases[strings.ToLower(s)]
+	return k, ok
+}
+
+// AlertLabel resolves an alert or container name (aliases included) to its
+// kind ("note", "tip", ...), icon and title.
+func AlertLabel(name string) (kind, icon, title string, ok bool) {
+	k, ok := parseAlertKind(name)
+	if !ok {
+		return "", "", "", false
+	}
+	l := alertLabels[k]
+	return string(k), l.icon, l.title, true
+}
+
+// RewriteAlerts replaces every GitHub alert and ":::" container in md with
+// the text fn returns for it. body is the Markdown inside the block, with
+// the "> " prefixes removed.
+func RewriteAlerts(md string, fn func(kind, title, body string) string) string {
+	return rewriteAlerts(md, func(kind alertKind, title, body string) (string, bool) {
+		return fn(string(kind), title, body), true
+	})
+}
+
+// preprocessAlerts replaces GitHub alerts and ":::" containers with boxed
+// callouts. Unknown kinds are left alone and render as plain blockquotes.
+// It runs before preprocessFences and preprocessMath, which would otherwise
+// leave their placeholders inside the callout text; hooks renders fenced
+// blocks in the body instead.
+func preprocessAlerts(md string, th editorialTheme, width int, sp *splicer, hooks map[string]fenceHook) string {
+	return rewriteAlerts(md, func(kind alertKind, title, body string) (string, bool) {
+		block, err := renderAlert(kind, title, body, th, width, hooks)
+		if err != nil {
+			return "", false
+		}
+		return sp.placeholder(block), true
+	})
+}
+
+// rewriteAlerts finds alerts and containers outside code fences and replaces
+// each with fn's result; blocks fn declines are kept as they are.
+func rewriteAlerts(md string, fn func(kind alertKind, title, body string) (string, bool)) string {
+	lines := splitSourceLines(md)
+	var (
+		out   []string
+		fence mdblock.FenceTracker
+	)
+
+	for i := 0; i < len(lines); i++ {
+		ln := lines[i]
+		if fence.Update(ln) {
+			out = append(out, ln)
+			continue
+		}
+
+		if m := alertMarkerRe.FindStringSubmatch(ln); m != nil {
+			kind, ok := parseAlertKind(m[1])
+			if ok {
+				var body []string
+				j := i + 1
+				for ; j < len(lines); j++ {
+					t := strings.TrimLeft(lines[j], " ")
+					if !strings.HasPrefix(t, ">") {
+						break
+					}
+					t = strings.TrimPrefix(t, ">")
+					t = strings.TrimPrefix(t, " ")
+					body = append(body, t)
+				}
+				if repl, ok := fn(kind, m[2], strings.Join(body, "\n")); ok {
+					out = append(out, repl)
+					i = j - 1
+					continue
+				}
+			}
+		}
+
+		if m := alertContainerRe.FindStringSubmatch(ln); m != nil {
+			kind, ok := parseAlertKind(m[1])
+			if ok {
+				var (
+					body   []string
+					inner  mdblock.FenceTracker
+					closed = -1
+				)
+				for j := i + 1; j < len(lines); j++ {
+					if !inner.Update(lines[j]) && alertCloseRe.MatchString(lines[j]) {
+						closed = j
+						break
+					}
+					body = append(body, lines[j])
+				}
+				if closed >= 0 {
+					if repl, ok := fn(kind, m[2], strings.Join(body, "\n")); ok {
+						out = append(out, repl)
+						i = closed
+						continue
+					}
+				}
+			}
+		}
+
+		out = append(out, ln)
+	}
+	return strings.Join(out, "\n")
+}
+
+func renderAlert(kind alertKind, title string, body string, th editorialTheme, width int, hooks map[string]fenceHook) ([]string, error) {
+	label := alertLabels[kind]
+	if strings.TrimSpace(title) == "" {
+		title = label.title
+	}
+	color := lipgloss.Color(th.Alerts[kind])
+
+	// Border (2) + horizontal padding (2).
+	innerW := max(8, width-4)
+
+	content := []string{
+		blockStyles.NewStyle().Bold(true).Foreground(color).Render(label.icon + " " + strings.TrimSpace(title)),
+	}
+	if strings.TrimSpace(body) != "" {
+		// Diagrams and math inside the box are rendered at its width.
+		sp := &splicer{}
+		body = preprocessFences(body, innerW, sp, hooks)
+		body = preprocessMath(body, innerW, sp)
+		lines, err := renderFragment(body, th.Styles, innerW)
+		if err != nil {
+			return nil, err
+		}
+		content = append(content, strings.Split(sp.splice(strings.Join(lines, "\n")), "\n")...)
+	}
+
+	box := blockStyles.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(color).
+		Padding(0, 1).
+		Width(innerW + 2).
+		Render(strings.Join(content, "\n"))
+	return strings.Split(box, "\n"), nil
+}
@@ -0,0 +1,79 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	xansi "github.com/charmbracelet/x/ansi"
+)
+
+func TestRenderMarkdown_Alerts(t *testing.T) {
+	md := "" +
+		"> [!WARNING]\n" +
+		"> Do not run this in production.\n" +
+		"\n" +
+		":::tip\n" +
+		"Use `make build`.\n" +
+		":::\n" +
+		"\n" +
+		"```md\n" +
+		"> [!NOTE]\n" +
+		"```\n"
+
+	out, err := RenderMarkdown(md, Options{Style: "dark", Width: 60})
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain := xansi.Strip(out)
+
+	for _, want := range []string{"▲ Warning", "Do not run this in production.", "✦ Tip", "╭", "╰"} {
+		if !strings.Contains(plain, want) {
+			t.Fatalf("expected %q in output:\n%s", want, plain)
+		}
+	}
+	if strings.Contains(plain, "[!WARNING]") || strings.Contains(plain, "mdsplice") {
+		t.Fatalf("alert marker leaked into output:\n%s", plain)
+	}
+	// Alerts inside code fences are left untouched.
+	if !strings.Contains(plain, "> [!NOTE]") {
+		t.Fatalf("fenced alert should stay verbatim:\n%s", plain)
+	}
+}
+
+func TestRenderMarkdown_DiagramInsideAlert(t *testing.T) {
+	md := "" +
+		":::note\n" +
+		"```mermaid\n" +
+		"graph TD\n  A[Start] --> B[End]\n" +
+		"```\n" +
+		":::\n" +
+		"\n" +
+		"> [!TIP]\n" +
+		"> ```mermaid\n" +
+		"> graph TD\n" +
+		">   C[Left] --> D[Right]\n" +
+		"> ```\n" +
+		">\n" +
+		"> $$\n" +
+		"> x^2\n" +
+		"> $$\n"
+
+	out, err := RenderMarkdown(md, Options{Style: "dark", Width: 60})
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain := xansi.Strip(out)
+	if strings.Contains(plain, "mdsplice") || strings.Contains(plain, "-->") {
+		t.Fatalf("diagram not rendered inside the callout:\n%s", plain)
+	}
+	for _, want := range []string{"│ Start │", "│ Right │", "x²"} {
+		if !strings.Contains(plain, want) {
+			t.Fatalf("expected %q in output:\n%s", want, plain)
+		}
+	}
+	for _, ln := range strings.Split(strings.TrimRight(plain, "\n"), "\n") {
+		if w := xansi.StringWidth(strings.TrimRight(ln, " ")); w > 60 {
+			t.Fatalf("line wider than 60 (%d): %q", w, ln)
+		}
+	}
+}
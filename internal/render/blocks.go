@@ -0,0 +1,153 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/charmbracelet/glamour/ansi"
+	"github.com/charmbracelet/lipgloss"
+	xansi "github.com/charmbracelet/x/ansi"
+	"github.com/muesli/termenv"
+)
+
+// glamour owns its goldmark instance, so we cannot register extra parsers or
+// node renderers. Instead, blocks we render ourselves are swapped for a
+// placeholder paragraph before glamour runs and spliced back afterwards.
+type splicer struct {
+	blocks [][]string
+	at     []splicedAt // where each block landed, filled in by splice
+}
+
+type splicedAt struct {
+	line int // first output line, -1 if the token was not found
+	col  int // width of the prefix glamour put in front of the block
+}
+
+// placeholder registers rendered block lines and returns the Markdown that
+// stands in for them. The token survives glamour untouched (no markup, no
+// spaces, so word wrap keeps it on one line).
+func (s *splicer) placeholder(lines []string) string {
+	s.blocks = append(s.blocks, lines)
+	return "\n" + spliceToken(len(s.blocks)-1) + "\n\n"
+}
+
+func spliceToken(i int) string { return fmt.Sprintf("mdsplice%04dx", i) }
+
+func (s *splicer) splice(out string) string {
+	if len(s.blocks) == 0 {
+		return out
+	}
+	s.at = make([]splicedAt, len(s.blocks))
+	for i := range s.at {
+		s.at[i].line = -1
+	}
+	lines := strings.Split(out, "\n")
+	res := make([]string, 0, len(lines))
+	for _, ln := range lines {
+		plain := xansi.Strip(ln)
+		if !strings.Contains(plain, "mdsplice") {
+			res = append(res, ln)
+			continue
+		}
+		replaced := false
+		for i, block := range s.blocks {
+			tok := spliceToken(i)
+			at := strings.Index(plain, tok)
+			if at < 0 {
+				continue
+			}
+			// Keep whatever glamour put in front of the token (margins,
+			// blockquote bars, list indentation).
+			prefix := plain[:at]
+			s.at[i] = splicedAt{line: len(res), col: xansi.StringWidth(prefix)}
+			for _, bl := range block {
+				res = append(res, prefix+bl)
+			}
+			replaced = true
+			break
+		}
+		if !replaced {
+			res = append(res, ln)
+		}
+	}
+	return strings.Join(res, "\n")
+}
+
+// blockWidth is the usable width inside the document margins.
+func blockWidth(cfg ansi.StyleConfig, width int) int {
+	margin := 0
+	if cfg.Document.Margin != nil {
+		margin = int(*cfg.Document.Margin)
+	}
+	return max(10, width-2*margin)
+}
+
+// renderFragment renders Markdown for embedding inside one of our blocks: no
+// document margins or leading/trailing blank lines.
+func renderFragment(md string, cfg ansi.StyleConfig, width int) ([]string, error) {
+	cfg.Document.Margin = uintPtr(0)
+	cfg.Document.BlockPrefix = ""
+	cfg.Document.BlockSuffix = ""
+	out, err := renderGlamour(md, cfg, width)
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(strings.TrimRight(out, "\n"), "\n")
+	for len(lines) > 0 && strings.TrimSpace(xansi.Strip(lines[0])) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(xansi.Strip(lines[len(lines)-1])) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines, nil
+}
+
+func uintPtr(u uint) *uint { return &u }
+
+// blockStyles renders lipgloss styles with the same true-color profile glamour
+// uses, independent of whether stdout is a terminal.
+var blockStyles = func() *lipgloss.Renderer {
+	r := lipgloss.NewRenderer(io.Discard)
+	r.SetColorProfile(termenv.TrueColor)
+	return r
+}()
+
+func countPrefix(s string, ch byte) int {
+	n := 0
+	for i := 0; i < len(s) && s[i] == ch; i++ {
+		n++
+	}
+	return n
+}
+
+func splitSourceLines(md string) []string {
+	return strings.Split(strings.ReplaceAll(md, "\r\n", "\n"), "\n")
+}
+
+// mapOutsideCode applies fn to the parts of a line that are not inside code
+// spans; code spans are copied verbatim.
+func mapOutsideCode(line string, fn func(string) string) string {
+	if !strings.Contains(line, "`") {
+		return fn(line)
+	}
+	var b strings.Builder
+	for {
+		i := strings.IndexByte(line, '`')
+		if i < 0 {
+			b.WriteString(fn(line))
+			return b.String()
+		}
+		b.WriteString(fn(line[:i]))
+		run := line[i : i+countPrefix(line[i:], '`')]
+		end := strings.Index(line[i+len(run):], run)
+		if end < 0 {
+			// Unmatched backticks are literal text.
+			b.WriteString(run)
+			line = line[i+len(run):]
+			continue
+		}
+		b.WriteString(line[i : i+len(run)+end+len(run)])
+		line = line[i+len(run)+end+len(run):]
+	}
+}
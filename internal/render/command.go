@@ -0,0 +1,138 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	xansi "github.com/charmbracelet/x/ansi"
+)
+
+// FenceCommand renders fenced code blocks of one language with an external
+// program: the block body is its stdin and its stdout replaces the block.
+// MD_WIDTH (and COLUMNS) hold the available width.
+type FenceCommand struct {
+	Command string        // run by the shell (sh -c, cmd /C on Windows)
+	Timeout time.Duration // default DefaultFenceTimeout
+}
+
+// DefaultFenceTimeout bounds a fence command that sets no timeout.
+const DefaultFenceTimeout = 5 * time.Second
+
+// fenceCache keeps command output by content hash for the session, as the
+// pager re-renders on every resize. A block whose command exited with an
+// error or printed nothing is remembered regardless of width (as nil lines),
+// so a broken command does not run again at each resize. Timeouts are not
+// remembered: a slow machine or a busy render may do better next time. At most maxFenceCache entries are
+// kept; the oldest go first.
+var fenceCache = struct {
+	sync.Mutex
+	m     map[string][]string
+	order []string
+}{m: map[string][]string{}}
+
+const maxFenceCache = 512
+
+// errFenceTimeout is wrapped by runFenceCommand when fc.Timeout ran out.
+var errFenceTimeout = errors.New("timed out")
+
+// commandFenceHook runs fc for each block. Failures, timeouts and empty
+// output fall back to regular highlighting.
+func commandFenceHook(fc FenceCommand) fenceHook {
+	return func(body string, width int) ([]string, bool) {
+		key := fenceCacheKey(fc.Command, body, width)
+		if lines, ok := cachedFence(key); ok {
+			return lines, true
+		}
+		failKey := fenceCacheKey(fc.Command, body, -1)
+		if _, failed := cachedFence(failKey); failed {
+			return nil, false
+		}
+		out, err := runFenceCommand(fc, body, width)
+		if err != nil {
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				storeFence(failKey, nil)
+			}
+			return nil, false
+		}
+		lines := strings.Split(strings.TrimRight(strings.ReplaceAll(out, "\r\n", "\n"), "\n"), "\n")
+		if len(lines) == 1 && strings.TrimSpace(lines[0]) == "" {
+			storeFence(failKey, nil)
+			return nil, false
+		}
+		for i, ln := range lines {
+			lines[i] = xansi.Truncate(strings.ReplaceAll(ln, "\t", "    "), width, "…")
+		}
+		storeFence(key, lines)
+		return lines, true
+	}
+}
+
+func fenceTimeout(fc FenceCommand) time.Duration {
+	if fc.Timeout <= 0 {
+		return DefaultFenceTimeout
+	}
+	return fc.Timeout
+}
+
+// runFenceCommand runs fc on one block. The timeout starts now, so blocks
+// waiting for a free slot do not lose part of theirs.
+func runFenceCommand(fc FenceCommand, body string, width int) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), fenceTimeout(fc))
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", fc.Command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", fc.Command)
+	}
+	cmd.Stdin = strings.NewReader(body + "\n")
+	cmd.Env = append(os.Environ(), fmt.Sprintf("MD_WIDTH=%d", width), fmt.Sprintf("COLUMNS=%d", width))
+	// Don't wait for grandchildren holding stdout open after a timeout.
+	cmd.WaitDelay = time.Second
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			err = errFenceTimeout
+		}
+		return "", fmt.Errorf("fence command %q: %w", fc.Command, err)
+	}
+	return stdout.String(), nil
+}
+
+func fenceCacheKey(command, body string, width int) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%d\x00%s", command, width, body)))
+	return hex.EncodeToString(sum[:])
+}
+
+func cachedFence(key string) ([]string, bool) {
+	fenceCache.Lock()
+	defer fenceCache.Unlock()
+	lines, ok := fenceCache.m[key]
+	return lines, ok
+}
+
+func storeFence(key string, lines []string) {
+	fenceCache.Lock()
+	defer fenceCache.Unlock()
+	if _, ok := fenceCache.m[key]; !ok {
+		fenceCache.order = append(fenceCache.order, key)
+		if len(fenceCache.order) > maxFenceCache {
+			delete(fenceCache.m, fenceCache.order[0])
+			fenceCache.order = fenceCache.order[1:]
+		}
+	}
+	fenceCache.m[key] = lines
+}
@@ -0,0 +1,151 @@
+package render
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	xansi "github.com/charmbracelet/x/ansi"
+)
+
+func fenceCommandEnv(t *testing.T) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fence command tests use sh")
+	}
+	dir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", dir)
+	t.Setenv("HOME", dir)
+	return dir
+}
+
+func TestRenderMarkdown_FenceCommand(t *testing.T) {
+	dir := fenceCommandEnv(t)
+	count := filepath.Join(dir, "count")
+	opts := Options{Style: "dark", Width: 60, FenceCommands: map[string]FenceCommand{
+		"shout": {Command: "echo run >> " + count + "; tr a-z A-Z; echo \"w=$MD_WIDTH\""},
+	}}
+	md := "# Doc\n\n```shout\nhello there\n```\n"
+
+	for i := 0; i < 2; i++ {
+		out, err := RenderMarkdown(md, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		plain := xansi.Strip(out)
+		if !strings.Contains(plain, "HELLO THERE") || strings.Contains(plain, "hello there") {
+			t.Fatalf("command output missing:\n%s", plain)
+		}
+		if !strings.Contains(plain, "w=") {
+			t.Fatalf("MD_WIDTH not set:\n%s", plain)
+		}
+	}
+	runs, _ := os.ReadFile(count)
+	if n := strings.Count(string(runs), "run"); n != 1 {
+		t.Fatalf("command ran %d times, want 1 (cached)", n)
+	}
+}
+
+func TestRenderMarkdown_FenceCommandFallback(t *testing.T) {
+	fenceCommandEnv(t)
+	for name, fc := range map[string]FenceCommand{
+		"fails":   {Command: "exit 3"},
+		"empty":   {Command: "cat >/dev/null"},
+		"timeout": {Command: "sleep 5", Timeout: 100 * time.Millisecond},
+	} {
+		opts := Options{Style: "dark", Width: 60, FenceCommands: map[string]FenceCommand{"dot": fc}}
+		start := time.Now()
+		out, err := RenderMarkdown("```dot\ndigraph { a -> b }\n```\n", opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(xansi.Strip(out), "digraph") {
+			t.Errorf("%s: source not shown:\n%s", name, out)
+		}
+		if time.Since(start) > 3*time.Second {
+			t.Errorf("%s: took %s", name, time.Since(start))
+		}
+	}
+}
+
+func TestRenderMarkdown_FenceCommandsRunConcurrently(t *testing.T) {
+	fenceCommandEnv(t)
+	opts := Options{Style: "dark", Width: 60, FenceCommands: map[string]FenceCommand{
+		"slow": {Command: "sleep 1; tr a-z A-Z"},
+	}}
+	md := "```slow\none\n```\n\n```slow\ntwo\n```\n\n```slow\nthree\n```\n\n```mermaid\ngraph LR; A-->B\n```\n\n```math\nx^2\n```\n"
+	start := time.Now()
+	out, err := RenderMarkdown(md, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d > 2500*time.Millisecond {
+		t.Errorf("three 1s commands took %s", d)
+	}
+	plain := xansi.Strip(out)
+	for _, want := range []string{"ONE", "TWO", "THREE", "x²"} {
+		if !strings.Contains(plain, want) {
+			t.Errorf("missing %q in:\n%s", want, plain)
+		}
+	}
+}
+
+func TestRenderMarkdown_FailedFenceCommandNotRerun(t *testing.T) {
+	dir := fenceCommandEnv(t)
+	count := filepath.Join(dir, "count")
+	opts := Options{Style: "dark", FenceCommands: map[string]FenceCommand{"bad": {Command: "echo run >> " + count + "; exit 1"}}}
+	for _, w := range []int{40, 60, 80} {
+		opts.Width = w
+		if _, err := RenderMarkdown("```bad\nx\n```\n", opts); err != nil {
+			t.Fatal(err)
+		}
+	}
+	runs, _ := os.ReadFile(count)
+	if n := strings.Count(string(runs), "run"); n != 1 {
+		t.Fatalf("failing command ran %d times, want 1", n)
+	}
+}
+
+func TestRenderMarkdown_QueuedFenceCommandsKeepTheirTimeout(t *testing.T) {
+	fenceCommandEnv(t)
+	opts := Options{Style: "dark", Width: 60, FenceCommands: map[string]FenceCommand{
+		"queued": {Command: "sleep 0.4; tr a-z A-Z", Timeout: 600 * time.Millisecond},
+	}}
+	var md strings.Builder
+	n := 2 * maxParallelFences
+	for i := range n {
+		md.WriteString("```queued\nblock" + strings.Repeat("x", i) + "\n```\n\n")
+	}
+	out, err := RenderMarkdown(md.String(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(xansi.Strip(out), "BLOCK"); got != n {
+		t.Fatalf("rendered %d of %d blocks:\n%s", got, n, xansi.Strip(out))
+	}
+}
+
+func TestRenderMarkdown_TimedOutFenceCommandRetried(t *testing.T) {
+	dir := fenceCommandEnv(t)
+	flag := filepath.Join(dir, "slow-once")
+	opts := Options{Style: "dark", Width: 60, FenceCommands: map[string]FenceCommand{
+		"once": {Command: "if [ -e " + flag + " ]; then tr a-z A-Z; else touch " + flag + "; sleep 5; fi", Timeout: 200 * time.Millisecond},
+	}}
+	md := "```once\nretried\n```\n"
+	out, err := RenderMarkdown(md, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(xansi.Strip(out), "retried") {
+		t.Fatalf("timed out block not shown as source:\n%s", xansi.Strip(out))
+	}
+	if out, err = RenderMarkdown(md, opts); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(xansi.Strip(out), "RETRIED") {
+		t.Fatalf("timed out command was not run again:\n%s", xansi.Strip(out))
+	}
+}
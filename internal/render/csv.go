@@ -0,0 +1,144 @@
+package render
+
+import (
+	"encoding/csv"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	xansi "github.com/charmbracelet/x/ansi"
+)
+
+// maxTableColumn caps a column of a wide CSV table; longer cells end in "…".
+const maxTableColumn = 40
+
+// CSVDocument wraps CSV (or TSV) data in a fenced block, so a .csv file
+// renders as one table.
+func CSVDocument(data string, tsv bool) string {
+	lang := "csv"
+	if tsv {
+		lang = "tsv"
+	}
+	fence := "```"
+	for strings.Contains(data, fence) {
+		fence += "`"
+	}
+	return fence + lang + "\n" + strings.TrimRight(data, "\r\n") + "\n" + fence + "\n"
+}
+
+// csvFenceHook renders ```csv and ```tsv blocks as tables that look like
+// glamour's Markdown tables. The first record is the header; numeric
+// columns are right-aligned. Unless wide is set, columns are shortened so
+// the table fits in the width.
+func csvFenceHook(th editorialTheme, comma rune, wide bool) fenceHook {
+	return func(body string, width int) ([]string, bool) {
+		r := csv.NewReader(strings.NewReader(body))
+		r.Comma = comma
+		r.LazyQuotes = true
+		r.FieldsPerRecord = -1
+		records, err := r.ReadAll()
+		if err != nil || len(records) == 0 {
+			return nil, false
+		}
+		return renderTable(records, th, width, wide), true
+	}
+}
+
+func renderTable(records [][]string, th editorialTheme, width int, wide bool) []string {
+	cols := 0
+	for _, rec := range records {
+		cols = max(cols, len(rec))
+	}
+	widths := make([]int, cols)
+	numeric := make([]bool, cols)
+	for c := range numeric {
+		numeric[c] = len(records) > 1
+	}
+	for i, rec := range records {
+		for c, cell := range rec {
+			cell = strings.TrimSpace(strings.ReplaceAll(cell, "\n", " "))
+			rec[c] = cell
+			widths[c] = max(widths[c], xansi.StringWidth(cell))
+			if i > 0 && cell != "" && !isNumber(cell) {
+				numeric[c] = false
+			}
+		}
+	}
+	for c := range widths {
+		widths[c] = max(1, min(widths[c], maxTableColumn))
+	}
+	if !wide {
+		fitColumns(widths, width-1-3*(cols-1))
+	}
+
+	text := blockStyles.NewStyle()
+	if c := th.Styles.Document.Color; c != nil {
+		text = text.Foreground(lipgloss.Color(*c))
+	}
+	colSep, rowSep, crossSep := " │ ", "─", "┼"
+	if s := th.Styles.Table.ColumnSeparator; s != nil {
+		colSep = " " + *s + " "
+	}
+	if s := th.Styles.Table.RowSeparator; s != nil {
+		rowSep = *s
+	}
+	if s := th.Styles.Table.CenterSeparator; s != nil {
+		crossSep = *s
+	}
+
+	row := func(rec []string) string {
+		cells := make([]string, cols)
+		for c := range cells {
+			cell := ""
+			if c < len(rec) {
+				cell = xansi.Truncate(rec[c], widths[c], "…")
+			}
+			pad := strings.Repeat(" ", widths[c]-xansi.StringWidth(cell))
+			if numeric[c] {
+				cells[c] = pad + text.Render(cell)
+			} else {
+				cells[c] = text.Render(cell) + pad
+			}
+		}
+		return " " + strings.Join(cells, colSep)
+	}
+
+	out := []string{row(records[0])}
+	rule := make([]string, cols)
+	for c, w := range widths {
+		rule[c] = strings.Repeat(rowSep, w+2)
+	}
+	out = append(out, strings.Join(rule, crossSep))
+	for _, rec := range records[1:] {
+		out = append(out, row(rec))
+	}
+	return out
+}
+
+// fitColumns narrows the widest columns until they add up to avail, keeping
+// every column at least 3 wide.
+func fitColumns(widths []int, avail int) {
+	total := 0
+	for _, w := range widths {
+		total += w
+	}
+	for total > avail {
+		widest := 0
+		for c, w := range widths {
+			if w > widths[widest] {
+				widest = c
+			}
+		}
+		if widths[widest] <= 3 {
+			return
+		}
+		widths[widest]--
+		total--
+	}
+}
+
+func isNumber(s string) bool {
+	s = strings.TrimSuffix(strings.ReplaceAll(s, ",", ""), "%")
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
@@ -0,0 +1,65 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	xansi "github.com/charmbracelet/x/ansi"
+)
+
+func TestRenderMarkdown_CSVTable(t *testing.T) {
+	md := "```csv\nname,qty,note\napple,3,\"red, sweet\"\nkiwi,12,green\n```\n"
+	out, err := RenderMarkdown(md, Options{Style: "dark", Width: 60})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rows []string
+	for _, ln := range strings.Split(xansi.Strip(out), "\n") {
+		if strings.TrimSpace(ln) != "" {
+			rows = append(rows, strings.TrimRight(ln, " "))
+		}
+	}
+	want := []string{
+		"   name  │ qty │ note",
+		"  ───────┼─────┼────────────",
+		"   apple │   3 │ red, sweet",
+		"   kiwi  │  12 │ green",
+	}
+	if strings.Join(rows, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("got:\n%s\nwant:\n%s", strings.Join(rows, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestRenderMarkdown_CSVColumnsFitOrScroll(t *testing.T) {
+	long := strings.Repeat("x", 60)
+	md := "```tsv\na\tb\n" + long + "\t" + long + "\n```\n"
+
+	widest := func(out string) int {
+		w := 0
+		for _, ln := range strings.Split(out, "\n") {
+			w = max(w, xansi.StringWidth(strings.TrimRight(xansi.Strip(ln), " ")))
+		}
+		return w
+	}
+	fit, err := RenderMarkdown(md, Options{Style: "dark", Width: 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := widest(fit); w > 50 || !strings.Contains(fit, "…") {
+		t.Fatalf("table not shortened to width 50 (widest %d):\n%s", w, fit)
+	}
+	wide, err := RenderMarkdown(md, Options{Style: "dark", Width: 50, WideTables: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := widest(wide); w <= 50 || w > 2+2*maxTableColumn+5 {
+		t.Fatalf("wide table is %d columns:\n%s", w, wide)
+	}
+}
+
+func TestCSVDocument_FenceLongerThanData(t *testing.T) {
+	got := CSVDocument("a,b\n```,x\n", false)
+	if want := "````csv\na,b\n```,x\n````\n"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,109 @@
+package render
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// fenceHook renders the body of a fenced code block whose info string starts
+// with a given language. Returning ok=false hands the block back to glamour,
+// which shows it as regular highlighted code.
+type fenceHook func(body string, width int) (lines []string, ok bool)
+
+// defaultFenceHooks are the fenced block renderers built into md.
+func defaultFenceHooks() map[string]fenceHook {
+	return map[string]fenceHook{
+		"mermaid": renderMermaid,
+		"math":    mathFenceHook,
+	}
+}
+
+// fenceHooks adds the table renderers and the configured fence commands to
+// the built-in hooks.
+func fenceHooks(opts Options, th editorialTheme) map[string]fenceHook {
+	hooks := defaultFenceHooks()
+	hooks["csv"] = csvFenceHook(th, ',', opts.WideTables)
+	hooks["tsv"] = csvFenceHook(th, '\t', opts.WideTables)
+	for lang, fc := range opts.FenceCommands {
+		if strings.TrimSpace(fc.Command) != "" {
+			hooks[strings.ToLower(lang)] = commandFenceHook(fc)
+		}
+	}
+	return hooks
+}
+
+// maxParallelFences bounds how many hooks run at once.
+const maxParallelFences = 8
+
+var fenceOpenRe = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})\\s*([^`\\s]*)(.*)$")
+
+// preprocessFences swaps top-level fenced blocks handled by hooks for rendered
+// placeholders. The hooks run concurrently, so a document with several slow
+// fence commands waits for the slowest, not for all of them in turn.
+func preprocessFences(md string, width int, sp *splicer, hooks map[string]fenceHook) string {
+	if len(hooks) == 0 {
+		return md
+	}
+	lines := splitSourceLines(md)
+
+	type hooked struct {
+		start, end int // fence lines
+		hook       fenceHook
+		rendered   []string
+		ok         bool
+	}
+	var blocks []*hooked
+
+	for i := 0; i < len(lines); i++ {
+		m := fenceOpenRe.FindStringSubmatch(lines[i])
+		if m == nil {
+			continue
+		}
+
+		fence := m[1]
+		end := -1
+		for j := i + 1; j < len(lines); j++ {
+			t := strings.TrimSpace(lines[j])
+			if strings.HasPrefix(t, fence) && strings.Trim(t, fence[:1]) == "" {
+				end = j
+				break
+			}
+		}
+		if end < 0 {
+			break // unclosed fence: the rest of the document is code
+		}
+
+		if hook, ok := hooks[strings.ToLower(m[2])]; ok {
+			blocks = append(blocks, &hooked{start: i, end: end, hook: hook})
+		}
+		i = end
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxParallelFences)
+	for _, b := range blocks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			b.rendered, b.ok = b.hook(strings.Join(lines[b.start+1:b.end], "\n"), width)
+		}()
+	}
+	wg.Wait()
+
+	var out []string
+	next := 0
+	for _, b := range blocks {
+		out = append(out, lines[next:b.start]...)
+		next = b.end + 1
+		if !b.ok {
+			out = append(out, lines[b.start:next]...)
+			continue
+		}
+		out = append(out, sp.placeholder(b.rendered))
+	}
+	out = append(out, lines[next:]...)
+	return strings.Join(out, "\n")
+}
@@ -0,0 +1,201 @@
+package render
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/simota/md/internal/mdblock"
+)
+
+// FootnotesTitle labels the section that collects footnote definitions at the
+// end of a rendered document.
+const FootnotesTitle = "Footnotes"
+
+// Footnote is a GFM footnote definition ("[^label]: text"). Footnotes are
+// numbered in order of their first reference; unreferenced definitions are
+// dropped, as on GitHub.
+type Footnote struct {
+	Label  string
+	Number int
+	Text   string
+}
+
+// footnoteRefMarker precedes each reference's superscript until rendering is
+// done; takeFootnoteRefs then records and removes it, so references can be
+// told from other superscripts (x² in math).
+const footnoteRefMarker = '⁡' // FUNCTION APPLICATION, invisible
+
+// Marker is the superscript shown in place of references to the footnote.
+func (f Footnote) Marker() string {
+	s, _ := superscript(fmt.Sprint(f.Number))
+	return s
+}
+
+var (
+	footnoteDefRe = regexp.MustCompile(`^ {0,3}\[\^([^\]\s]+)\]:[ \t]*(.*)$`)
+	footnoteRefRe = regexp.MustCompile(`\[\^([^\]\s]+)\]`)
+)
+
+// ParseFootnotes returns the referenced footnotes of md in number order.
+func ParseFootnotes(md string) []Footnote {
+	notes, _ := scanFootnotes(splitSourceLines(md))
+	return notes
+}
+
+// scanFootnotes collects definitions and numbers them by first reference. It
+// also reports which source lines belong to definitions.
+func scanFootnotes(lines []string) ([]Footnote, map[int]bool) {
+	defs := map[string]string{}
+	defLines := map[int]bool{}
+	var fence mdblock.FenceTracker
+
+	for i := 0; i < len(lines); i++ {
+		if fence.Update(lines[i]) {
+			continue
+		}
+		m := footnoteDefRe.FindStringSubmatch(lines[i])
+		if m == nil {
+			continue
+		}
+		label := strings.ToLower(m[1])
+		body := []string{m[2]}
+		defLines[i] = true
+		// Continuation lines are indented; blank lines are kept only when
+		// another indented line follows.
+		for j := i + 1; j < len(lines); j++ {
+			ln := lines[j]
+			if strings.TrimSpace(ln) == "" {
+				if j+1 < len(lines) && isIndentedContinuation(lines[j+1]) {
+					body = append(body, "")
+					defLines[j] = true
+					continue
+				}
+				break
+			}
+			if !isIndentedContinuation(ln) {
+				break
+			}
+			ln = strings.TrimSpace(ln)
+			if last := len(body) - 1; body[last] != "" {
+				body[last] += " " + ln // soft line break
+			} else {
+				body = append(body, ln)
+			}
+			defLines[j] = true
+			i = j
+		}
+		if _, dup := defs[label]; !dup {
+			defs[label] = strings.TrimSpace(strings.Join(body, "\n"))
+		}
+	}
+	if len(defs) == 0 {
+		return nil, defLines
+	}
+
+	var notes []Footnote
+	seen := map[string]bool{}
+	fence = mdblock.FenceTracker{}
+	for i, ln := range lines {
+		if fence.Update(ln) || defLines[i] {
+			continue
+		}
+		mapOutsideCode(ln, func(s string) string {
+			for _, m := range footnoteRefRe.FindAllStringSubmatch(s, -1) {
+				label := strings.ToLower(m[1])
+				text, ok := defs[label]
+				if !ok || seen[label] {
+					continue
+				}
+				seen[label] = true
+				notes = append(notes, Footnote{Label: label, Number: len(notes) + 1, Text: text})
+			}
+			return s
+		})
+	}
+	return notes, defLines
+}
+
+func isIndentedContinuation(ln string) bool {
+	return strings.HasPrefix(ln, "    ") || strings.HasPrefix(ln, "\t")
+}
+
+// preprocessFootnotes replaces references with marked superscript numbers,
+// removes the definitions from the body and appends them as a numbered list.
+// It returns the referenced footnote numbers in document order.
+func preprocessFootnotes(md string) (string, []int) {
+	lines := splitSourceLines(md)
+	notes, defLines := scanFootnotes(lines)
+	if len(notes) == 0 {
+		return md, nil
+	}
+	byLabel := map[string]Footnote{}
+	for _, n := range notes {
+		byLabel[n.Label] = n
+	}
+
+	var (
+		out   []string
+		refs  []int
+		fence mdblock.FenceTracker
+	)
+	for i, ln := range lines {
+		if fence.Update(ln) {
+			out = append(out, ln)
+			continue
+		}
+		if defLines[i] {
+			continue
+		}
+		out = append(out, mapOutsideCode(ln, func(s string) string {
+			return footnoteRefRe.ReplaceAllStringFunc(s, func(ref string) string {
+				if n, ok := byLabel[strings.ToLower(ref[2:len(ref)-1])]; ok {
+					refs = append(refs, n.Number)
+					return string(footnoteRefMarker) + n.Marker()
+				}
+				return ref
+			})
+		}))
+	}
+
+	out = append(out, "", "---", "", "**"+FootnotesTitle+"**", "")
+	for _, n := range notes {
+		prefix := fmt.Sprintf("%d. ", n.Number)
+		indent := strings.Repeat(" ", len(prefix))
+		for i, ln := range strings.Split(n.Text, "\n") {
+			switch {
+			case i == 0:
+				out = append(out, prefix+ln)
+			case ln == "":
+				out = append(out, "")
+			default:
+				out = append(out, indent+ln)
+			}
+		}
+	}
+	return strings.Join(out, "\n"), refs
+}
+
+// takeFootnoteRefs removes the reference markers from rendered output and
+// returns the footnotes referenced on each line. refs are matched to markers
+// by order, which glamour preserves.
+func takeFootnoteRefs(out string, refs []int) (string, map[int][]int) {
+	if len(refs) == 0 {
+		return out, nil
+	}
+	lines := strings.Split(out, "\n")
+	byLine := map[int][]int{}
+	next := 0
+	for i, ln := range lines {
+		n := strings.Count(ln, string(footnoteRefMarker))
+		if n == 0 {
+			continue
+		}
+		for ; n > 0 && next < len(refs); n-- {
+			byLine[i] = append(byLine[i], refs[next])
+			next++
+		}
+		lines[i] = strings.ReplaceAll(ln, string(footnoteRefMarker), "")
+	}
+	return strings.Join(lines, "\n"), byLine
+}
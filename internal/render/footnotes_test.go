@@ -0,0 +1,73 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	xansi "github.com/charmbracelet/x/ansi"
+)
+
+func TestParseFootnotes_NumberedByFirstReference(t *testing.T) {
+	md := "" +
+		"B[^b] then A[^A] then B again[^b].\n" +
+		"\n" +
+		"[^a]: First\n" +
+		"    continued.\n" +
+		"[^b]: Second.\n" +
+		"[^unused]: Never referenced.\n"
+
+	notes := ParseFootnotes(md)
+	if len(notes) != 2 {
+		t.Fatalf("expected 2 footnotes, got %+v", notes)
+	}
+	if notes[0].Label != "b" || notes[0].Number != 1 || notes[0].Text != "Second." {
+		t.Fatalf("unexpected notes[0]: %+v", notes[0])
+	}
+	if notes[1].Label != "a" || notes[1].Number != 2 || notes[1].Text != "First continued." {
+		t.Fatalf("unexpected notes[1]: %+v", notes[1])
+	}
+}
+
+func TestRenderMarkdown_Footnotes(t *testing.T) {
+	md := "" +
+		"Retries[^r] matter. `[^r]` is code.\n" +
+		"\n" +
+		"[^r]: Exponential backoff.\n"
+
+	out, err := RenderMarkdown(md, Options{Style: "dark", Width: 60})
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain := xansi.Strip(out)
+	for _, want := range []string{"Retries¹ matter.", "[^r]", FootnotesTitle, "1. Exponential backoff."} {
+		if !strings.Contains(plain, want) {
+			t.Fatalf("expected %q in output:\n%s", want, plain)
+		}
+	}
+	if strings.Contains(plain, "[^r]:") {
+		t.Fatalf("footnote definition leaked into body:\n%s", plain)
+	}
+}
+
+func TestRender_FootnoteRefLines(t *testing.T) {
+	md := "Area is $x^2$.\n\nFirst[^a] and second[^b].\n\nAgain[^b].\n\n[^a]: A.\n[^b]: B.\n"
+	r, err := Render(md, Options{Style: "dark", Width: 60})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.ContainsRune(r.Text, footnoteRefMarker) {
+		t.Fatal("reference marker left in output")
+	}
+	lines := strings.Split(xansi.Strip(r.Text), "\n")
+	var got []string
+	for i, ln := range lines {
+		for _, n := range r.FootnoteRefs[i] {
+			got = append(got, fmt.Sprintf("%d@%s", n, strings.TrimSpace(ln)))
+		}
+	}
+	want := []string{"1@First¹ and second².", "2@First¹ and second².", "2@Again²."}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("refs = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,276 @@
+package render
+
+import (
+	"strconv"
+	"strings"
+)
+
+// FrontMatter is the metadata block at the very top of a document, delimited by
+// "---" (YAML) or "+++" (TOML). Only a flat view is kept: nested keys are joined
+// with "." and lists are joined with ", ". That is all we need for display.
+type FrontMatter struct {
+	Format string // yaml|toml
+	Fields []MetaField
+}
+
+type MetaField struct {
+	Key   string
+	Value string
+}
+
+func (f FrontMatter) Empty() bool { return len(f.Fields) == 0 }
+
+// Get returns the value of a top-level key (case-insensitive).
+func (f FrontMatter) Get(key string) (string, bool) {
+	for _, fld := range f.Fields {
+		if strings.EqualFold(fld.Key, key) {
+			return fld.Value, true
+		}
+	}
+	return "", false
+}
+
+func (f FrontMatter) Title() string {
+	v, _ := f.Get("title")
+	return strings.TrimSpace(v)
+}
+
+// Markdown returns the metadata as a compact two-column table, ready to be
+// prepended to the document body.
+func (f FrontMatter) Markdown() string {
+	if f.Empty() {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("| Key | Value |\n|---|---|\n")
+	for _, fld := range f.Fields {
+		b.WriteString("| ")
+		b.WriteString(escapeTableCell(fld.Key))
+		b.WriteString(" | ")
+		b.WriteString(escapeTableCell(fld.Value))
+		b.WriteString(" |\n")
+	}
+	return b.String()
+}
+
+func escapeTableCell(s string) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	return strings.ReplaceAll(s, "|", "\\|")
+}
+
+// SplitFrontMatter detects YAML or TOML front matter at the start of md.
+//
+// The returned body keeps the original line count: front matter lines are
+// blanked rather than removed, so raw line numbers (e.g. from heading parsing)
+// stay valid for the remaining document.
+func SplitFrontMatter(md string) (FrontMatter, string, bool) {
+	md = strings.TrimPrefix(md, "\ufeff")
+	lines := strings.Split(strings.ReplaceAll(md, "\r\n", "\n"), "\n")
+	if len(lines) < 2 {
+		return FrontMatter{}, md, false
+	}
+
+	var format string
+	switch strings.TrimRight(lines[0], " \t") {
+	case "---":
+		format = "yaml"
+	case "+++":
+		format = "toml"
+	default:
+		return FrontMatter{}, md, false
+	}
+
+	end := -1
+	for i := 1; i < len(lines); i++ {
+		t := strings.TrimRight(lines[i], " \t")
+		if (format == "yaml" && (t == "---" || t == "...")) || (format == "toml" && t == "+++") {
+			end = i
+			break
+		}
+	}
+	if end < 0 {
+		return FrontMatter{}, md, false
+	}
+
+	var (
+		fields []MetaField
+		ok     bool
+	)
+	if format == "yaml" {
+		fields, ok = parseYAMLFields(lines[1:end])
+	} else {
+		fields, ok = parseTOMLFields(lines[1:end])
+	}
+	if !ok {
+		// A leading thematic break followed by prose is not front matter.
+		return FrontMatter{}, md, false
+	}
+
+	for i := 0; i <= end; i++ {
+		lines[i] = ""
+	}
+	return FrontMatter{Format: format, Fields: fields}, strings.Join(lines, "\n"), true
+}
+
+func parseYAMLFields(lines []string) ([]MetaField, bool) {
+	var (
+		out    []MetaField
+		parent string // key of the current block (list, map or block scalar)
+		block  []string
+		scalar bool // parent is a "|" or ">" block scalar
+	)
+
+	flush := func() {
+		if parent == "" || len(block) == 0 {
+			block = nil
+			return
+		}
+		sep := ", "
+		if scalar {
+			sep = " "
+		}
+		out = append(out, MetaField{Key: parent, Value: strings.Join(block, sep)})
+		block = nil
+	}
+
+	for _, ln := range lines {
+		t := strings.TrimSpace(ln)
+		if t == "" || strings.HasPrefix(t, "#") {
+			continue
+		}
+		indented := ln[0] == ' ' || ln[0] == '\t'
+
+		if indented && parent != "" {
+			switch {
+			case scalar:
+				block = append(block, t)
+			case strings.HasPrefix(t, "- ") || t == "-":
+				block = append(block, yamlScalar(strings.TrimSpace(strings.TrimPrefix(t, "-"))))
+			default:
+				k, v, ok := splitYAMLKey(t)
+				if !ok {
+					block = append(block, t)
+					continue
+				}
+				out = append(out, MetaField{Key: parent + "." + k, Value: yamlScalar(v)})
+			}
+			continue
+		}
+		if indented {
+			continue
+		}
+
+		flush()
+		parent, scalar = "", false
+
+		k, v, ok := splitYAMLKey(t)
+		if !ok {
+			return nil, false
+		}
+		switch v {
+		case "":
+			parent = k
+		case "|", "|-", "|+", ">", ">-", ">+":
+			parent, scalar = k, true
+		default:
+			out = append(out, MetaField{Key: k, Value: yamlScalar(v)})
+		}
+	}
+	flush()
+	return out, true
+}
+
+func splitYAMLKey(t string) (string, string, bool) {
+	i := strings.Index(t, ":")
+	if i <= 0 {
+		return "", "", false
+	}
+	// "key:value" without a space is a plain scalar in YAML, not a mapping.
+	if i+1 < len(t) && t[i+1] != ' ' && t[i+1] != '\t' {
+		return "", "", false
+	}
+	k := strings.TrimSpace(t[:i])
+	quoted := false
+	if uq, err := strconv.Unquote(k); err == nil {
+		k, quoted = uq, true
+	} else if len(k) >= 2 && k[0] == '\'' && k[len(k)-1] == '\'' {
+		k, quoted = k[1:len(k)-1], true
+	}
+	if k == "" || (!quoted && strings.ContainsAny(k, " \t")) {
+		return "", "", false
+	}
+	return k, strings.TrimSpace(t[i+1:]), true
+}
+
+func yamlScalar(v string) string {
+	v = strings.TrimSpace(v)
+	if strings.HasPrefix(v, "[") && strings.HasSuffix(v, "]") {
+		return joinInlineList(v[1 : len(v)-1])
+	}
+	if len(v) >= 2 && v[0] == '\'' && v[len(v)-1] == '\'' {
+		return strings.ReplaceAll(v[1:len(v)-1], "''", "'")
+	}
+	if uq, err := strconv.Unquote(v); err == nil && strings.HasPrefix(v, "\"") {
+		return uq
+	}
+	// Drop trailing comments on plain scalars.
+	if i := strings.Index(v, " #"); i >= 0 {
+		v = strings.TrimSpace(v[:i])
+	}
+	return v
+}
+
+func parseTOMLFields(lines []string) ([]MetaField, bool) {
+	var (
+		out     []MetaField
+		section string
+	)
+	for _, ln := range lines {
+		t := strings.TrimSpace(ln)
+		if t == "" || strings.HasPrefix(t, "#") {
+			continue
+		}
+		if strings.HasPrefix(t, "[") && strings.HasSuffix(t, "]") {
+			section = strings.Trim(t, "[] ")
+			continue
+		}
+		i := strings.Index(t, "=")
+		if i <= 0 {
+			return nil, false
+		}
+		k := strings.Trim(strings.TrimSpace(t[:i]), "\"'")
+		if section != "" {
+			k = section + "." + k
+		}
+		out = append(out, MetaField{Key: k, Value: tomlValue(strings.TrimSpace(t[i+1:]))})
+	}
+	return out, true
+}
+
+func tomlValue(v string) string {
+	if strings.HasPrefix(v, "[") && strings.HasSuffix(v, "]") {
+		return joinInlineList(v[1 : len(v)-1])
+	}
+	if strings.HasPrefix(v, "'") && strings.HasSuffix(v, "'") && len(v) >= 2 {
+		return v[1 : len(v)-1]
+	}
+	if uq, err := strconv.Unquote(v); err == nil && strings.HasPrefix(v, "\"") {
+		return uq
+	}
+	if i := strings.Index(v, " #"); i >= 0 {
+		v = strings.TrimSpace(v[:i])
+	}
+	return v
+}
+
+func joinInlineList(s string) string {
+	var items []string
+	for _, it := range strings.Split(s, ",") {
+		it = strings.TrimSpace(it)
+		if it == "" {
+			continue
+		}
+		items = append(items, yamlScalar(it))
+	}
+	return strings.Join(items, ", ")
+}
@@ -0,0 +1,56 @@
+package render
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSplitFrontMatter_YAML(t *testing.T) {
+	md := "" +
+		"---\n" +
+		"title: \"Hello: World\"\n" +
+		"tags: [a, b]\n" +
+		"authors:\n" +
+		"  - alice\n" +
+		"  - bob\n" +
+		"---\n" +
+		"# Heading\n"
+
+	fm, body, ok := SplitFrontMatter(md)
+	if !ok {
+		t.Fatalf("expected front matter")
+	}
+	if fm.Format != "yaml" || fm.Title() != "Hello: World" {
+		t.Fatalf("unexpected front matter: %+v", fm)
+	}
+	if v, _ := fm.Get("tags"); v != "a, b" {
+		t.Fatalf("tags=%q", v)
+	}
+	if v, _ := fm.Get("authors"); v != "alice, bob" {
+		t.Fatalf("authors=%q", v)
+	}
+
+	// Line numbers must be preserved.
+	lines := strings.Split(body, "\n")
+	if len(lines) != len(strings.Split(md, "\n")) || lines[7] != "# Heading" {
+		t.Fatalf("body lines shifted: %q", body)
+	}
+}
+
+func TestSplitFrontMatter_TOML(t *testing.T) {
+	md := "+++\ntitle = 'Doc'\n[params]\ndraft = true\n+++\nbody\n"
+	fm, _, ok := SplitFrontMatter(md)
+	if !ok || fm.Format != "toml" || fm.Title() != "Doc" {
+		t.Fatalf("unexpected: ok=%v fm=%+v", ok, fm)
+	}
+	if v, _ := fm.Get("params.draft"); v != "true" {
+		t.Fatalf("params.draft=%q", v)
+	}
+}
+
+func TestSplitFrontMatter_ThematicBreakIsNotFrontMatter(t *testing.T) {
+	md := "---\nJust some prose here.\n---\n"
+	if _, body, ok := SplitFrontMatter(md); ok || body != md {
+		t.Fatalf("thematic break misdetected as front matter")
+	}
+}
@@ -0,0 +1,342 @@
+package render
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // register decoders for image.Decode
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/simota/md/internal/mdblock"
+)
+
+// Image modes accepted in Options.Images.
+const (
+	ImagesKitty  = "kitty"
+	ImagesITerm  = "iterm"
+	ImagesSixel  = "sixel"
+	ImagesBlocks = "blocks"
+)
+
+const (
+	defaultCellWidth  = 10
+	defaultCellHeight = 20
+	maxImageRows      = 40
+)
+
+// Image is a picture drawn with a terminal graphics protocol. The rendered
+// output reserves Rows lines starting at Line for it, filled with a half-block
+// preview; Seq draws the real image with its top-left corner at the cursor.
+type Image struct {
+	Line, Col  int
+	Rows, Cols int
+	Seq        string
+}
+
+type pendingImage struct {
+	block      int
+	rows, cols int
+	seq        string
+}
+
+// A paragraph that is nothing but an image: ![alt](path "title").
+var imageLineRe = regexp.MustCompile(`^ {0,3}!\[[^\]]*\]\(\s*<?([^\s)>]+)>?(?:\s+"[^"]*")?\s*\)\s*$`)
+
+// preprocessImages replaces standalone local images with a half-block
+// rendering and, for graphics protocols, prepares the escape sequence that
+// draws the image over it. Remote images and decode failures keep glamour's
+// link text.
+func preprocessImages(md string, opts Options, width int, sp *splicer) (string, []pendingImage) {
+	if opts.Images == "" {
+		return md, nil
+	}
+	cellW, cellH := opts.CellWidth, opts.CellHeight
+	if cellW <= 0 || cellH <= 0 {
+		cellW, cellH = defaultCellWidth, defaultCellHeight
+	}
+
+	lines := splitSourceLines(md)
+	var (
+		out     []string
+		pending []pendingImage
+		fence   mdblock.FenceTracker
+	)
+	for _, ln := range lines {
+		if fence.Update(ln) {
+			out = append(out, ln)
+			continue
+		}
+		m := imageLineRe.FindStringSubmatch(ln)
+		if m == nil {
+			out = append(out, ln)
+			continue
+		}
+		img, ok := loadLocalImage(m[1], opts.BaseDir)
+		if !ok {
+			out = append(out, ln)
+			continue
+		}
+
+		cols, rows := imageCells(img.Bounds(), width, cellW, cellH)
+		p := pendingImage{block: len(sp.blocks), rows: rows, cols: cols}
+		switch opts.Images {
+		case ImagesKitty:
+			p.seq = kittyImage(resizeImage(img, cols*cellW, rows*cellH), cols, rows)
+		case ImagesITerm:
+			p.seq = itermImage(resizeImage(img, cols*cellW, rows*cellH), cols, rows)
+		case ImagesSixel:
+			p.seq = encodeSixel(resizeImage(img, cols*cellW, rows*cellH))
+		}
+		out = append(out, sp.placeholder(halfBlocks(resizeImage(img, cols, rows*2))))
+		if p.seq != "" {
+			pending = append(pending, p)
+		}
+	}
+	return strings.Join(out, "\n"), pending
+}
+
+// maxImagePixels caps the decoded size of an image: the header is checked
+// first, so a small file declaring a huge canvas is not decoded.
+const maxImagePixels = 40 << 20
+
+// HasLocalImages reports whether md has a standalone image that
+// preprocessImages would draw, so callers can skip asking the terminal
+// about graphics when there is nothing to show.
+func HasLocalImages(md, baseDir string) bool {
+	var fence mdblock.FenceTracker
+	for _, ln := range splitSourceLines(md) {
+		if fence.Update(ln) {
+			continue
+		}
+		if m := imageLineRe.FindStringSubmatch(ln); m != nil {
+			if _, ok := localImagePath(m[1], baseDir); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// localImagePath resolves an image reference to a file below baseDir.
+// Absolute paths, paths that climb out of the document's directory and
+// symlinks that lead out of it are refused.
+func localImagePath(ref, baseDir string) (string, bool) {
+	if strings.Contains(ref, "://") || strings.HasPrefix(ref, "data:") {
+		return "", false
+	}
+	if u, err := url.PathUnescape(ref); err == nil {
+		ref = u
+	}
+	if !filepath.IsLocal(filepath.FromSlash(ref)) {
+		return "", false
+	}
+	root, err := filepath.Abs(baseDir)
+	if err != nil {
+		return "", false
+	}
+	if root, err = filepath.EvalSymlinks(root); err != nil {
+		return "", false
+	}
+	path, err := filepath.EvalSymlinks(filepath.Join(root, filepath.FromSlash(ref)))
+	if err != nil {
+		return "", false
+	}
+	if rel, err := filepath.Rel(root, path); err != nil || !filepath.IsLocal(rel) {
+		return "", false
+	}
+	return path, true
+}
+
+// loadLocalImage decodes an image below baseDir (see localImagePath).
+func loadLocalImage(ref, baseDir string) (image.Image, bool) {
+	path, ok := localImagePath(ref, baseDir)
+	if !ok {
+		return nil, false
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, false
+	}
+	defer f.Close()
+	cfg, _, err := image.DecodeConfig(f)
+	if err != nil || cfg.Width <= 0 || cfg.Height <= 0 || int64(cfg.Width)*int64(cfg.Height) > maxImagePixels {
+		return nil, false
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, false
+	}
+	img, _, err := image.Decode(f)
+	if err != nil || img.Bounds().Empty() {
+		return nil, false
+	}
+	return img, true
+}
+
+// imageCells picks the size in terminal cells: native size (one cell per
+// cellW pixels) capped to maxCols and maxImageRows, keeping the aspect ratio.
+func imageCells(b image.Rectangle, maxCols, cellW, cellH int) (cols, rows int) {
+	w, h := float64(b.Dx()), float64(b.Dy())
+	cols = min(maxCols, max(1, (b.Dx()+cellW-1)/cellW))
+	rows = max(1, int(math.Round(float64(cols*cellW)*h/w/float64(cellH))))
+	if rows > maxImageRows {
+		rows = maxImageRows
+		cols = max(1, int(math.Round(float64(rows*cellH)*w/h/float64(cellW))))
+	}
+	return cols, rows
+}
+
+// resizeImage scales img to w×h pixels by averaging (a sample of) the source
+// pixels behind each destination pixel.
+func resizeImage(img image.Image, w, h int) *image.NRGBA {
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	b := img.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	for y := 0; y < h; y++ {
+		y0 := b.Min.Y + y*sh/h
+		y1 := max(y0+1, b.Min.Y+(y+1)*sh/h)
+		for x := 0; x < w; x++ {
+			x0 := b.Min.X + x*sw/w
+			x1 := max(x0+1, b.Min.X+(x+1)*sw/w)
+			stepX, stepY := max(1, (x1-x0)/4), max(1, (y1-y0)/4)
+
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy += stepY {
+				for sx := x0; sx < x1; sx += stepX {
+					cr, cg, cb, ca := img.At(sx, sy).RGBA()
+					r, g, bl, a = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			c := color.NRGBA{}
+			if a > 0 {
+				// Colors are premultiplied; undo that to get straight alpha.
+				c = color.NRGBA{
+					R: uint8(r * 0xff / a),
+					G: uint8(g * 0xff / a),
+					B: uint8(bl * 0xff / a),
+					A: uint8(a / n >> 8),
+				}
+			}
+			dst.SetNRGBA(x, y, c)
+		}
+	}
+	return dst
+}
+
+// halfBlocks draws two pixel rows per line with "▀": the foreground is the
+// upper pixel, the background the lower one.
+func halfBlocks(img *image.NRGBA) []string {
+	b := img.Bounds()
+	var out []string
+	for y := 0; y+1 < b.Dy(); y += 2 {
+		var sb strings.Builder
+		for x := 0; x < b.Dx(); x++ {
+			top, bot := img.NRGBAAt(x, y), img.NRGBAAt(x, y+1)
+			topOK, botOK := top.A >= 0x80, bot.A >= 0x80
+			switch {
+			case topOK && botOK:
+				fmt.Fprintf(&sb, "\x1b[38;2;%d;%d;%dm\x1b[48;2;%d;%d;%dm▀", top.R, top.G, top.B, bot.R, bot.G, bot.B)
+			case topOK:
+				fmt.Fprintf(&sb, "\x1b[49m\x1b[38;2;%d;%d;%dm▀", top.R, top.G, top.B)
+			case botOK:
+				fmt.Fprintf(&sb, "\x1b[49m\x1b[38;2;%d;%d;%dm▄", bot.R, bot.G, bot.B)
+			default:
+				sb.WriteString("\x1b[0m ")
+			}
+		}
+		sb.WriteString("\x1b[0m")
+		out = append(out, sb.String())
+	}
+	return out
+}
+
+func encodePNG(img image.Image) []byte {
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, img)
+	return buf.Bytes()
+}
+
+// kittyImage transmits and places a PNG in one go (a=T), scaled to cols×rows
+// cells, without moving the cursor (C=1).
+func kittyImage(img image.Image, cols, rows int) string {
+	data := base64.StdEncoding.EncodeToString(encodePNG(img))
+	const chunk = 4096
+	var sb strings.Builder
+	for i := 0; i < len(data); i += chunk {
+		end := min(len(data), i+chunk)
+		more := 0
+		if end < len(data) {
+			more = 1
+		}
+		if i == 0 {
+			fmt.Fprintf(&sb, "\x1b_Ga=T,f=100,q=2,C=1,c=%d,r=%d,m=%d;%s\x1b\\", cols, rows, more, data[i:end])
+		} else {
+			fmt.Fprintf(&sb, "\x1b_Gm=%d;%s\x1b\\", more, data[i:end])
+		}
+	}
+	return sb.String()
+}
+
+func itermImage(img image.Image, cols, rows int) string {
+	data := encodePNG(img)
+	return fmt.Sprintf("\x1b]1337;File=inline=1;size=%d;width=%d;height=%d;preserveAspectRatio=0:%s\a",
+		len(data), cols, rows, base64.StdEncoding.EncodeToString(data))
+}
+
+// KittyClearImages deletes all kitty image placements on screen. The pager
+// sends it before redrawing images so scrolled-away ones do not linger.
+const KittyClearImages = "\x1b_Ga=d,d=a,q=2\x1b\\"
+
+// ImageOverlay draws im with the cursor on the reserved block's last line at
+// the block's column, then restores the cursor.
+func ImageOverlay(im Image) string {
+	up := ""
+	if im.Rows > 1 {
+		up = fmt.Sprintf("\x1b[%dA", im.Rows-1)
+	}
+	return "\x1b7" + up + im.Seq + "\x1b8"
+}
+
+// placeImages converts pending images to output positions once the splicer
+// knows where their blocks landed.
+func placeImages(sp *splicer, pending []pendingImage) []Image {
+	var out []Image
+	for _, p := range pending {
+		at := sp.at[p.block]
+		if at.line < 0 {
+			continue
+		}
+		out = append(out, Image{Line: at.line, Col: at.col, Rows: p.rows, Cols: p.cols, Seq: p.seq})
+	}
+	return out
+}
+
+// drawImages replaces each image's half-block preview with blank rows and
+// draws the image over them from the last row, for printing to a terminal.
+func drawImages(out string, images []Image) string {
+	if len(images) == 0 {
+		return out
+	}
+	lines := strings.Split(out, "\n")
+	for _, im := range images {
+		if im.Line+im.Rows > len(lines) {
+			continue
+		}
+		pad := strings.Repeat(" ", im.Col)
+		for i := im.Line; i < im.Line+im.Rows; i++ {
+			lines[i] = pad
+		}
+		lines[im.Line+im.Rows-1] += ImageOverlay(im)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,140 @@
+package render
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	xansi "github.com/charmbracelet/x/ansi"
+)
+
+func writeTestPNG(t *testing.T, dir string, w, h int) {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: 200, G: 40, B: 40, A: 255})
+		}
+	}
+	f, err := os.Create(filepath.Join(dir, "pic.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, img); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRenderWithImages_HalfBlocks(t *testing.T) {
+	dir := t.TempDir()
+	writeTestPNG(t, dir, 200, 100)
+
+	md := "![pic](pic.png)\n\n![remote](https://example.com/x.png)\n"
+	out, images, err := RenderWithImages(md, Options{Style: "dark", Width: 60, Images: ImagesBlocks, BaseDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(images) != 0 {
+		t.Fatalf("half blocks need no overlay, got %+v", images)
+	}
+	plain := xansi.Strip(out)
+	// 200×100 px at 10×20 px per cell: 20 columns, 5 rows.
+	if n := strings.Count(plain, strings.Repeat("▀", 20)); n != 5 {
+		t.Fatalf("expected 5 rows of half blocks, got %d:\n%s", n, plain)
+	}
+	if !strings.Contains(plain, "remote") {
+		t.Fatalf("remote image should stay as link text:\n%s", plain)
+	}
+}
+
+func TestRenderWithImages_KittyPlacement(t *testing.T) {
+	dir := t.TempDir()
+	writeTestPNG(t, dir, 100, 40)
+
+	out, images, err := RenderWithImages("Intro\n\n![pic](pic.png)\n", Options{Style: "dark", Width: 60, Images: ImagesKitty, BaseDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(images) != 1 {
+		t.Fatalf("expected 1 image, got %d", len(images))
+	}
+	im := images[0]
+	if im.Cols != 10 || im.Rows != 2 || !strings.HasPrefix(im.Seq, "\x1b_Ga=T,f=100") {
+		t.Fatalf("unexpected image: %+v", im)
+	}
+	lines := strings.Split(out, "\n")
+	if !strings.Contains(xansi.Strip(lines[im.Line]), "▀") {
+		t.Fatalf("image line %d should hold the preview:\n%s", im.Line, xansi.Strip(out))
+	}
+}
+
+func TestLoadLocalImage_RefusesHugeAndOutsideImages(t *testing.T) {
+	dir := t.TempDir()
+	writeTestPNG(t, dir, 4, 4)
+	sub := filepath.Join(dir, "doc")
+	if err := os.Mkdir(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := loadLocalImage("../pic.png", sub); ok {
+		t.Fatal("image outside the document directory was loaded")
+	}
+	if _, ok := loadLocalImage(filepath.Join(dir, "pic.png"), sub); ok {
+		t.Fatal("absolute image path was loaded")
+	}
+
+	// Declare a 30000×30000 canvas in the IHDR chunk of a tiny PNG.
+	data, err := os.ReadFile(filepath.Join(dir, "pic.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	binary.BigEndian.PutUint32(data[16:], 30000)
+	binary.BigEndian.PutUint32(data[20:], 30000)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+	if err := os.WriteFile(filepath.Join(sub, "huge.png"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := loadLocalImage("huge.png", sub); ok {
+		t.Fatal("oversized image was decoded")
+	}
+	if _, ok := loadLocalImage("doc/../pic.png", dir); !ok {
+		t.Fatal("local image was refused")
+	}
+
+	// A symlink is followed only while it stays in the directory.
+	if err := os.Symlink(filepath.Join(dir, "pic.png"), filepath.Join(sub, "out.png")); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+	if _, ok := loadLocalImage("out.png", sub); ok {
+		t.Fatal("symlink out of the document directory was followed")
+	}
+	if err := os.Symlink("pic.png", filepath.Join(dir, "in.png")); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := loadLocalImage("in.png", dir); !ok {
+		t.Fatal("symlink inside the document directory was refused")
+	}
+}
+
+func TestHasLocalImages(t *testing.T) {
+	dir := t.TempDir()
+	writeTestPNG(t, dir, 4, 4)
+	cases := map[string]bool{
+		"# Doc\n\n![a](pic.png)\n":            true,
+		"# Doc\n\n![a](missing.png)\n":        false,
+		"# Doc\n\n![a](https://x.io/a.png)\n": false,
+		"```\n![a](pic.png)\n```\n":           false,
+		"# Doc\n":                             false,
+	}
+	for md, want := range cases {
+		if got := HasLocalImages(md, dir); got != want {
+			t.Errorf("HasLocalImages(%q) = %v, want %v", md, got, want)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package render
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	xansi "github.com/charmbracelet/x/ansi"
+
+	"github.com/simota/md/internal/mdblock"
+)
+
+// Link text is wrapped in invisible marker runes before glamour runs; after
+// rendering, the markers are swapped for OSC 8 hyperlink sequences. Targets
+// are matched to markers by order, which glamour preserves.
+const (
+	linkOpenMarker  = '⁣' // INVISIBLE SEPARATOR
+	linkCloseMarker = '⁢' // INVISIBLE TIMES
+)
+
+// hiddenLinkURL is a fragment-only destination; glamour prints no URL after
+// the text of such links.
+const hiddenLinkURL = "#"
+
+var (
+	// [text](dest "title"), allowing one level of nested brackets in text
+	// (e.g. a linked image).
+	inlineLinkRe = regexp.MustCompile(`(!?)\[((?:[^\[\]]|\[[^\[\]]*\])*)\]\(\s*(<[^>]*>|[^\s()]*)(?:\s+(?:"[^"]*"|'[^']*'))?\s*\)`)
+	// [text][ref], [text][] and [ref].
+	refLinkRe = regexp.MustCompile(`(!?)\[((?:[^\[\]]|\[[^\[\]]*\])*)\](?:\[([^\[\]]*)\])?`)
+	linkDefRe = regexp.MustCompile(`^ {0,3}\[([^\]]+)\]:\s*<?([^\s>]+)>?`)
+	bareURLRe = regexp.MustCompile(`https?://[^\s<>"\x1b` + string(linkOpenMarker) + string(linkCloseMarker) + `]+`)
+)
+
+type linkRewriter struct {
+	hyperlinks bool
+	hideURLs   bool
+	baseDir    string
+	defs       map[string]string
+	targets    []string // hyperlink targets in document order
+}
+
+// preprocessLinks marks link text for OSC 8 hyperlinks and, when hideURLs is
+// set, drops the URL glamour would print after it. It returns the targets
+// for postprocessLinks.
+func preprocessLinks(md string, opts Options) (string, []string) {
+	if !opts.Hyperlinks && !opts.HideURLs {
+		return md, nil
+	}
+	lines := splitSourceLines(md)
+	lr := &linkRewriter{
+		hyperlinks: opts.Hyperlinks,
+		hideURLs:   opts.HideURLs,
+		baseDir:    opts.BaseDir,
+		defs:       map[string]string{},
+	}
+
+	var fence mdblock.FenceTracker
+	for _, ln := range lines {
+		if fence.Update(ln) {
+			continue
+		}
+		if m := linkDefRe.FindStringSubmatch(ln); m != nil && !strings.HasPrefix(m[1], "^") {
+			lr.defs[strings.ToLower(m[1])] = m[2]
+		}
+	}
+
+	fence = mdblock.FenceTracker{}
+	for i, ln := range lines {
+		if fence.Update(ln) || linkDefRe.MatchString(ln) {
+			continue
+		}
+		lines[i] = mapOutsideCode(ln, lr.rewrite)
+	}
+	return strings.Join(lines, "\n"), lr.targets
+}
+
+func (lr *linkRewriter) rewrite(s string) string {
+	s = inlineLinkRe.ReplaceAllStringFunc(s, func(m string) string {
+		sm := inlineLinkRe.FindStringSubmatch(m)
+		if sm[1] == "!" {
+			return m // images
+		}
+		dest := strings.TrimSuffix(strings.TrimPrefix(sm[3], "<"), ">")
+		return lr.link(sm[2], dest, m)
+	})
+	if len(lr.defs) == 0 {
+		return s
+	}
+	return refLinkRe.ReplaceAllStringFunc(s, func(m string) string {
+		sm := refLinkRe.FindStringSubmatch(m)
+		if sm[1] == "!" || strings.ContainsRune(sm[2], linkOpenMarker) {
+			return m
+		}
+		label := sm[3]
+		if label == "" {
+			label = sm[2]
+		}
+		dest, ok := lr.defs[strings.ToLower(label)]
+		if !ok {
+			return m
+		}
+		return lr.link(sm[2], dest, m)
+	})
+}
+
+// link rewrites one link with text and destination; orig is the source.
+func (lr *linkRewriter) link(text, dest, orig string) string {
+	if strings.TrimSpace(text) == "" || strings.HasPrefix(dest, "#") {
+		return orig
+	}
+	if lr.hyperlinks {
+		lr.targets = append(lr.targets, lr.resolve(dest))
+		text = string(linkOpenMarker) + text + string(linkCloseMarker)
+	}
+	if lr.hideURLs {
+		dest = hiddenLinkURL
+	}
+	return "[" + text + "](" + dest + ")"
+}
+
+// resolve turns a relative link into a file:// URL against the document
+// directory. Absolute URLs (any scheme) are kept.
+func (lr *linkRewriter) resolve(dest string) string {
+	if u, err := url.Parse(dest); err != nil || u.Scheme != "" {
+		return dest
+	}
+	path, frag, _ := strings.Cut(dest, "#")
+	if p, err := url.PathUnescape(path); err == nil {
+		path = p
+	}
+	if !filepath.IsAbs(path) {
+		base := lr.baseDir
+		if base == "" {
+			base, _ = os.Getwd()
+		}
+		path = filepath.Join(base, path)
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path), Fragment: frag}).String()
+}
+
+// postprocessLinks replaces link markers with OSC 8 sequences and makes bare
+// http(s) URLs clickable. Every line is self-contained: a link that wraps is
+// closed at the end of the line and reopened on the next.
+func postprocessLinks(out string, targets []string, hyperlinks bool) string {
+	if !hyperlinks {
+		return out
+	}
+	lines := strings.Split(out, "\n")
+	next := 0
+	open := "" // target of the link spanning into the current line
+	for i, ln := range lines {
+		var b strings.Builder
+		if open != "" {
+			b.WriteString(xansi.SetHyperlink(open))
+		}
+		rest := ln
+		for rest != "" {
+			j := strings.IndexAny(rest, string(linkOpenMarker)+string(linkCloseMarker))
+			seg := rest
+			if j >= 0 {
+				seg = rest[:j]
+			}
+			if open == "" {
+				seg = linkBareURLs(seg)
+			}
+			b.WriteString(seg)
+			if j < 0 {
+				break
+			}
+			if strings.HasPrefix(rest[j:], string(linkOpenMarker)) {
+				if next < len(targets) {
+					open = targets[next]
+					next++
+					b.WriteString(xansi.SetHyperlink(open))
+				}
+				rest = rest[j+len(string(linkOpenMarker)):]
+				continue
+			}
+			if open != "" {
+				b.WriteString(xansi.ResetHyperlink())
+				open = ""
+			}
+			rest = rest[j+len(string(linkCloseMarker)):]
+		}
+		if open != "" {
+			b.WriteString(xansi.ResetHyperlink())
+		}
+		lines[i] = b.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+func linkBareURLs(s string) string {
+	if !strings.Contains(s, "://") {
+		return s
+	}
+	return bareURLRe.ReplaceAllStringFunc(s, func(u string) string {
+		trimmed := strings.TrimRight(u, ".,;:!?)'")
+		return xansi.SetHyperlink(trimmed) + trimmed + xansi.ResetHyperlink() + u[len(trimmed):]
+	})
+}
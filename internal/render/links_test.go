@@ -0,0 +1,44 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	xansi "github.com/charmbracelet/x/ansi"
+)
+
+func TestRenderMarkdown_Hyperlinks(t *testing.T) {
+	md := "Read [the guide](docs/guide.md#intro) or [home][h].\n\n[h]: https://example.com\n"
+
+	out, err := RenderMarkdown(md, Options{Style: "dark", Width: 80, Hyperlinks: true, BaseDir: "/srv/doc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"\x1b]8;;file:///srv/doc/docs/guide.md#intro\a",
+		"\x1b]8;;https://example.com\a",
+		"\x1b]8;;\a",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in output:\n%q", want, out)
+		}
+	}
+	if strings.ContainsRune(out, linkOpenMarker) || strings.ContainsRune(out, linkCloseMarker) {
+		t.Fatalf("link markers leaked into output:\n%q", out)
+	}
+}
+
+func TestRenderMarkdown_HideURLs(t *testing.T) {
+	md := "Visit [the site](https://example.com/page).\n"
+
+	out, err := RenderMarkdown(md, Options{Style: "dark", Width: 80, HideURLs: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out, "example.com") {
+		t.Fatalf("URL should be hidden:\n%q", out)
+	}
+	if !strings.Contains(xansi.Strip(out), "the site") {
+		t.Fatalf("link text missing:\n%q", out)
+	}
+}
@@ -0,0 +1,934 @@
+package render
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	xansi "github.com/charmbracelet/x/ansi"
+	gast "github.com/yuin/goldmark/ast"
+
+	"github.com/simota/md/internal/mdblock"
+)
+
+// LaTeX math is converted to Unicode text before glamour sees the document:
+// inline "$...$" becomes a single line (x², aᵢ, a/b), while "$$...$$" blocks and
+// ```math fences are laid out in 2D with stacked fractions and limits.
+
+type mathKind int
+
+const (
+	mathAtom mathKind = iota
+	mathRow
+	mathFrac
+	mathSqrt
+	mathScripts
+	mathBigOp
+)
+
+type mathClass int
+
+const (
+	classOrd mathClass = iota
+	classBin           // + - × ...
+	classRel           // = < ≤ → ...
+	classPunct
+	classOpen
+)
+
+type mathNode struct {
+	kind  mathKind
+	text  string
+	class mathClass
+
+	items []*mathNode // mathRow
+
+	a, b  *mathNode // frac num/den, sqrt arg/index, scripts base
+	sup   *mathNode
+	sub   *mathNode
+	noBar bool // \binom
+}
+
+var mathSymbols = map[string]string{
+	// Greek
+	"alpha": "α", "beta": "β", "gamma": "γ", "delta": "δ", "epsilon": "ϵ", "varepsilon": "ε",
+	"zeta": "ζ", "eta": "η", "theta": "θ", "vartheta": "ϑ", "iota": "ι", "kappa": "κ",
+	"lambda": "λ", "mu": "μ", "nu": "ν", "xi": "ξ", "pi": "π", "varpi": "ϖ", "rho": "ρ",
+	"varrho": "ϱ", "sigma": "σ", "varsigma": "ς", "tau": "τ", "upsilon": "υ", "phi": "ϕ",
+	"varphi": "φ", "chi": "χ", "psi": "ψ", "omega": "ω",
+	"Gamma": "Γ", "Delta": "Δ", "Theta": "Θ", "Lambda": "Λ", "Xi": "Ξ", "Pi": "Π",
+	"Sigma": "Σ", "Upsilon": "Υ", "Phi": "Φ", "Psi": "Ψ", "Omega": "Ω",
+	// Misc
+	"infty": "∞", "partial": "∂", "nabla": "∇", "emptyset": "∅", "varnothing": "∅",
+	"forall": "∀", "exists": "∃", "neg": "¬", "lnot": "¬", "angle": "∠", "degree": "°",
+	"prime": "′", "hbar": "ℏ", "ell": "ℓ", "Re": "ℜ", "Im": "ℑ", "aleph": "ℵ",
+	"ldots": "…", "dots": "…", "cdots": "⋯", "vdots": "⋮", "ddots": "⋱",
+	"langle": "⟨", "rangle": "⟩", "lfloor": "⌊", "rfloor": "⌋", "lceil": "⌈", "rceil": "⌉",
+	"{": "{", "}": "}", "|": "‖", "lbrace": "{", "rbrace": "}", "vert": "|", "Vert": "‖",
+	"%": "%", "$": "$", "#": "#", "&": "&", "_": "_",
+	"top": "⊤", "bot": "⊥", "checkmark": "✓", "star": "⋆", "dagger": "†",
+}
+
+var mathBinary = map[string]string{
+	"times": "×", "cdot": "·", "pm": "±", "mp": "∓", "div": "÷", "ast": "∗", "circ": "∘",
+	"cup": "∪", "cap": "∩", "land": "∧", "wedge": "∧", "lor": "∨", "vee": "∨",
+	"oplus": "⊕", "otimes": "⊗", "setminus": "∖", "bullet": "∙",
+}
+
+var mathRelations = map[string]string{
+	"leq": "≤", "le": "≤", "geq": "≥", "ge": "≥", "neq": "≠", "ne": "≠", "approx": "≈",
+	"equiv": "≡", "sim": "∼", "simeq": "≃", "cong": "≅", "propto": "∝", "ll": "≪", "gg": "≫",
+	"in": "∈", "notin": "∉", "ni": "∋", "subset": "⊂", "subseteq": "⊆", "supset": "⊃",
+	"supseteq": "⊇", "perp": "⊥", "parallel": "∥", "mid": "∣",
+	"to": "→", "rightarrow": "→", "leftarrow": "←", "gets": "←", "leftrightarrow": "↔",
+	"Rightarrow": "⇒", "Leftarrow": "⇐", "Leftrightarrow": "⇔", "implies": "⟹",
+	"iff": "⟺", "mapsto": "↦", "longrightarrow": "⟶", "longleftarrow": "⟵",
+	"uparrow": "↑", "downarrow": "↓",
+}
+
+// Big operators take limits above/below in display math.
+var mathBigOps = map[string]string{
+	"sum": "∑", "prod": "∏", "coprod": "∐", "bigcup": "⋃", "bigcap": "⋂",
+	"bigoplus": "⨁", "bigotimes": "⨂", "lim": "lim", "limsup": "lim sup",
+	"liminf": "lim inf", "max": "max", "min": "min", "sup": "sup", "inf": "inf",
+	"argmax": "argmax", "argmin": "argmin",
+}
+
+// Integrals keep their scripts on the side.
+var mathIntegrals = map[string]string{
+	"int": "∫", "iint": "∬", "iiint": "∭", "oint": "∮",
+}
+
+var mathFunctions = map[string]bool{
+	"sin": true, "cos": true, "tan": true, "cot": true, "sec": true, "csc": true,
+	"arcsin": true, "arccos": true, "arctan": true, "sinh": true, "cosh": true, "tanh": true,
+	"log": true, "ln": true, "lg": true, "exp": true, "det": true, "dim": true, "ker": true,
+	"gcd": true, "deg": true, "arg": true, "Pr": true, "mod": true, "bmod": true,
+}
+
+var mathSpaces = map[string]string{
+	",": " ", ":": " ", ";": " ", " ": " ", "quad": "  ", "qquad": "    ", "!": "",
+	"enspace": " ", "thinspace": " ",
+}
+
+var mathAccents = map[string]string{
+	"hat": "̂", "widehat": "̂", "bar": "̅", "overline": "̅",
+	"vec": "⃗", "dot": "̇", "ddot": "̈", "tilde": "̃",
+	"widetilde": "̃", "underline": "̲",
+}
+
+var mathBlackboard = map[rune]string{
+	'N': "ℕ", 'Z': "ℤ", 'Q': "ℚ", 'R': "ℝ", 'C': "ℂ", 'P': "ℙ", 'H': "ℍ", 'E': "𝔼",
+}
+
+// Commands that only affect sizing or style; their argument (if any) is kept.
+var mathIgnored = map[string]bool{
+	"displaystyle": true, "textstyle": true, "scriptstyle": true, "limits": true, "nolimits": true,
+	"big": true, "Big": true, "bigg": true, "Bigg": true, "bigl": true, "bigr": true,
+	"Bigl": true, "Bigr": true, "biggl": true, "biggr": true, "Biggl": true, "Biggr": true,
+	"middle": true, "nonumber": true, "notag": true,
+}
+
+var mathStyleCommands = map[string]bool{
+	"mathbf": true, "mathit": true, "mathcal": true, "mathsf": true, "mathtt": true,
+	"mathscr": true, "mathfrak": true, "boldsymbol": true, "bm": true, "mathnormal": true,
+}
+
+type mathParser struct {
+	s string
+	i int
+}
+
+func parseMath(s string) *mathNode {
+	p := &mathParser{s: s}
+	return p.row(false)
+}
+
+func (p *mathParser) peek() byte {
+	if p.i >= len(p.s) {
+		return 0
+	}
+	return p.s[p.i]
+}
+
+func (p *mathParser) skipSpace() {
+	for p.i < len(p.s) && (p.s[p.i] == ' ' || p.s[p.i] == '\t' || p.s[p.i] == '\n') {
+		p.i++
+	}
+}
+
+// row parses atoms until the end of input or a closing brace (when inGroup).
+func (p *mathParser) row(inGroup bool) *mathNode {
+	r := &mathNode{kind: mathRow}
+	for {
+		p.skipSpace()
+		c := p.peek()
+		if c == 0 {
+			return r
+		}
+		if c == '}' {
+			if inGroup {
+				p.i++
+				return r
+			}
+			p.i++
+			continue
+		}
+		if strings.HasPrefix(p.s[p.i:], `\right`) {
+			p.i += len(`\right`)
+			if d := p.delimiter(); d != "" {
+				r.items = append(r.items, &mathNode{kind: mathAtom, text: d})
+			}
+			continue
+		}
+		if c == '^' || c == '_' {
+			// Script with an empty base, e.g. "{}^{14}C".
+			r.items = append(r.items, p.scripts(&mathNode{kind: mathAtom}))
+			continue
+		}
+		n := p.atom()
+		if n == nil {
+			continue
+		}
+		p.skipSpace()
+		if c := p.peek(); c == '^' || c == '_' {
+			n = p.scripts(n)
+		}
+		r.items = append(r.items, n)
+	}
+}
+
+func (p *mathParser) scripts(base *mathNode) *mathNode {
+	kind := mathScripts
+	if base.kind == mathBigOp {
+		kind = mathBigOp
+	}
+	n := &mathNode{kind: kind, a: base, text: base.text}
+	for {
+		p.skipSpace()
+		switch p.peek() {
+		case '^':
+			p.i++
+			n.sup = p.arg()
+		case '_':
+			p.i++
+			n.sub = p.arg()
+		default:
+			return n
+		}
+	}
+}
+
+// arg parses a command or script argument: a braced group or a single token.
+func (p *mathParser) arg() *mathNode {
+	p.skipSpace()
+	if p.peek() == '{' {
+		p.i++
+		return p.row(true)
+	}
+	if p.peek() >= '0' && p.peek() <= '9' {
+		// Only the first digit binds: x^23 is x²·3 in LaTeX.
+		p.i++
+		return &mathNode{kind: mathAtom, text: p.s[p.i-1 : p.i]}
+	}
+	n := p.atom()
+	if n == nil {
+		return &mathNode{kind: mathRow}
+	}
+	return n
+}
+
+// rawArg returns the literal text of a braced argument (for \text and friends).
+func (p *mathParser) rawArg() string {
+	p.skipSpace()
+	if p.peek() != '{' {
+		if p.i < len(p.s) {
+			_, size := utf8.DecodeRuneInString(p.s[p.i:])
+			p.i += size
+			return p.s[p.i-size : p.i]
+		}
+		return ""
+	}
+	depth := 0
+	start := p.i + 1
+	for ; p.i < len(p.s); p.i++ {
+		switch p.s[p.i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				p.i++
+				return p.s[start : p.i-1]
+			}
+		}
+	}
+	return p.s[start:]
+}
+
+func (p *mathParser) delimiter() string {
+	p.skipSpace()
+	if p.peek() == '\\' {
+		name := p.command()
+		if v, ok := mathSymbols[name]; ok {
+			return v
+		}
+		if v, ok := mathRelations[name]; ok {
+			return v
+		}
+		return ""
+	}
+	c := p.peek()
+	if c == 0 {
+		return ""
+	}
+	p.i++
+	if c == '.' {
+		return ""
+	}
+	return string(c)
+}
+
+func (p *mathParser) command() string {
+	p.i++ // backslash
+	start := p.i
+	for p.i < len(p.s) && (p.s[p.i] >= 'a' && p.s[p.i] <= 'z' || p.s[p.i] >= 'A' && p.s[p.i] <= 'Z') {
+		p.i++
+	}
+	if p.i == start && p.i < len(p.s) {
+		p.i++ // single non-letter command: \, \{ \\ ...
+	}
+	return p.s[start:p.i]
+}
+
+func (p *mathParser) atom() *mathNode {
+	c := p.peek()
+	switch {
+	case c == '{':
+		p.i++
+		return p.row(true)
+	case c == '\\':
+		return p.commandAtom()
+	case c >= '0' && c <= '9' || c == '.':
+		start := p.i
+		for p.i < len(p.s) && (p.s[p.i] >= '0' && p.s[p.i] <= '9' || p.s[p.i] == '.') {
+			p.i++
+		}
+		return &mathNode{kind: mathAtom, text: p.s[start:p.i]}
+	case c == '&':
+		p.i++
+		return &mathNode{kind: mathAtom, text: " "}
+	}
+
+	r, size := utf8.DecodeRuneInString(p.s[p.i:])
+	p.i += size
+	n := &mathNode{kind: mathAtom, text: string(r)}
+	switch r {
+	case '+', '-', '*':
+		n.class = classBin
+		if r == '*' {
+			n.text = "∗"
+		}
+	case '=', '<', '>':
+		n.class = classRel
+	case ',', ';':
+		n.class = classPunct
+	case '(', '[':
+		n.class = classOpen
+	case '\'':
+		n.text = "′"
+	}
+	return n
+}
+
+func (p *mathParser) commandAtom() *mathNode {
+	name := p.command()
+	atom := func(text string, class mathClass) *mathNode {
+		return &mathNode{kind: mathAtom, text: text, class: class}
+	}
+
+	switch {
+	case name == "\\":
+		return atom("\n", classOrd)
+	case name == "frac" || name == "dfrac" || name == "tfrac" || name == "cfrac":
+		return &mathNode{kind: mathFrac, a: p.arg(), b: p.arg()}
+	case name == "binom" || name == "dbinom" || name == "tbinom":
+		return &mathNode{kind: mathFrac, a: p.arg(), b: p.arg(), noBar: true}
+	case name == "sqrt":
+		n := &mathNode{kind: mathSqrt}
+		p.skipSpace()
+		if p.peek() == '[' {
+			end := strings.IndexByte(p.s[p.i:], ']')
+			if end > 0 {
+				n.b = parseMath(p.s[p.i+1 : p.i+end])
+				p.i += end + 1
+			}
+		}
+		n.a = p.arg()
+		return n
+	case name == "left":
+		return atom(p.delimiter(), classOpen)
+	case name == "text" || name == "textrm" || name == "textit" || name == "textbf" ||
+		name == "mathrm" || name == "operatorname" || name == "mbox":
+		return atom(p.rawArg(), classOrd)
+	case name == "mathbb":
+		var b strings.Builder
+		for _, r := range p.rawArg() {
+			if v, ok := mathBlackboard[r]; ok {
+				b.WriteString(v)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+		return atom(b.String(), classOrd)
+	case name == "begin" || name == "end":
+		p.rawArg() // environment name; the content is laid out as rows
+		return nil
+	case mathStyleCommands[name]:
+		return p.arg()
+	case mathIgnored[name]:
+		return nil
+	}
+
+	if v, ok := mathAccents[name]; ok {
+		return atom(accentText(linearMath(p.arg(), false), v), classOrd)
+	}
+	if v, ok := mathSpaces[name]; ok {
+		return atom(v, classOrd)
+	}
+	if v, ok := mathSymbols[name]; ok {
+		return atom(v, classOrd)
+	}
+	if v, ok := mathBinary[name]; ok {
+		return atom(v, classBin)
+	}
+	if v, ok := mathRelations[name]; ok {
+		return atom(v, classRel)
+	}
+	if v, ok := mathBigOps[name]; ok {
+		return &mathNode{kind: mathBigOp, text: v}
+	}
+	if v, ok := mathIntegrals[name]; ok {
+		return atom(v, classOrd)
+	}
+	if mathFunctions[name] {
+		return atom(name+" ", classOrd)
+	}
+	// Unknown command: show its name rather than a stray backslash.
+	return atom(name, classOrd)
+}
+
+func isIntegral(s string) bool {
+	for _, v := range mathIntegrals {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+func accentText(s, mark string) string {
+	var b strings.Builder
+	for _, r := range s {
+		b.WriteRune(r)
+		if r != ' ' {
+			b.WriteString(mark)
+		}
+	}
+	return b.String()
+}
+
+var superscripts = map[rune]rune{
+	'0': '⁰', '1': '¹', '2': '²', '3': '³', '4': '⁴', '5': '⁵', '6': '⁶', '7': '⁷', '8': '⁸', '9': '⁹',
+	'+': '⁺', '-': '⁻', '=': '⁼', '(': '⁽', ')': '⁾', '′': '′',
+	'a': 'ᵃ', 'b': 'ᵇ', 'c': 'ᶜ', 'd': 'ᵈ', 'e': 'ᵉ', 'f': 'ᶠ', 'g': 'ᵍ', 'h': 'ʰ', 'i': 'ⁱ',
+	'j': 'ʲ', 'k': 'ᵏ', 'l': 'ˡ', 'm': 'ᵐ', 'n': 'ⁿ', 'o': 'ᵒ', 'p': 'ᵖ', 'r': 'ʳ', 's': 'ˢ',
+	't': 'ᵗ', 'u': 'ᵘ', 'v': 'ᵛ', 'w': 'ʷ', 'x': 'ˣ', 'y': 'ʸ', 'z': 'ᶻ',
+	'A': 'ᴬ', 'B': 'ᴮ', 'D': 'ᴰ', 'E': 'ᴱ', 'G': 'ᴳ', 'H': 'ᴴ', 'I': 'ᴵ', 'J': 'ᴶ', 'K': 'ᴷ',
+	'L': 'ᴸ', 'M': 'ᴹ', 'N': 'ᴺ', 'O': 'ᴼ', 'P': 'ᴾ', 'R': 'ᴿ', 'T': 'ᵀ', 'U': 'ᵁ', 'V': 'ⱽ', 'W': 'ᵂ',
+	'α': 'ᵅ', 'β': 'ᵝ', 'γ': 'ᵞ', 'δ': 'ᵟ', 'ε': 'ᵋ', 'θ': 'ᶿ', 'ι': 'ᶥ', 'φ': 'ᶲ', 'χ': 'ᵡ',
+	'∗': '*',
+}
+
+var subscripts = map[rune]rune{
+	'0': '₀', '1': '₁', '2': '₂', '3': '₃', '4': '₄', '5': '₅', '6': '₆', '7': '₇', '8': '₈', '9': '₉',
+	'+': '₊', '-': '₋', '=': '₌', '(': '₍', ')': '₎',
+	'a': 'ₐ', 'e': 'ₑ', 'h': 'ₕ', 'i': 'ᵢ', 'j': 'ⱼ', 'k': 'ₖ', 'l': 'ₗ', 'm': 'ₘ', 'n': 'ₙ',
+	'o': 'ₒ', 'p': 'ₚ', 'r': 'ᵣ', 's': 'ₛ', 't': 'ₜ', 'u': 'ᵤ', 'v': 'ᵥ', 'x': 'ₓ',
+	'β': 'ᵦ', 'γ': 'ᵧ', 'ρ': 'ᵨ', 'φ': 'ᵩ', 'χ': 'ᵪ',
+}
+
+// superscript converts s to Unicode superscript characters. ok is false when
+// some character has no superscript form.
+func superscript(s string) (string, bool) { return mapScript(s, superscripts) }
+
+func subscript(s string) (string, bool) { return mapScript(s, subscripts) }
+
+func mapScript(s string, table map[rune]rune) (string, bool) {
+	var b strings.Builder
+	for _, r := range s {
+		if r == ' ' {
+			continue
+		}
+		v, ok := table[r]
+		if !ok {
+			return "", false
+		}
+		b.WriteRune(v)
+	}
+	return b.String(), true
+}
+
+var vulgarFractions = map[string]string{
+	"1/2": "½", "1/3": "⅓", "2/3": "⅔", "1/4": "¼", "3/4": "¾", "1/5": "⅕", "1/6": "⅙", "1/8": "⅛",
+}
+
+// linearMath renders a node on a single line. Top-level binary operators and
+// relations get spaces around them; scripts stay tight.
+func linearMath(n *mathNode, spaced bool) string {
+	if n == nil {
+		return ""
+	}
+	switch n.kind {
+	case mathAtom:
+		return n.text
+	case mathRow:
+		var b strings.Builder
+		prevOp := true // a leading "-" is unary
+		for _, it := range n.items {
+			s := linearMath(it, spaced)
+			isOp := it.kind == mathAtom && (it.class == classBin || it.class == classRel)
+			switch {
+			case spaced && isOp && (it.class == classRel || !prevOp):
+				b.WriteString(" " + s + " ")
+			case spaced && it.kind == mathAtom && it.class == classPunct:
+				b.WriteString(s + " ")
+			default:
+				b.WriteString(s)
+			}
+			prevOp = isOp || (it.kind == mathAtom && it.class == classOpen)
+		}
+		return strings.TrimSpace(collapseSpaces(b.String()))
+	case mathFrac:
+		a, b := linearMath(n.a, false), linearMath(n.b, false)
+		if n.noBar {
+			return "C(" + a + ", " + b + ")"
+		}
+		if v, ok := vulgarFractions[a+"/"+b]; ok {
+			return v
+		}
+		return wrapMath(a) + "/" + wrapMath(b)
+	case mathSqrt:
+		arg := wrapMath(linearMath(n.a, false))
+		switch idx := linearMath(n.b, false); idx {
+		case "":
+			return "√" + arg
+		case "3":
+			return "∛" + arg
+		case "4":
+			return "∜" + arg
+		default:
+			if sup, ok := superscript(idx); ok {
+				return sup + "√" + arg
+			}
+			return "root(" + idx + ")" + arg
+		}
+	case mathScripts, mathBigOp:
+		base := n.text
+		if n.kind == mathScripts {
+			base = linearMath(n.a, spaced)
+		}
+		out := base
+		if n.sub != nil {
+			out += linearScript(linearMath(n.sub, false), subscript, "_")
+		}
+		if n.sup != nil {
+			out += linearScript(linearMath(n.sup, false), superscript, "^")
+		}
+		if n.kind == mathBigOp || isIntegral(base) {
+			out += " "
+		}
+		return out
+	}
+	return ""
+}
+
+func linearScript(s string, conv func(string) (string, bool), marker string) string {
+	if v, ok := conv(s); ok {
+		return v
+	}
+	if utf8.RuneCountInString(s) > 1 {
+		return marker + "(" + s + ")"
+	}
+	return marker + s
+}
+
+// wrapMath parenthesizes compound expressions so "a/b" stays unambiguous.
+func wrapMath(s string) string {
+	if utf8.RuneCountInString(s) <= 1 || isSimpleMath(s) {
+		return s
+	}
+	return "(" + s + ")"
+}
+
+func isSimpleMath(s string) bool {
+	for _, r := range s {
+		if strings.ContainsRune(" +-=/<>×·±∓÷,", r) {
+			return false
+		}
+	}
+	return true
+}
+
+func collapseSpaces(s string) string {
+	for strings.Contains(s, "  ") {
+		s = strings.ReplaceAll(s, "  ", " ")
+	}
+	return s
+}
+
+// mathBox is a block of text lines with a baseline, used for display math.
+type mathBox struct {
+	lines []string
+	base  int
+	width int
+}
+
+func textBox(s string) mathBox {
+	return mathBox{lines: []string{s}, width: xansi.StringWidth(s)}
+}
+
+func (b mathBox) pad(width int) mathBox {
+	for i, ln := range b.lines {
+		b.lines[i] = ln + strings.Repeat(" ", max(0, width-xansi.StringWidth(ln)))
+	}
+	b.width = max(b.width, width)
+	return b
+}
+
+func (b mathBox) center(width int) mathBox {
+	left := max(0, (width-b.width)/2)
+	out := mathBox{base: b.base, width: width}
+	for _, ln := range b.lines {
+		out.lines = append(out.lines, strings.Repeat(" ", left)+ln)
+	}
+	return out.pad(width)
+}
+
+// hcat joins boxes side by side, aligning their baselines.
+func hcat(boxes ...mathBox) mathBox {
+	above, below := 0, 0
+	for _, b := range boxes {
+		above = max(above, b.base)
+		below = max(below, len(b.lines)-b.base-1)
+	}
+	out := mathBox{base: above, lines: make([]string, above+below+1)}
+	for _, b := range boxes {
+		b = b.pad(b.width)
+		top := above - b.base
+		for i := range out.lines {
+			j := i - top
+			if j >= 0 && j < len(b.lines) {
+				out.lines[i] += b.lines[j]
+			} else {
+				out.lines[i] += strings.Repeat(" ", b.width)
+			}
+		}
+		out.width += b.width
+	}
+	return out
+}
+
+// vstack stacks boxes vertically, centered, with the baseline on row base.
+func vstack(base int, boxes ...mathBox) mathBox {
+	w := 0
+	for _, b := range boxes {
+		w = max(w, b.width)
+	}
+	out := mathBox{base: base, width: w}
+	for _, b := range boxes {
+		out.lines = append(out.lines, b.center(w).lines...)
+	}
+	return out
+}
+
+// displayMath lays a node out in 2D.
+func displayMath(n *mathNode, tight bool) mathBox {
+	if n == nil {
+		return textBox("")
+	}
+	switch n.kind {
+	case mathRow:
+		var parts []mathBox
+		prevOp := true
+		for _, it := range n.items {
+			b := displayMath(it, tight)
+			isOp := it.kind == mathAtom && (it.class == classBin || it.class == classRel)
+			if tight {
+				// Scripts and limits stay compact.
+			} else if isOp && (it.class == classRel || !prevOp) {
+				b = hcat(textBox(" "), b, textBox(" "))
+			} else if it.kind == mathAtom && it.class == classPunct {
+				b = hcat(b, textBox(" "))
+			}
+			prevOp = isOp || (it.kind == mathAtom && it.class == classOpen)
+			parts = append(parts, b)
+		}
+		if len(parts) == 0 {
+			return textBox("")
+		}
+		return hcat(parts...)
+	case mathFrac:
+		num, den := displayMath(n.a, tight), displayMath(n.b, tight)
+		w := max(num.width, den.width) + 2
+		if n.noBar {
+			inner := vstack(num.base, num, den)
+			return hcat(parenBox("(", len(inner.lines), inner.base), inner, parenBox(")", len(inner.lines), inner.base))
+		}
+		return vstack(len(num.lines), num, textBox(strings.Repeat("─", w)), den)
+	case mathSqrt:
+		arg := displayMath(n.a, tight)
+		if len(arg.lines) == 1 && n.b == nil {
+			return mathBox{lines: []string{" " + strings.Repeat("_", arg.width), "√" + arg.lines[0]}, base: 1, width: arg.width + 1}
+		}
+		return textBox(linearMath(n, !tight))
+	case mathBigOp:
+		if n.sub == nil && n.sup == nil {
+			return textBox(n.text)
+		}
+		op := textBox(n.text)
+		var rows []mathBox
+		base := 0
+		if n.sup != nil {
+			sup := displayMath(n.sup, true)
+			rows = append(rows, sup)
+			base = len(sup.lines)
+		}
+		rows = append(rows, op)
+		if n.sub != nil {
+			rows = append(rows, displayMath(n.sub, true))
+		}
+		return hcat(vstack(base, rows...), textBox(" "))
+	case mathScripts:
+		base := displayMath(n.a, tight)
+		// Prefer compact Unicode scripts when the script has a Unicode form.
+		sub, subOK := "", true
+		sup, supOK := "", true
+		if n.sub != nil {
+			sub, subOK = subscript(linearMath(n.sub, false))
+		}
+		if n.sup != nil {
+			sup, supOK = superscript(linearMath(n.sup, false))
+		}
+		if subOK && supOK {
+			return hcat(base, textBox(sub+sup))
+		}
+		var (
+			rows []mathBox
+			off  = len(base.lines)
+		)
+		side := mathBox{}
+		if n.sup != nil {
+			s := displayMath(n.sup, true)
+			rows = append(rows, s)
+			off = len(s.lines) + base.base
+		}
+		rows = append(rows, textBox(strings.Repeat(" ", 1)))
+		if n.sub != nil {
+			rows = append(rows, displayMath(n.sub, true))
+		}
+		w := 0
+		for _, r := range rows {
+			w = max(w, r.width)
+		}
+		for _, r := range rows {
+			side.lines = append(side.lines, r.pad(w).lines...)
+		}
+		side.width = w
+		side.base = off - base.base
+		return hcat(base, side)
+	}
+	return textBox(linearMath(n, !tight))
+}
+
+func parenBox(p string, height, base int) mathBox {
+	if height <= 1 {
+		return textBox(p)
+	}
+	top, mid, bot := "⎛", "⎜", "⎝"
+	if p == ")" {
+		top, mid, bot = "⎞", "⎟", "⎠"
+	}
+	b := mathBox{base: base, width: 1}
+	for i := 0; i < height; i++ {
+		switch i {
+		case 0:
+			b.lines = append(b.lines, top)
+		case height - 1:
+			b.lines = append(b.lines, bot)
+		default:
+			b.lines = append(b.lines, mid)
+		}
+	}
+	return b
+}
+
+// renderDisplayMath lays out a "$$" block, one box per "\\" line. Lines that do
+// not fit in width fall back to the single-line form.
+func renderDisplayMath(src string, width int) []string {
+	var out []string
+	for _, part := range strings.Split(src, `\\`) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		n := parseMath(part)
+		box := displayMath(n, false)
+		if box.width > width {
+			box = textBox(linearMath(n, true))
+		}
+		if len(out) > 0 {
+			out = append(out, "")
+		}
+		for _, ln := range box.lines {
+			out = append(out, strings.TrimRight(ln, " "))
+		}
+	}
+	return out
+}
+
+// mathFenceHook renders ```math blocks.
+func mathFenceHook(body string, width int) ([]string, bool) {
+	lines := renderDisplayMath(body, width)
+	return lines, len(lines) > 0
+}
+
+// preprocessMath replaces "$$" display blocks with rendered placeholders and
+// inline "$...$" spans with Unicode text, skipping fenced and indented code.
+func preprocessMath(md string, width int, sp *splicer) string {
+	lines := splitSourceLines(md)
+	var (
+		out   []string
+		fence mdblock.FenceTracker
+	)
+	code := mdblock.Lines(md, gast.KindCodeBlock)
+	for i := 0; i < len(lines); i++ {
+		ln := lines[i]
+		if fence.Update(ln) || code[i] {
+			out = append(out, ln)
+			continue
+		}
+		t := strings.TrimSpace(ln)
+		if strings.HasPrefix(t, "$$") {
+			body := strings.TrimPrefix(t, "$$")
+			end := -1
+			if strings.HasSuffix(body, "$$") {
+				body, end = strings.TrimSuffix(body, "$$"), i
+			} else {
+				parts := []string{body}
+				for j := i + 1; j < len(lines); j++ {
+					tj := strings.TrimSpace(lines[j])
+					if code[j] || mdblock.IsFence(tj) {
+						break // unclosed: the block would swallow code
+					}
+					if strings.HasSuffix(tj, "$$") {
+						parts = append(parts, strings.TrimSuffix(tj, "$$"))
+						end = j
+						break
+					}
+					parts = append(parts, tj)
+				}
+				body = strings.Join(parts, "\n")
+			}
+			if end >= 0 && strings.TrimSpace(body) != "" {
+				out = append(out, sp.placeholder(renderDisplayMath(body, width)))
+				i = end
+				continue
+			}
+		}
+		out = append(out, replaceInlineMath(ln))
+	}
+	return strings.Join(out, "\n")
+}
+
+// replaceInlineMath converts "$...$" spans in one line. Following pandoc, the
+// opening "$" must be followed by a non-space and the closing "$" must follow a
+// non-space and not precede a digit, so prices like "$5 and $10" are left alone.
+func replaceInlineMath(line string) string {
+	if !strings.Contains(line, "$") {
+		return line
+	}
+	var b strings.Builder
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '\\' && i+1 < len(line):
+			b.WriteString(line[i : i+2])
+			i++
+			continue
+		case c == '`':
+			// Copy code spans verbatim.
+			n := countPrefix(line[i:], '`')
+			run := line[i : i+n]
+			end := strings.Index(line[i+n:], run)
+			if end < 0 {
+				b.WriteString(run)
+				i += n - 1
+				continue
+			}
+			b.WriteString(line[i : i+n+end+n])
+			i += n + end + n - 1
+			continue
+		case c != '$':
+			b.WriteByte(c)
+			continue
+		}
+
+		delim := "$"
+		if strings.HasPrefix(line[i:], "$$") {
+			delim = "$$"
+		}
+		start := i + len(delim)
+		if start >= len(line) || line[start] == ' ' {
+			b.WriteString(delim)
+			i = start - 1
+			continue
+		}
+		end := -1
+		for j := start; j < len(line); j++ {
+			if line[j] == '\\' {
+				j++
+				continue
+			}
+			if line[j] == '`' {
+				break // a code span starts before the math closes
+			}
+			if !strings.HasPrefix(line[j:], delim) {
+				continue
+			}
+			after := j + len(delim)
+			if line[j-1] != ' ' && (after >= len(line) || line[after] < '0' || line[after] > '9') {
+				end = j
+			}
+			break
+		}
+		if end < 0 {
+			b.WriteString(delim)
+			i = start - 1
+			continue
+		}
+		b.WriteString(escapeMarkdown(linearMath(parseMath(line[start:end]), true)))
+		i = end + len(delim) - 1
+	}
+	return b.String()
+}
+
+func escapeMarkdown(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune("\\`*_[]<>#|~", r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
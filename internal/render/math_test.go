@@ -0,0 +1,76 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	xansi "github.com/charmbracelet/x/ansi"
+)
+
+func TestLinearMath_Golden(t *testing.T) {
+	cases := []struct{ in, want string }{
+		{`x^2 + y^2 = z^2`, "x² + y² = z²"},
+		{`a_i^2`, "aᵢ²"},
+		{`\alpha \leq \beta`, "α ≤ β"},
+		{`\frac{1}{2}`, "½"},
+		{`\frac{a+b}{c}`, "(a+b)/c"},
+		{`\sqrt{x+1}`, "√(x+1)"},
+		{`\sum_{i=1}^{n} i`, "∑ᵢ₌₁ⁿ i"},
+		{`\int_0^\infty e^{-x} dx`, "∫₀^∞ e⁻ˣdx"},
+		{`e^{i\pi} + 1 = 0`, "e^(iπ) + 1 = 0"},
+		{`\mathbb{R}^n`, "ℝⁿ"},
+		{`\sin x \cdot \cos x`, "sin x · cos x"},
+		{`-x \to \infty`, "-x → ∞"},
+	}
+	for _, c := range cases {
+		if got := linearMath(parseMath(c.in), true); got != c.want {
+			t.Errorf("%s: got %q, want %q", c.in, got, c.want)
+		}
+	}
+}
+
+func TestRenderDisplayMath_StackedFraction(t *testing.T) {
+	got := renderDisplayMath(`\sum_{i=1}^{n} i = \frac{n(n+1)}{2}`, 80)
+	want := []string{
+		" n       n(n + 1)",
+		" ∑  i = ──────────",
+		"i=1         2",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestReplaceInlineMath_SkipsPricesAndCode(t *testing.T) {
+	got := replaceInlineMath("costs $5 and $10, `$x$` but $x^2$")
+	want := "costs $5 and $10, `$x$` but x²"
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestRenderMarkdown_Math(t *testing.T) {
+	md := "Euler: $e^{i\\pi} + 1 = 0$\n\n$$\n\\frac{a}{b}\n$$\n"
+	out, err := RenderMarkdown(md, Options{Style: "dark", Width: 60})
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain := xansi.Strip(out)
+	for _, want := range []string{"e^(iπ) + 1 = 0", "───"} {
+		if !strings.Contains(plain, want) {
+			t.Fatalf("expected %q in output:\n%s", want, plain)
+		}
+	}
+	if strings.Contains(plain, `\frac`) || strings.Contains(plain, "$$") {
+		t.Fatalf("math source leaked into output:\n%s", plain)
+	}
+}
+
+func TestPreprocessMath_SkipsIndentedCode(t *testing.T) {
+	md := "Para $x^2$.\n\n    echo $HOME/$USER and $x^2$\n\n- item\n\n      echo $HOME/$USER and $x^2$\n\n$$\n```\n$x$\n```\n"
+	got := preprocessMath(md, 60, &splicer{})
+	want := "Para x².\n\n    echo $HOME/$USER and $x^2$\n\n- item\n\n      echo $HOME/$USER and $x^2$\n\n$$\n```\n$x$\n```\n"
+	if got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,260 @@
+package render
+
+import (
+	"strings"
+
+	xansi "github.com/charmbracelet/x/ansi"
+)
+
+// renderMermaid draws flowcharts and sequence diagrams with box-drawing
+// characters. Other diagram types, parse failures and diagrams wider than
+// width fall back to showing the source.
+func renderMermaid(src string, width int) ([]string, bool) {
+	lines := mermaidStatements(src)
+	if len(lines) == 0 {
+		return nil, false
+	}
+
+	header := strings.Fields(lines[0])
+	var (
+		out []string
+		ok  bool
+	)
+	switch strings.ToLower(header[0]) {
+	case "graph", "flowchart":
+		dir := "TD"
+		if len(header) > 1 {
+			dir = strings.ToUpper(header[1])
+		}
+		out, ok = renderFlowchart(dir, lines[1:])
+	case "sequencediagram":
+		out, ok = renderSequence(lines[1:])
+	}
+	if !ok || len(out) == 0 {
+		return nil, false
+	}
+	for _, ln := range out {
+		if xansi.StringWidth(ln) > width {
+			return nil, false
+		}
+	}
+	return out, true
+}
+
+// mermaidStatements splits a diagram into trimmed statements, dropping blank
+// lines, comments and front matter style directives.
+func mermaidStatements(src string) []string {
+	var out []string
+	for _, ln := range splitSourceLines(src) {
+		if strings.HasPrefix(strings.TrimSpace(ln), "%%") {
+			continue // a comment runs to the end of the line
+		}
+		for _, st := range splitStatements(ln) {
+			st = strings.TrimSpace(st)
+			if st == "" || strings.HasPrefix(st, "%%") {
+				continue
+			}
+			out = append(out, st)
+		}
+	}
+	return out
+}
+
+// splitStatements splits a line on ";" outside quoted strings and
+// bracketed node labels, so A["x; y"] stays one statement.
+func splitStatements(ln string) []string {
+	var (
+		out    []string
+		depth  int
+		quoted bool
+		start  int
+	)
+	for i, r := range ln {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case quoted:
+		case r == '[' || r == '(' || r == '{':
+			depth++
+		case r == ']' || r == ')' || r == '}':
+			depth = max(0, depth-1)
+		case r == ';' && depth == 0:
+			out = append(out, ln[start:i])
+			start = i + 1
+		}
+	}
+	return append(out, ln[start:])
+}
+
+// Line directions stored per canvas cell; joined into box-drawing runes.
+const (
+	lineUp uint8 = 1 << iota
+	lineDown
+	lineLeft
+	lineRight
+)
+
+var lineRunes = map[uint8]rune{
+	lineUp:                                   '│',
+	lineDown:                                 '│',
+	lineUp | lineDown:                        '│',
+	lineLeft:                                 '─',
+	lineRight:                                '─',
+	lineLeft | lineRight:                     '─',
+	lineDown | lineRight:                     '┌',
+	lineDown | lineLeft:                      '┐',
+	lineUp | lineRight:                       '└',
+	lineUp | lineLeft:                        '┘',
+	lineUp | lineDown | lineRight:            '├',
+	lineUp | lineDown | lineLeft:             '┤',
+	lineDown | lineLeft | lineRight:          '┬',
+	lineUp | lineLeft | lineRight:            '┴',
+	lineUp | lineDown | lineLeft | lineRight: '┼',
+}
+
+// diagramCanvas is a grid of cells holding either text runes or line
+// segments. Segments drawn across each other merge into junctions.
+type diagramCanvas struct {
+	w, h  int
+	runes [][]rune // 0 = empty, -1 = second column of a wide rune
+	bits  [][]uint8
+}
+
+func newDiagramCanvas(w, h int) *diagramCanvas {
+	c := &diagramCanvas{w: w, h: h}
+	c.runes = make([][]rune, h)
+	c.bits = make([][]uint8, h)
+	for y := 0; y < h; y++ {
+		c.runes[y] = make([]rune, w)
+		c.bits[y] = make([]uint8, w)
+	}
+	return c
+}
+
+func (c *diagramCanvas) in(x, y int) bool { return x >= 0 && y >= 0 && x < c.w && y < c.h }
+
+func (c *diagramCanvas) free(x, y int) bool {
+	return c.in(x, y) && c.runes[y][x] == 0 && c.bits[y][x] == 0
+}
+
+func (c *diagramCanvas) set(x, y int, r rune) {
+	if c.in(x, y) {
+		c.runes[y][x] = r
+	}
+}
+
+func (c *diagramCanvas) addBits(x, y int, b uint8) {
+	if c.in(x, y) {
+		c.bits[y][x] |= b
+	}
+}
+
+func (c *diagramCanvas) hline(y, x0, x1 int) {
+	if x0 > x1 {
+		x0, x1 = x1, x0
+	}
+	for x := x0; x <= x1; x++ {
+		if x > x0 {
+			c.addBits(x, y, lineLeft)
+		}
+		if x < x1 {
+			c.addBits(x, y, lineRight)
+		}
+	}
+}
+
+func (c *diagramCanvas) vline(x, y0, y1 int) {
+	if y0 > y1 {
+		y0, y1 = y1, y0
+	}
+	for y := y0; y <= y1; y++ {
+		if y > y0 {
+			c.addBits(x, y, lineUp)
+		}
+		if y < y1 {
+			c.addBits(x, y, lineDown)
+		}
+	}
+}
+
+// text writes s starting at (x, y) and returns the columns used.
+func (c *diagramCanvas) text(x, y int, s string) int {
+	col := x
+	for _, r := range s {
+		w := xansi.StringWidth(string(r))
+		if w <= 0 {
+			continue
+		}
+		c.set(col, y, r)
+		if w == 2 {
+			c.set(col+1, y, -1)
+		}
+		col += w
+	}
+	return col - x
+}
+
+func (c *diagramCanvas) textFree(x, y int, s string) bool {
+	for i := 0; i < xansi.StringWidth(s); i++ {
+		if !c.free(x+i, y) {
+			return false
+		}
+	}
+	return true
+}
+
+// box draws a rectangle with label lines centered inside.
+func (c *diagramCanvas) box(x, y, w, h int, label []string) {
+	c.hline(y, x, x+w-1)
+	c.hline(y+h-1, x, x+w-1)
+	c.vline(x, y, y+h-1)
+	c.vline(x+w-1, y, y+h-1)
+	top := y + 1 + (h-2-len(label))/2
+	for i, ln := range label {
+		lw := xansi.StringWidth(ln)
+		c.text(x+(w-lw)/2, top+i, ln)
+	}
+}
+
+func (c *diagramCanvas) lines() []string {
+	out := make([]string, 0, c.h)
+	for y := 0; y < c.h; y++ {
+		var b strings.Builder
+		for x := 0; x < c.w; x++ {
+			switch r := c.runes[y][x]; {
+			case r == -1:
+			case r != 0:
+				b.WriteRune(r)
+			case c.bits[y][x] != 0:
+				b.WriteRune(lineRunes[c.bits[y][x]])
+			default:
+				b.WriteByte(' ')
+			}
+		}
+		out = append(out, strings.TrimRight(b.String(), " "))
+	}
+	return out
+}
+
+// mermaidLabel normalizes node and message text: quotes removed, <br> split
+// into lines, common entities decoded.
+func mermaidLabel(s string) []string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		s = s[1 : len(s)-1]
+	}
+	s = strings.NewReplacer("<br/>", "\n", "<br />", "\n", "<br>", "\n", "&quot;", "\"", "&amp;", "&", "&lt;", "<", "&gt;", ">", "#quot;", "\"").Replace(s)
+	var out []string
+	for _, ln := range strings.Split(s, "\n") {
+		out = append(out, strings.TrimSpace(ln))
+	}
+	return out
+}
+
+func maxLineWidth(lines []string) int {
+	w := 0
+	for _, ln := range lines {
+		w = max(w, xansi.StringWidth(ln))
+	}
+	return w
+}
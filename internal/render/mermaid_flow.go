@@ -0,0 +1,566 @@
+package render
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+type flowNode struct {
+	id    string
+	label []string
+	dummy bool // routing point for an edge spanning several ranks
+
+	rank  int
+	pos   int // start on the order axis (x for TD, y for LR)
+	osize int // size along the order axis
+	rsize int // size along the rank axis
+}
+
+type flowEdge struct {
+	from, to int
+	label    string
+	arrow    bool
+}
+
+type flowGraph struct {
+	nodes []*flowNode
+	ids   map[string]int
+	edges []flowEdge
+}
+
+var (
+	// "-- text -->", "== text ==>", "-. text .->"
+	flowLabeledEdgeRe = regexp.MustCompile(`^\s*(?:--|==|-\.)\s*([^-=>.|\s][^>]*?)\s*(?:-{2,}|={2,}|\.-+)([>xo]?)`)
+	// "-->", "---", "==>", "-.->", "--x", optionally followed by "|text|"
+	flowEdgeRe = regexp.MustCompile(`^\s*<?(-{2,}|={2,}|-\.+-|~~~)([>xo]?)(?:\s*\|([^|]*)\|)?`)
+
+	flowShapes = [][2]string{
+		{"(((", ")))"}, {"((", "))"}, {"([", "])"}, {"[[", "]]"}, {"[(", ")]"},
+		{"{{", "}}"}, {"[/", "/]"}, {"[\\", "\\]"}, {"[", "]"}, {"(", ")"}, {"{", "}"}, {">", "]"},
+	}
+)
+
+func parseFlowchart(stmts []string) (*flowGraph, bool) {
+	g := &flowGraph{ids: map[string]int{}}
+	for _, st := range stmts {
+		kw := strings.ToLower(strings.Fields(st)[0])
+		switch kw {
+		case "subgraph", "end", "classdef", "class", "style", "linkstyle", "click", "direction":
+			// Grouping and styling do not affect our layout.
+			continue
+		}
+		if !g.parseStatement(st) {
+			return nil, false
+		}
+	}
+	return g, true
+}
+
+// parseStatement handles "A", "A[Label]" and edge chains such as
+// "A & B --> C -- text --> D".
+func (g *flowGraph) parseStatement(st string) bool {
+	pos := 0
+	prev, ok := g.parseNodeGroup(st, &pos)
+	if !ok {
+		return false
+	}
+	for {
+		for pos < len(st) && st[pos] == ' ' {
+			pos++
+		}
+		if pos >= len(st) {
+			return true
+		}
+
+		var (
+			label string
+			head  string
+			kind  string
+		)
+		rest := st[pos:]
+		if m := flowLabeledEdgeRe.FindStringSubmatchIndex(rest); m != nil {
+			label, head = rest[m[2]:m[3]], rest[m[4]:m[5]]
+			pos += m[1]
+		} else if m := flowEdgeRe.FindStringSubmatchIndex(rest); m != nil {
+			kind, head = rest[m[2]:m[3]], rest[m[4]:m[5]]
+			if m[6] >= 0 {
+				label = rest[m[6]:m[7]]
+			}
+			pos += m[1]
+		} else {
+			return false
+		}
+
+		next, ok := g.parseNodeGroup(st, &pos)
+		if !ok {
+			return false
+		}
+		if kind == "~~~" {
+			// Invisible link: only influences layout in mermaid; skip it.
+			prev = next
+			continue
+		}
+		for _, a := range prev {
+			for _, b := range next {
+				g.edges = append(g.edges, flowEdge{
+					from:  a,
+					to:    b,
+					label: strings.Join(mermaidLabel(label), " "),
+					arrow: head != "",
+				})
+			}
+		}
+		prev = next
+	}
+}
+
+func (g *flowGraph) parseNodeGroup(st string, pos *int) ([]int, bool) {
+	var out []int
+	for {
+		for *pos < len(st) && st[*pos] == ' ' {
+			*pos++
+		}
+		n, ok := g.parseNode(st, pos)
+		if !ok {
+			return nil, false
+		}
+		out = append(out, n)
+
+		p := *pos
+		for p < len(st) && st[p] == ' ' {
+			p++
+		}
+		if p >= len(st) || st[p] != '&' {
+			return out, true
+		}
+		*pos = p + 1
+	}
+}
+
+func (g *flowGraph) parseNode(st string, pos *int) (int, bool) {
+	start := *pos
+	for *pos < len(st) {
+		r := rune(st[*pos])
+		if r >= 0x80 || unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			*pos++
+			continue
+		}
+		break
+	}
+	id := st[start:*pos]
+	if id == "" {
+		return 0, false
+	}
+
+	var label []string
+	rest := st[*pos:]
+	for _, sh := range flowShapes {
+		if !strings.HasPrefix(rest, sh[0]) {
+			continue
+		}
+		body := rest[len(sh[0]):]
+		skip := 0
+		if strings.HasPrefix(body, "\"") {
+			// Quoted text may contain the closing bracket.
+			if q := strings.Index(body[1:], "\""); q >= 0 {
+				skip = q + 2
+			}
+		}
+		end := strings.Index(body[skip:], sh[1])
+		if end < 0 {
+			return 0, false
+		}
+		end += skip
+		label = mermaidLabel(body[:end])
+		*pos += len(sh[0]) + end + len(sh[1])
+		break
+	}
+
+	// ":::className" suffix.
+	if strings.HasPrefix(st[*pos:], ":::") {
+		*pos += 3
+		for *pos < len(st) && st[*pos] != ' ' {
+			*pos++
+		}
+	}
+
+	idx, ok := g.ids[id]
+	if !ok {
+		idx = len(g.nodes)
+		g.ids[id] = idx
+		g.nodes = append(g.nodes, &flowNode{id: id, label: []string{id}})
+	}
+	if label != nil {
+		g.nodes[idx].label = label
+	}
+	return idx, true
+}
+
+// flowSeg is one drawn piece of an edge between adjacent ranks.
+type flowSeg struct {
+	from, to int
+	label    string
+	arrow    bool
+}
+
+// layout places nodes in ranks (longest path from the sources), orders each
+// rank with a few barycenter sweeps and routes every edge through a private
+// track in the channel between two ranks. lr switches from top-down to
+// left-to-right by swapping the axes.
+func (g *flowGraph) layout(lr bool) []string {
+	n := len(g.nodes)
+
+	// Break cycles: edges back to a node on the DFS stack are listed below the
+	// diagram instead of being drawn.
+	adj := make([][]int, n)
+	for i, e := range g.edges {
+		adj[e.from] = append(adj[e.from], i)
+	}
+	state := make([]int, n)
+	back := make([]bool, len(g.edges))
+	var dfs func(v int)
+	dfs = func(v int) {
+		state[v] = 1
+		for _, ei := range adj[v] {
+			switch w := g.edges[ei].to; state[w] {
+			case 1:
+				back[ei] = true
+			case 0:
+				dfs(w)
+			}
+		}
+		state[v] = 2
+	}
+	for v := 0; v < n; v++ {
+		if state[v] == 0 {
+			dfs(v)
+		}
+	}
+
+	// Longest-path ranking over the remaining DAG.
+	indeg := make([]int, n)
+	for i, e := range g.edges {
+		if !back[i] {
+			indeg[e.to]++
+		}
+	}
+	var queue []int
+	for v := 0; v < n; v++ {
+		if indeg[v] == 0 {
+			queue = append(queue, v)
+		}
+	}
+	for len(queue) > 0 {
+		v := queue[0]
+		queue = queue[1:]
+		for _, ei := range adj[v] {
+			if back[ei] {
+				continue
+			}
+			w := g.edges[ei].to
+			g.nodes[w].rank = max(g.nodes[w].rank, g.nodes[v].rank+1)
+			if indeg[w]--; indeg[w] == 0 {
+				queue = append(queue, w)
+			}
+		}
+	}
+
+	// Split long edges into segments via dummy nodes, one per skipped rank.
+	var segs []flowSeg
+	for i, e := range g.edges {
+		if back[i] {
+			continue
+		}
+		prev := e.from
+		for r := g.nodes[e.from].rank + 1; r < g.nodes[e.to].rank; r++ {
+			g.nodes = append(g.nodes, &flowNode{dummy: true, rank: r})
+			d := len(g.nodes) - 1
+			segs = append(segs, flowSeg{from: prev, to: d})
+			prev = d
+		}
+		segs = append(segs, flowSeg{from: prev, to: e.to, label: e.label, arrow: e.arrow})
+	}
+
+	ranks := 0
+	for _, nd := range g.nodes {
+		ranks = max(ranks, nd.rank+1)
+	}
+	layers := make([][]int, ranks)
+	for v, nd := range g.nodes {
+		layers[nd.rank] = append(layers[nd.rank], v)
+	}
+	g.orderLayers(layers, segs)
+
+	// Node sizes. Boxes are top/left aligned within their rank band.
+	gap := 2
+	if lr {
+		gap = 1
+	}
+	band := make([]int, ranks)
+	for _, nd := range g.nodes {
+		if nd.dummy {
+			nd.osize, nd.rsize = 1, 1
+		} else if lr {
+			nd.osize, nd.rsize = len(nd.label)+2, maxLineWidth(nd.label)+4
+		} else {
+			nd.osize, nd.rsize = maxLineWidth(nd.label)+4, len(nd.label)+2
+		}
+		band[nd.rank] = max(band[nd.rank], nd.rsize)
+	}
+	extent := 0
+	for _, layer := range layers {
+		p := 0
+		for i, v := range layer {
+			if i > 0 {
+				p += gap
+			}
+			g.nodes[v].pos = p
+			p += g.nodes[v].osize
+		}
+		extent = max(extent, p)
+	}
+	for _, layer := range layers {
+		if len(layer) == 0 {
+			continue
+		}
+		last := g.nodes[layer[len(layer)-1]]
+		shift := (extent - (last.pos + last.osize)) / 2
+		for _, v := range layer {
+			g.nodes[v].pos += shift
+		}
+	}
+	center := func(v int) int { return g.nodes[v].pos + g.nodes[v].osize/2 }
+
+	// Channels between ranks: one track per source node, then an optional
+	// label lane, then the arrow heads.
+	type channel struct {
+		tracks map[int]int
+		lane   int // label lane size along the rank axis
+		size   int
+	}
+	chans := make([]channel, ranks)
+	for r := 0; r < ranks-1; r++ {
+		ch := channel{tracks: map[int]int{}}
+		labelW := 0
+		for _, v := range layers[r] {
+			for _, s := range segs {
+				if s.from == v {
+					if _, ok := ch.tracks[v]; !ok {
+						ch.tracks[v] = len(ch.tracks)
+					}
+					labelW = max(labelW, maxLineWidth([]string{s.label}))
+				}
+			}
+		}
+		if labelW > 0 {
+			ch.lane = 1
+			if lr {
+				ch.lane = labelW + 2
+			}
+		}
+		ch.size = max(1, len(ch.tracks)) + ch.lane + 1
+		chans[r] = ch
+	}
+	bandStart := make([]int, ranks)
+	total := 0
+	for r := 0; r < ranks; r++ {
+		bandStart[r] = total
+		total += band[r] + chans[r].size
+	}
+
+	var c *diagramCanvas
+	if lr {
+		c = newDiagramCanvas(total, extent)
+	} else {
+		c = newDiagramCanvas(extent, total)
+	}
+	// Abstract drawing on (rank, order) coordinates.
+	rline := func(o, r0, r1 int) {
+		if lr {
+			c.hline(o, r0, r1)
+		} else {
+			c.vline(o, r0, r1)
+		}
+	}
+	oline := func(r, o0, o1 int) {
+		if lr {
+			c.vline(r, o0, o1)
+		} else {
+			c.hline(r, o0, o1)
+		}
+	}
+	point := func(r, o int) (int, int) {
+		if lr {
+			return r, o
+		}
+		return o, r
+	}
+
+	for _, nd := range g.nodes {
+		r0 := bandStart[nd.rank]
+		switch {
+		case nd.dummy:
+			rline(nd.pos, r0, r0+band[nd.rank]-1)
+		case lr:
+			c.box(r0, nd.pos, nd.rsize, nd.osize, nd.label)
+		default:
+			c.box(nd.pos, r0, nd.osize, nd.rsize, nd.label)
+		}
+	}
+
+	type pendingLabel struct {
+		x, y int
+		text string
+		seg  flowSeg
+	}
+	var labels []pendingLabel
+	arrowHead := '▼'
+	if lr {
+		arrowHead = '▶'
+	}
+
+	for r := 0; r < ranks-1; r++ {
+		ch := chans[r]
+		chanStart := bandStart[r] + band[r]
+		arrowR := bandStart[r+1] - 1
+		for _, s := range segs {
+			from := g.nodes[s.from]
+			if from.rank != r {
+				continue
+			}
+			trackR := chanStart + ch.tracks[s.from]
+			boxEnd := bandStart[r] + from.rsize - 1
+			if from.dummy {
+				boxEnd = bandStart[r] + band[r] - 1
+			}
+			sc, tc := center(s.from), center(s.to)
+			rline(sc, boxEnd, trackR)
+			oline(trackR, sc, tc)
+			if s.arrow {
+				rline(tc, trackR, arrowR)
+				x, y := point(arrowR, tc)
+				c.set(x, y, arrowHead)
+			} else {
+				rline(tc, trackR, bandStart[r+1])
+			}
+			if s.label != "" {
+				var x, y int
+				if lr {
+					x, y = chanStart+len(ch.tracks)+1, tc-1
+				} else {
+					x, y = tc+2, chanStart+len(ch.tracks)
+				}
+				labels = append(labels, pendingLabel{x: x, y: y, text: s.label, seg: s})
+			}
+		}
+	}
+
+	// Labels go in last so they never hide a line; those that would collide
+	// are listed below the diagram instead.
+	var legend []string
+	for _, l := range labels {
+		if c.textFree(l.x, l.y, l.text) {
+			c.text(l.x, l.y, l.text)
+			continue
+		}
+		legend = append(legend, g.edgeName(l.seg.from, l.seg.to)+": "+l.text)
+	}
+	for i, e := range g.edges {
+		if !back[i] {
+			continue
+		}
+		ln := "↺ " + g.edgeName(e.from, e.to)
+		if e.label != "" {
+			ln += ": " + e.label
+		}
+		legend = append(legend, ln)
+	}
+
+	out := c.lines()
+	if len(legend) > 0 {
+		out = append(out, "")
+		out = append(out, legend...)
+	}
+	return out
+}
+
+// edgeName describes an edge by its real endpoints, skipping dummy nodes.
+func (g *flowGraph) edgeName(from, to int) string {
+	name := func(v int) string { return strings.Join(g.nodes[v].label, " ") }
+	if g.nodes[from].dummy {
+		for _, e := range g.edges {
+			if e.to == to {
+				from = e.from
+				break
+			}
+		}
+	}
+	return name(from) + " → " + name(to)
+}
+
+// orderLayers reduces crossings by sorting each rank by the mean position of
+// its neighbours in the adjacent rank, sweeping down and up a few times.
+func (g *flowGraph) orderLayers(layers [][]int, segs []flowSeg) {
+	idx := make([]int, len(g.nodes))
+	reindex := func(layer []int) {
+		for i, v := range layer {
+			idx[v] = i
+		}
+	}
+	for _, layer := range layers {
+		reindex(layer)
+	}
+
+	sweep := func(r int, down bool) {
+		key := map[int]float64{}
+		for _, v := range layers[r] {
+			sum, cnt := 0, 0
+			for _, s := range segs {
+				if down && s.to == v {
+					sum += idx[s.from]
+					cnt++
+				} else if !down && s.from == v {
+					sum += idx[s.to]
+					cnt++
+				}
+			}
+			if cnt == 0 {
+				key[v] = float64(idx[v])
+				continue
+			}
+			key[v] = float64(sum) / float64(cnt)
+		}
+		sort.SliceStable(layers[r], func(i, j int) bool {
+			return key[layers[r][i]] < key[layers[r][j]]
+		})
+		reindex(layers[r])
+	}
+
+	for iter := 0; iter < 4; iter++ {
+		for r := 1; r < len(layers); r++ {
+			sweep(r, true)
+		}
+		for r := len(layers) - 2; r >= 0; r-- {
+			sweep(r, false)
+		}
+	}
+}
+
+func renderFlowchart(dir string, stmts []string) ([]string, bool) {
+	var lr bool
+	switch dir {
+	case "TD", "TB":
+	case "LR":
+		lr = true
+	default:
+		// BT/RL would need mirrored arrows; show the source instead.
+		return nil, false
+	}
+	g, ok := parseFlowchart(stmts)
+	if !ok || len(g.nodes) == 0 {
+		return nil, false
+	}
+	return g.layout(lr), true
+}
@@ -0,0 +1,282 @@
+package render
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	xansi "github.com/charmbracelet/x/ansi"
+)
+
+type seqParticipant struct {
+	id     string
+	label  string
+	center int
+}
+
+type seqEventKind int
+
+const (
+	seqMessage seqEventKind = iota
+	seqNote
+	seqBlock // loop/alt/opt/... and their "else"/"end" separators
+)
+
+type seqEvent struct {
+	kind     seqEventKind
+	from, to int
+	text     string
+	dashed   bool
+	head     rune
+	notePos  string // "over", "left of", "right of"
+}
+
+type seqDiagram struct {
+	parts []*seqParticipant
+	ids   map[string]int
+	evs   []seqEvent
+}
+
+var (
+	seqParticipantRe = regexp.MustCompile(`^(?i:participant|actor)\s+(.+?)(?:\s+as\s+(.+))?$`)
+	seqMessageRe     = regexp.MustCompile(`^(.+?)\s*(--?)(>>|>|x|\))\s*[+-]?\s*(.+?)\s*:\s*(.*)$`)
+	seqNoteRe        = regexp.MustCompile(`^(?i:note)\s+(?i:(over|left of|right of))\s+([^:]+?)\s*:\s*(.*)$`)
+	seqBlockRe       = regexp.MustCompile(`^(?i:(loop|alt|else|opt|par|and|critical|option|break|rect))\b\s*(.*)$`)
+)
+
+func parseSequence(stmts []string) (*seqDiagram, bool) {
+	d := &seqDiagram{ids: map[string]int{}}
+	autonumber := false
+	n := 0
+
+	for _, st := range stmts {
+		lower := strings.ToLower(st)
+		switch {
+		case lower == "autonumber":
+			autonumber = true
+		case lower == "end":
+			d.evs = append(d.evs, seqEvent{kind: seqBlock})
+		case strings.HasPrefix(lower, "activate "), strings.HasPrefix(lower, "deactivate "),
+			strings.HasPrefix(lower, "title"), strings.HasPrefix(lower, "box"):
+			// Presentation only.
+		default:
+			if m := seqParticipantRe.FindStringSubmatch(st); m != nil {
+				p := d.participant(m[1])
+				if m[2] != "" {
+					p.label = strings.Join(mermaidLabel(m[2]), " ")
+				}
+				continue
+			}
+			if m := seqNoteRe.FindStringSubmatch(st); m != nil {
+				who := strings.Split(m[2], ",")
+				from := d.ids[d.participant(who[0]).id]
+				to := from
+				if len(who) > 1 {
+					to = d.ids[d.participant(who[1]).id]
+				}
+				d.evs = append(d.evs, seqEvent{
+					kind:    seqNote,
+					from:    min(from, to),
+					to:      max(from, to),
+					text:    strings.Join(mermaidLabel(m[3]), " "),
+					notePos: strings.ToLower(m[1]),
+				})
+				continue
+			}
+			if m := seqBlockRe.FindStringSubmatch(st); m != nil {
+				text := strings.ToLower(m[1])
+				if m[2] != "" {
+					text += ": " + strings.Join(mermaidLabel(m[2]), " ")
+				}
+				d.evs = append(d.evs, seqEvent{kind: seqBlock, text: text})
+				continue
+			}
+			m := seqMessageRe.FindStringSubmatch(st)
+			if m == nil {
+				return nil, false
+			}
+			ev := seqEvent{
+				kind:   seqMessage,
+				from:   d.ids[d.participant(m[1]).id],
+				to:     d.ids[d.participant(m[4]).id],
+				text:   strings.Join(mermaidLabel(m[5]), " "),
+				dashed: m[2] == "--",
+				head:   '▶',
+			}
+			switch m[3] {
+			case "x":
+				ev.head = '×'
+			case ")":
+				ev.head = '▷'
+			}
+			if autonumber {
+				n++
+				ev.text = fmt.Sprintf("%d. %s", n, ev.text)
+			}
+			d.evs = append(d.evs, ev)
+		}
+	}
+	return d, len(d.parts) > 0
+}
+
+func (d *seqDiagram) participant(id string) *seqParticipant {
+	id = strings.TrimSpace(id)
+	if i, ok := d.ids[id]; ok {
+		return d.parts[i]
+	}
+	p := &seqParticipant{id: id, label: id}
+	d.ids[id] = len(d.parts)
+	d.parts = append(d.parts, p)
+	return p
+}
+
+func renderSequence(stmts []string) ([]string, bool) {
+	d, ok := parseSequence(stmts)
+	if !ok {
+		return nil, false
+	}
+	return d.layout(), true
+}
+
+func (d *seqDiagram) layout() []string {
+	boxW := func(p *seqParticipant) int { return xansi.StringWidth(p.label) + 4 }
+
+	// Space lifelines so boxes never touch, then widen gaps until every
+	// message label fits between its lifelines.
+	d.parts[0].center = boxW(d.parts[0]) / 2
+	for i := 1; i < len(d.parts); i++ {
+		prev, cur := d.parts[i-1], d.parts[i]
+		d.parts[i].center = prev.center + (boxW(prev)+1)/2 + boxW(cur)/2 + 3
+	}
+	shiftFrom := func(i, by int) {
+		for ; i < len(d.parts); i++ {
+			d.parts[i].center += by
+		}
+	}
+	right := d.parts[len(d.parts)-1].center + boxW(d.parts[len(d.parts)-1])/2 + 1
+	for _, ev := range d.evs {
+		w := xansi.StringWidth(ev.text)
+		switch {
+		case ev.kind == seqMessage && ev.from != ev.to:
+			a, b := min(ev.from, ev.to), max(ev.from, ev.to)
+			if need := w + 4 - (d.parts[b].center - d.parts[a].center); need > 0 {
+				shiftFrom(b, need)
+			}
+		case ev.kind == seqMessage:
+			// Self message: loop plus label to the right of the lifeline.
+			if ev.from+1 < len(d.parts) {
+				if need := w + 7 - (d.parts[ev.from+1].center - d.parts[ev.from].center); need > 0 {
+					shiftFrom(ev.from+1, need)
+				}
+			}
+		}
+	}
+	last := d.parts[len(d.parts)-1]
+	right = max(right, last.center+boxW(last)/2+1)
+	for _, ev := range d.evs {
+		w := xansi.StringWidth(ev.text)
+		switch {
+		case ev.kind == seqMessage && ev.from == ev.to:
+			right = max(right, d.parts[ev.from].center+w+6)
+		case ev.kind == seqNote:
+			right = max(right, d.parts[ev.to].center+w+8)
+		case ev.kind == seqBlock:
+			right = max(right, w+6)
+		}
+	}
+
+	height := 3 + 3
+	for _, ev := range d.evs {
+		switch ev.kind {
+		case seqMessage:
+			height += 2
+		case seqNote:
+			height += 3
+		case seqBlock:
+			height++
+		}
+	}
+	height++ // breathing room above the bottom boxes
+
+	c := newDiagramCanvas(right, height)
+	drawBoxes := func(y int) {
+		for _, p := range d.parts {
+			w := boxW(p)
+			c.box(p.center-w/2, y, w, 3, []string{p.label})
+		}
+	}
+	drawBoxes(0)
+	drawBoxes(height - 3)
+	for _, p := range d.parts {
+		for y := 3; y < height-3; y++ {
+			c.set(p.center, y, '│')
+		}
+	}
+
+	y := 3
+	for _, ev := range d.evs {
+		switch ev.kind {
+		case seqMessage:
+			a, b := d.parts[ev.from].center, d.parts[ev.to].center
+			line := '─'
+			if ev.dashed {
+				line = '╌'
+			}
+			if a == b {
+				c.text(a+1, y, string([]rune{line, line, '┐'}))
+				c.text(a+5, y, ev.text)
+				c.text(a+1, y+1, string([]rune{'◀', line, '┘'}))
+				y += 2
+				continue
+			}
+			c.text(min(a, b)+2, y, ev.text)
+			for x := min(a, b) + 1; x < max(a, b); x++ {
+				c.set(x, y+1, line)
+			}
+			if b > a {
+				c.set(b-1, y+1, ev.head)
+			} else {
+				head := ev.head
+				if head == '▶' {
+					head = '◀'
+				} else if head == '▷' {
+					head = '◁'
+				}
+				c.set(b+1, y+1, head)
+			}
+			y += 2
+		case seqNote:
+			w := xansi.StringWidth(ev.text) + 4
+			a, b := d.parts[ev.from].center, d.parts[ev.to].center
+			x := (a+b)/2 - w/2
+			switch ev.notePos {
+			case "right of":
+				x = a + 2
+			case "left of":
+				x = a - 1 - w
+			default:
+				if span := b - a + 4; span > w {
+					w = span
+					x = a - 2
+				}
+			}
+			x = max(0, x)
+			for yy := y; yy < y+3; yy++ {
+				for xx := x; xx < x+w; xx++ {
+					c.set(xx, yy, 0)
+				}
+			}
+			c.box(x, y, w, 3, []string{ev.text})
+			y += 3
+		case seqBlock:
+			for x := 0; x < right; x++ {
+				c.set(x, y, '┄')
+			}
+			if ev.text != "" {
+				c.text(2, y, " "+ev.text+" ")
+			}
+			y++
+		}
+	}
+	return c.lines()
+}
@@ -0,0 +1,78 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	xansi "github.com/charmbracelet/x/ansi"
+)
+
+func TestRenderMermaid_Flowchart(t *testing.T) {
+	src := "graph TD\n  A[Start] --> B[End]\n"
+	got, ok := renderMermaid(src, 80)
+	if !ok {
+		t.Fatalf("expected flowchart to render")
+	}
+	want := []string{
+		"┌───────┐",
+		"│ Start │",
+		"└───┬───┘",
+		"    │",
+		"    ▼",
+		" ┌─────┐",
+		" │ End │",
+		" └─────┘",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected diagram:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func TestRenderMermaid_Sequence(t *testing.T) {
+	src := "sequenceDiagram\n  A->>B: hi\n  B-->>A: ok\n"
+	got, ok := renderMermaid(src, 80)
+	if !ok {
+		t.Fatalf("expected sequence diagram to render")
+	}
+	want := []string{
+		"┌───┐   ┌───┐",
+		"│ A │   │ B │",
+		"└───┘   └───┘",
+		"  │ hi    │",
+		"  │──────▶│",
+		"  │ ok    │",
+		"  │◀╌╌╌╌╌╌│",
+		"  │       │",
+		"┌───┐   ┌───┐",
+		"│ A │   │ B │",
+		"└───┘   └───┘",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected diagram:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func TestRenderMermaid_Fallback(t *testing.T) {
+	if _, ok := renderMermaid("pie\n  \"a\": 1\n", 80); ok {
+		t.Fatalf("unsupported diagram types should fall back")
+	}
+	if _, ok := renderMermaid("graph LR\n  A[a very long label] --> B[another very long label]\n", 20); ok {
+		t.Fatalf("diagrams wider than the render width should fall back")
+	}
+
+	out, err := RenderMarkdown("```mermaid\npie\n  \"a\": 1\n```\n", Options{Style: "dark", Width: 60})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(xansi.Strip(out), "pie") {
+		t.Fatalf("expected mermaid source in fallback output")
+	}
+}
+
+func TestMermaidStatements_KeepsSemicolonsInLabels(t *testing.T) {
+	got := mermaidStatements("graph TD; A[\"x; y\"] --> B(a;b)\n%% c;d\nB --> C{\"p]; q\"}; C --> D\n")
+	want := []string{"graph TD", `A["x; y"] --> B(a;b)`, `B --> C{"p]; q"}`, "C --> D"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("statements = %q, want %q", got, want)
+	}
+}
@@ -13,35 +13,92 @@ import (
 type Options struct {
 	Style string // auto|dark|light
 	Width int
+
+	// Images selects how standalone local images are drawn: ImagesKitty,
+	// ImagesITerm, ImagesSixel, ImagesBlocks, or "" to keep them as link text.
+	Images string
+	// BaseDir resolves relative image paths (the document's directory).
+	BaseDir string
+	// CellWidth and CellHeight are the terminal cell size in pixels, used to
+	// size images (0 = 10×20).
+	CellWidth, CellHeight int
+
+	// Hyperlinks makes links clickable with OSC 8 escape sequences; relative
+	// targets resolve to file:// URLs under BaseDir.
+	Hyperlinks bool
+	// HideURLs drops the raw URL glamour prints after link text.
+	HideURLs bool
+
+	// WideTables lets ```csv and ```tsv tables grow past Width (for a
+	// pager that scrolls sideways) instead of shortening their columns.
+	WideTables bool
+
+	// FenceCommands renders fenced blocks by language (lower case) with
+	// external programs. They take precedence over the built-in renderers.
+	FenceCommands map[string]FenceCommand
 }
 
 func strPtr(s string) *string { return &s }
 func boolPtr(b bool) *bool    { return &b }
 
-func editorialStyleConfig(style string) (ansi.StyleConfig, error) {
+// editorialTheme bundles the glamour style with the colors of the blocks we
+// render ourselves (callouts, ...), so both always agree on dark vs light.
+type editorialTheme struct {
+	Dark   bool
+	Styles ansi.StyleConfig
+	Alerts map[alertKind]string
+}
+
+func editorialThemeFor(style string) (editorialTheme, error) {
 	switch strings.ToLower(strings.TrimSpace(style)) {
 	case "dark":
-		return editorialDark(), nil
+		return editorialTheme{Dark: true, Styles: editorialDark(), Alerts: editorialDarkAlerts()}, nil
 	case "light":
-		return editorialLight(), nil
+		return editorialTheme{Styles: editorialLight(), Alerts: editorialLightAlerts()}, nil
 	case "", "auto":
 		if termenv.HasDarkBackground() {
-			return editorialDark(), nil
+			return editorialThemeFor("dark")
 		}
-		return editorialLight(), nil
+		return editorialThemeFor("light")
 	default:
-		return ansi.StyleConfig{}, fmt.Errorf("invalid --style=%q (use auto|dark|light)", style)
+		return editorialTheme{}, fmt.Errorf("invalid --style=%q (use auto|dark|light)", style)
+	}
+}
+
+// Palette is the editorial color set, for outputs other than the terminal
+// (e.g. HTML) that should look like the rendered document.
+type Palette struct {
+	Accent, Fg, Muted, CodeBg string
+	// Alerts maps note|tip|important|warning|caution to a color.
+	Alerts map[string]string
+}
+
+var (
+	// Editorial Minimal: monochrome base + one accent.
+	darkPalette  = Palette{Accent: "#8AB4F8", Fg: "#E6E6E6", Muted: "#B8B8B8", CodeBg: "#141414"}
+	lightPalette = Palette{Accent: "#2563EB", Fg: "#1A1A1A", Muted: "#444444", CodeBg: "#F2F2F2"}
+)
+
+// EditorialPalette returns the colors of the dark or light theme.
+func EditorialPalette(dark bool) Palette {
+	p, alerts := lightPalette, editorialLightAlerts()
+	if dark {
+		p, alerts = darkPalette, editorialDarkAlerts()
 	}
+	p.Alerts = map[string]string{}
+	for k, c := range alerts {
+		p.Alerts[string(k)] = c
+	}
+	return p
 }
 
 func editorialDark() ansi.StyleConfig {
 	cfg := styles.DarkStyleConfig
 
-	// Editorial Minimal: monochrome base + one accent.
-	accent := "#8AB4F8"
-	fg := "#E6E6E6"
-	muted := "#B8B8B8"
-	codeBg := "#141414"
+	accent := darkPalette.Accent
+	fg := darkPalette.Fg
+	muted := darkPalette.Muted
+	codeBg := darkPalette.CodeBg
 
 	cfg.Document.StylePrimitive.Color = strPtr(fg)
 	cfg.Heading.StylePrimitive.Color = strPtr(accent)
@@ -77,13 +134,23 @@ func editorialDark() ansi.StyleConfig {
 	return cfg
 }
 
+func editorialDarkAlerts() map[alertKind]string {
+	return map[alertKind]string{
+		alertNote:      "#8AB4F8",
+		alertTip:       "#81C995",
+		alertImportant: "#C58AF9",
+		alertWarning:   "#FDD663",
+		alertCaution:   "#F28B82",
+	}
+}
+
 func editorialLight() ansi.StyleConfig {
 	cfg := styles.LightStyleConfig
 
-	accent := "#2563EB"
-	fg := "#1A1A1A"
-	muted := "#444444"
-	codeBg := "#F2F2F2"
+	accent := lightPalette.Accent
+	fg := lightPalette.Fg
+	muted := lightPalette.Muted
+	codeBg := lightPalette.CodeBg
 
 	cfg.Document.StylePrimitive.Color = strPtr(fg)
 	cfg.Heading.StylePrimitive.Color = strPtr(accent)
@@ -115,22 +182,78 @@ func editorialLight() ansi.StyleConfig {
 	return cfg
 }
 
+func editorialLightAlerts() map[alertKind]string {
+	return map[alertKind]string{
+		alertNote:      "#2563EB",
+		alertTip:       "#15803D",
+		alertImportant: "#7C3AED",
+		alertWarning:   "#B45309",
+		alertCaution:   "#DC2626",
+	}
+}
+
 func RenderMarkdown(md string, opts Options) (string, error) {
+	out, images, err := RenderWithImages(md, opts)
+	if err != nil {
+		return "", err
+	}
+	return drawImages(out, images), nil
+}
+
+// RenderWithImages renders like RenderMarkdown but leaves graphics protocol
+// images to the caller: the output holds their half-block previews and the
+// returned Images say where to draw them.
+func RenderWithImages(md string, opts Options) (string, []Image, error) {
+	r, err := Render(md, opts)
+	return r.Text, r.Images, err
+}
+
+// Rendered is a rendered document with what a pager needs to know about it.
+type Rendered struct {
+	Text   string
+	Images []Image // see RenderWithImages
+	// FootnoteRefs lists the footnote numbers referenced on each line of Text.
+	FootnoteRefs map[int][]int
+}
+
+// Render renders md like RenderWithImages and also reports where footnotes
+// are referenced.
+func Render(md string, opts Options) (Rendered, error) {
 	w := opts.Width
 	if w <= 0 {
 		w = 80
 	}
 
-	var renderer *glamour.TermRenderer
-	var err error
+	th, err := editorialThemeFor(opts.Style)
+	if err != nil {
+		return Rendered{}, err
+	}
 
-	cfg, cfgErr := editorialStyleConfig(opts.Style)
-	if cfgErr != nil {
-		return "", cfgErr
+	// Constructs glamour does not know about are rendered by us and spliced
+	// back into its output (see splicer).
+	sp := &splicer{}
+	bw := blockWidth(th.Styles, w)
+	hooks := fenceHooks(opts, th)
+	md, refs := preprocessFootnotes(md)
+	md, pending := preprocessImages(md, opts, bw, sp)
+	md, targets := preprocessLinks(md, opts)
+	md = preprocessAlerts(md, th, bw, sp, hooks)
+	md = preprocessFences(md, bw, sp, hooks)
+	md = preprocessMath(md, bw, sp)
+
+	out, err := renderGlamour(md, th.Styles, w)
+	if err != nil {
+		return Rendered{}, err
 	}
-	renderer, err = glamour.NewTermRenderer(
+	out = postprocessLinks(sp.splice(out), targets, opts.Hyperlinks)
+	out, refLines := takeFootnoteRefs(out, refs)
+	return Rendered{Text: out, Images: placeImages(sp, pending), FootnoteRefs: refLines}, nil
+}
+
+func renderGlamour(md string, cfg ansi.StyleConfig, width int) (string, error) {
+	renderer, err := glamour.NewTermRenderer(
 		glamour.WithStyles(cfg),
-		glamour.WithWordWrap(w),
+		glamour.WithWordWrap(width),
 	)
 	if err != nil {
 		return "", fmt.Errorf("init renderer: %w", err)
@@ -0,0 +1,80 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"strings"
+)
+
+// encodeSixel converts img to a DEC sixel sequence using a 6×6×6 color cube.
+// Transparent pixels are left undrawn.
+func encodeSixel(img *image.NRGBA) string {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	level := func(v uint8) int { return (int(v)*5 + 127) / 255 }
+	index := func(x, y int) int {
+		c := img.NRGBAAt(b.Min.X+x, b.Min.Y+y)
+		if c.A < 0x80 {
+			return -1
+		}
+		return level(c.R)*36 + level(c.G)*6 + level(c.B)
+	}
+
+	var sb strings.Builder
+	// P2=1: pixels we do not paint keep the background.
+	fmt.Fprintf(&sb, "\x1bP0;1;0q\"1;1;%d;%d", w, h)
+	for i := 0; i < 216; i++ {
+		fmt.Fprintf(&sb, "#%d;2;%d;%d;%d", i, i/36*20, i/6%6*20, i%6*20)
+	}
+
+	row := make([]byte, w)
+	for band := 0; band < h; band += 6 {
+		// Bits per color for each column of the band.
+		used := map[int][]byte{}
+		var order []int
+		for dy := 0; dy < 6 && band+dy < h; dy++ {
+			for x := 0; x < w; x++ {
+				c := index(x, band+dy)
+				if c < 0 {
+					continue
+				}
+				bits, ok := used[c]
+				if !ok {
+					bits = make([]byte, w)
+					used[c] = bits
+					order = append(order, c)
+				}
+				bits[x] |= 1 << dy
+			}
+		}
+		for i, c := range order {
+			if i > 0 {
+				sb.WriteByte('$') // back to the start of the band
+			}
+			fmt.Fprintf(&sb, "#%d", c)
+			for x, bits := range used[c] {
+				row[x] = '?' + bits
+			}
+			writeSixelRLE(&sb, row)
+		}
+		sb.WriteByte('-')
+	}
+	sb.WriteString("\x1b\\")
+	return sb.String()
+}
+
+func writeSixelRLE(sb *strings.Builder, row []byte) {
+	for i := 0; i < len(row); {
+		j := i
+		for j < len(row) && row[j] == row[i] {
+			j++
+		}
+		if n := j - i; n > 3 {
+			fmt.Fprintf(sb, "!%d%c", n, row[i])
+		} else {
+			sb.Write(row[i:j])
+		}
+		i = j
+	}
+}
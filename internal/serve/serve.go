@@ -0,0 +1,292 @@
+// Package serve previews a directory of Markdown files in the browser:
+// Markdown is rendered to HTML with the editorial theme, directories get an
+// index, and open pages reload when their file changes.
+package serve
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/simota/md/internal/export"
+	"github.com/simota/md/internal/input"
+)
+
+// EventsPath is the Server-Sent Events endpoint pages subscribe to.
+const EventsPath = "/_md/events"
+
+// Server serves Root. The zero value serves the working directory.
+type Server struct {
+	Root  string        // "" = working directory
+	Style string        // auto|dark|light
+	Poll  time.Duration // how often watched files are checked, default 500ms
+}
+
+// reloadScript reloads the page when the server reports a change. It is the
+// only script a page may run: the Content-Security-Policy allows just its
+// nonce.
+func reloadScript(nonce string) template.HTML {
+	return template.HTML(`<script nonce="` + nonce + `">
+new EventSource("` + EventsPath + `?path=" + encodeURIComponent(location.pathname))
+  .addEventListener("reload", function () { location.reload(); });
+</script>`)
+}
+
+// contentSecurityPolicy applies to every response; scriptSrc is "'none'" or
+// a nonce source. Images may come from anywhere, as documents link them.
+func contentSecurityPolicy(scriptSrc string) string {
+	return "default-src 'self'; script-src " + scriptSrc + "; style-src 'self' 'unsafe-inline'; img-src * data:; object-src 'none'; base-uri 'none'; form-action 'none'; frame-ancestors 'none'"
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !localHost(r.Host) {
+		// A page on another site may resolve its own name to 127.0.0.1 (DNS
+		// rebinding); only requests addressed to this machine are answered.
+		http.Error(w, "forbidden host "+r.Host+" (use localhost or an IP address)", http.StatusForbidden)
+		return
+	}
+	w.Header().Set("Content-Security-Policy", contentSecurityPolicy("'none'"))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if r.URL.Path == EventsPath {
+		s.serveEvents(w, r)
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	file, ok := s.resolve(r.URL.Path)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	st, err := os.Stat(file)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	switch {
+	case st.IsDir():
+		if !strings.HasSuffix(r.URL.Path, "/") {
+			// Relative links in the listing need the trailing slash.
+			http.Redirect(w, r, r.URL.Path+"/", http.StatusMovedPermanently)
+			return
+		}
+		s.serveIndex(w, r.URL.Path, file)
+	case isMarkdown(file):
+		s.serveMarkdown(w, file)
+	default:
+		http.ServeFile(w, r, file)
+	}
+}
+
+// resolve maps a URL path to an existing file under Root. Paths that would
+// leave Root, also through a symlink, and hidden files (.git, .env, ...) are
+// refused.
+func (s *Server) resolve(urlPath string) (string, bool) {
+	clean := path.Clean("/" + urlPath)
+	if hiddenPath(clean, "/") {
+		return "", false
+	}
+	root, err := filepath.Abs(s.root())
+	if err != nil {
+		return "", false
+	}
+	if root, err = filepath.EvalSymlinks(root); err != nil {
+		return "", false
+	}
+	file, err := filepath.EvalSymlinks(filepath.Join(root, filepath.FromSlash(clean)))
+	if err != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(root, file)
+	if err != nil || !filepath.IsLocal(rel) || hiddenPath(rel, string(filepath.Separator)) {
+		return "", false
+	}
+	return file, true
+}
+
+func hiddenPath(p, sep string) bool {
+	for _, part := range strings.Split(p, sep) {
+		if strings.HasPrefix(part, ".") && part != "." {
+			return true
+		}
+	}
+	return false
+}
+
+// localHost reports whether a Host header names this machine: localhost, a
+// *.localhost name or an IP address. DNS rebinding needs a domain name, so
+// IP addresses are safe to accept, e.g. with --addr :8080 on a LAN.
+func localHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.ToLower(strings.Trim(host, "[]")), ".")
+	return host == "localhost" || strings.HasSuffix(host, ".localhost") || net.ParseIP(host) != nil
+}
+
+func (s *Server) root() string {
+	if s.Root == "" {
+		return "."
+	}
+	return s.Root
+}
+
+func (s *Server) serveMarkdown(w http.ResponseWriter, file string) {
+	raw, err := os.ReadFile(file)
+	if err == nil {
+		raw, err = input.Decode(raw, "")
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.writePage(w, filepath.Base(file), string(raw))
+}
+
+// serveIndex lists subdirectories and Markdown files. The listing is built
+// as Markdown so it gets the same look as the documents.
+func (s *Server) serveIndex(w http.ResponseWriter, urlPath, dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var dirs, files []string
+	for _, e := range entries {
+		name := e.Name()
+		switch {
+		case strings.HasPrefix(name, "."):
+		case e.IsDir():
+			dirs = append(dirs, name+"/")
+		case isMarkdown(name):
+			files = append(files, name)
+		}
+	}
+	sort.Strings(dirs)
+	sort.Strings(files)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", escapeMarkdown(urlPath))
+	if urlPath != "/" {
+		b.WriteString("- [../](../)\n")
+	}
+	for _, name := range append(dirs, files...) {
+		// "./" keeps names like "a:b.md" from reading as a URL scheme.
+		fmt.Fprintf(&b, "- [%s](<./%s>)\n", escapeMarkdown(name), (&url.URL{Path: name}).EscapedPath())
+	}
+	if len(dirs)+len(files) == 0 {
+		b.WriteString("_No Markdown files here._\n")
+	}
+	s.writePage(w, urlPath, b.String())
+}
+
+func (s *Server) writePage(w http.ResponseWriter, title, md string) {
+	var nonce [16]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	n := base64.StdEncoding.EncodeToString(nonce[:])
+	page, err := export.HTML(md, export.HTMLOptions{Title: title, Style: s.Style, Head: reloadScript(n)})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Security-Policy", contentSecurityPolicy("'nonce-"+n+"'"))
+	_, _ = w.Write([]byte(page))
+}
+
+// serveEvents streams a "reload" event whenever the file (or directory
+// listing) at ?path= changes, until the browser disconnects.
+func (s *Server) serveEvents(w http.ResponseWriter, r *http.Request) {
+	file, ok := s.resolve(r.URL.Query().Get("path"))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	poll := s.Poll
+	if poll <= 0 {
+		poll = 500 * time.Millisecond
+	}
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+
+	last := fingerprint(file)
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			cur := fingerprint(file)
+			if cur == last {
+				continue
+			}
+			last = cur
+			if _, err := fmt.Fprint(w, "event: reload\ndata: {}\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// fingerprint summarizes what a page shows: a file's size and modification
+// time, or a directory's visible entries.
+func fingerprint(file string) string {
+	st, err := os.Stat(file)
+	if err != nil {
+		return "missing"
+	}
+	if !st.IsDir() {
+		return fmt.Sprintf("%d %d", st.Size(), st.ModTime().UnixNano())
+	}
+	entries, err := os.ReadDir(file)
+	if err != nil {
+		return "unreadable"
+	}
+	var b strings.Builder
+	for _, e := range entries {
+		if !strings.HasPrefix(e.Name(), ".") {
+			fmt.Fprintf(&b, "%s %t\n", e.Name(), e.IsDir())
+		}
+	}
+	return b.String()
+}
+
+func isMarkdown(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".md", ".markdown", ".mdown", ".mkd":
+		return true
+	}
+	return false
+}
+
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, `[`, `\[`, `]`, `\]`, `*`, `\*`, `_`, `\_`, "`", "\\`", `<`, `\<`)
+
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
@@ -0,0 +1,185 @@
+package serve
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestServer(t *testing.T) (string, *httptest.Server) {
+	t.Helper()
+	parent := t.TempDir()
+	root := filepath.Join(parent, "site")
+	for name, body := range map[string]string{
+		"README.md":        "# Read Me\n\nSee [guide](docs/guide.md#setup).\n",
+		"docs/guide.md":    "# Guide\n\n## Setup\n",
+		"docs/img.txt":     "plain",
+		".git/config":      "secret",
+		"../outside.md":    "# Outside\n",
+		"docs/.hidden.md":  "# Hidden\n",
+		"empty/notes.text": "",
+	} {
+		p := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ts := httptest.NewServer(&Server{Root: root, Poll: 10 * time.Millisecond})
+	t.Cleanup(ts.Close)
+	return root, ts
+}
+
+func get(t *testing.T, url string) (int, string) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
+
+func TestServer_RendersMarkdown(t *testing.T) {
+	_, ts := newTestServer(t)
+	code, body := get(t, ts.URL+"/docs/guide.md")
+	if code != http.StatusOK {
+		t.Fatalf("status %d", code)
+	}
+	for _, want := range []string{`<h2 id="setup">Setup</h2>`, EventsPath, "<title>guide.md</title>"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("missing %q in:\n%s", want, body)
+		}
+	}
+	if code, body := get(t, ts.URL+"/docs/img.txt"); code != http.StatusOK || body != "plain" {
+		t.Errorf("static file: %d %q", code, body)
+	}
+}
+
+func TestServer_Index(t *testing.T) {
+	_, ts := newTestServer(t)
+	code, body := get(t, ts.URL+"/")
+	if code != http.StatusOK {
+		t.Fatalf("status %d", code)
+	}
+	for _, want := range []string{`href="./docs/"`, `href="./empty/"`, `href="./README.md"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("missing %q in:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, ".git") {
+		t.Errorf("hidden directory listed:\n%s", body)
+	}
+
+	// Directories without a trailing slash redirect, so relative links work.
+	_, body = get(t, ts.URL+"/docs")
+	if !strings.Contains(body, `href="./guide.md"`) || strings.Contains(body, "hidden") {
+		t.Errorf("docs index:\n%s", body)
+	}
+}
+
+func TestServer_RefusesOutsideAndHidden(t *testing.T) {
+	root, ts := newTestServer(t)
+	for _, p := range []string{"/../outside.md", "/docs/../../outside.md", "/.git/config", "/docs/.hidden.md"} {
+		req, _ := http.NewRequest(http.MethodGet, ts.URL, nil)
+		req.URL.Path = p // keep the dots; the client would clean them
+		rec := httptest.NewRecorder()
+		(&Server{Root: root}).ServeHTTP(rec, req)
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("%s: status %d, want 404", p, rec.Code)
+		}
+	}
+}
+
+func TestServer_SymlinksStayInRoot(t *testing.T) {
+	root, ts := newTestServer(t)
+	links := map[string]string{
+		"out.md":   filepath.Join(root, "..", "outside.md"),
+		"up":       "..",
+		"git":      ".git",
+		"guide.md": filepath.Join("docs", "guide.md"),
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(root, name)); err != nil {
+			t.Skip("symlinks not supported:", err)
+		}
+	}
+	for _, p := range []string{"/out.md", "/up/outside.md", "/git/config"} {
+		if code, body := get(t, ts.URL+p); code != http.StatusNotFound {
+			t.Errorf("%s: status %d, body %.40q", p, code, body)
+		}
+	}
+	if code, body := get(t, ts.URL+"/guide.md"); code != http.StatusOK || !strings.Contains(body, "Guide") {
+		t.Errorf("link inside the root: status %d", code)
+	}
+}
+
+func TestServer_ReloadEvent(t *testing.T) {
+	root, ts := newTestServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+EventsPath+"?path=/README.md", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("content type %q", ct)
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "README.md"), []byte("# Changed, longer\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sc := bufio.NewScanner(resp.Body)
+	for sc.Scan() {
+		if sc.Text() == "event: reload" {
+			return
+		}
+	}
+	t.Fatalf("no reload event: %v", sc.Err())
+}
+
+func TestServer_HostCheckAndCSP(t *testing.T) {
+	root, ts := newTestServer(t)
+	resp, err := http.Get(ts.URL + "/README.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	csp := resp.Header.Get("Content-Security-Policy")
+	nonce, ok := strings.CutPrefix(csp[strings.Index(csp, "script-src ")+len("script-src "):], "'nonce-")
+	if !ok {
+		t.Fatalf("no script nonce in %q", csp)
+	}
+	nonce = nonce[:strings.Index(nonce, "'")]
+	if !strings.Contains(string(body), `<script nonce="`+nonce+`">`) {
+		t.Errorf("reload script lacks nonce %q", nonce)
+	}
+
+	for host, want := range map[string]int{
+		"evil.example:7070": http.StatusForbidden,
+		"localhost:7070":    http.StatusOK,
+		"[::1]:7070":        http.StatusOK,
+		"192.168.1.5:8080":  http.StatusOK,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/README.md", nil)
+		req.Host = host
+		rec := httptest.NewRecorder()
+		(&Server{Root: root}).ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("Host %s: status %d, want %d", host, rec.Code, want)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+// Package stats counts words, reading time and structure of a document.
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/simota/md/internal/export"
+)
+
+// WordsPerMinute is the reading speed used for reading time estimates.
+const WordsPerMinute = 200
+
+// Stats summarizes a document. Words and characters count prose only:
+// code blocks and raw HTML are left out, inline code is included.
+type Stats struct {
+	Words      int            `json:"words"`
+	Characters int            `json:"characters"` // not counting whitespace
+	Minutes    int            `json:"reading_minutes"`
+	Lines      int            `json:"lines"`
+	Headings   [6]int         `json:"headings"` // H1..H6
+	CodeBlocks map[string]int `json:"code_blocks"`
+	Links      int            `json:"links"`
+	Images     int            `json:"images"`
+	Tables     int            `json:"tables"`
+}
+
+// Compute parses md and gathers its statistics.
+func Compute(md string) Stats {
+	s, _ := compute(md)
+	return s
+}
+
+// WordsByLine returns the words Compute counts on each 0-based line of md
+// (split on "\n"); a word goes to the line its text node starts on.
+func WordsByLine(md string) []int {
+	_, byLine := compute(md)
+	return byLine
+}
+
+func compute(md string) (Stats, []int) {
+	ast := export.ParseAST(md)
+	s := Stats{CodeBlocks: map[string]int{}, Lines: ast.Document.EndLine}
+	byLine := make([]int, strings.Count(md, "\n")+1)
+
+	var walk func(n *export.Node, line int)
+	walk = func(n *export.Node, line int) {
+		if n.StartLine > 0 {
+			line = n.StartLine
+		}
+		words := 0
+		switch n.Kind {
+		case "Heading":
+			if n.Level >= 1 && n.Level <= 6 {
+				s.Headings[n.Level-1]++
+			}
+		case "FencedCodeBlock", "CodeBlock":
+			lang := n.Language
+			if lang == "" {
+				lang = "text"
+			}
+			s.CodeBlocks[lang]++
+			return
+		case "HTMLBlock", "RawHTML":
+			return
+		case "Link":
+			s.Links++
+		case "AutoLink":
+			// A URL reads as one word.
+			s.Links++
+			words = 1
+			s.Characters += countChars(n.Text)
+		case "Image":
+			s.Images++
+		case "Table":
+			s.Tables++
+		case "Text", "String":
+			words = CountWords(n.Text)
+			s.Characters += countChars(n.Text)
+		}
+		s.Words += words
+		if line > 0 && line <= len(byLine) {
+			byLine[line-1] += words
+		}
+		for _, ch := range n.Children {
+			walk(ch, line)
+		}
+	}
+	walk(ast.Document, 0)
+	s.Minutes = ReadingMinutes(s.Words)
+	return s, byLine
+}
+
+// CountWords counts runs of letters and digits. Han, kana and Hangul
+// characters are counted one word each, since those scripts do not separate
+// words with spaces; punctuation and box drawing are not words.
+func CountWords(text string) int {
+	n := 0
+	inWord := false
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			n++
+			inWord = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if !inWord {
+				n++
+			}
+			inWord = true
+		case r == '\'' || r == '’' || r == '-' || r == '_':
+			// Part of the word when inside one: don't, e-mail, snake_case.
+		default:
+			inWord = false
+		}
+	}
+	return n
+}
+
+func countChars(text string) int {
+	n := 0
+	for _, r := range text {
+		if !unicode.IsSpace(r) {
+			n++
+		}
+	}
+	return n
+}
+
+// ReadingMinutes estimates the reading time of words, rounded up.
+func ReadingMinutes(words int) int {
+	return (words + WordsPerMinute - 1) / WordsPerMinute
+}
+
+// Rows returns the statistics as label/value pairs, in display order.
+func (s Stats) Rows() [][2]string {
+	rows := [][2]string{
+		{"Words", fmt.Sprint(s.Words)},
+		{"Characters", fmt.Sprint(s.Characters)},
+		{"Reading time", fmt.Sprintf("~%d min", max(1, s.Minutes))},
+		{"Lines", fmt.Sprint(s.Lines)},
+	}
+
+	total := 0
+	var levels []string
+	for i, n := range s.Headings {
+		total += n
+		if n > 0 {
+			levels = append(levels, fmt.Sprintf("H%d %d", i+1, n))
+		}
+	}
+	rows = append(rows, [2]string{"Headings", withParts(total, levels)})
+
+	type lang struct {
+		name string
+		n    int
+	}
+	var langs []lang
+	total = 0
+	for name, n := range s.CodeBlocks {
+		langs = append(langs, lang{name, n})
+		total += n
+	}
+	sort.Slice(langs, func(i, j int) bool {
+		if langs[i].n != langs[j].n {
+			return langs[i].n > langs[j].n
+		}
+		return langs[i].name < langs[j].name
+	})
+	parts := make([]string, 0, len(langs))
+	for _, l := range langs {
+		parts = append(parts, fmt.Sprintf("%s %d", l.name, l.n))
+	}
+	rows = append(rows,
+		[2]string{"Code blocks", withParts(total, parts)},
+		[2]string{"Links", fmt.Sprint(s.Links)},
+		[2]string{"Images", fmt.Sprint(s.Images)},
+		[2]string{"Tables", fmt.Sprint(s.Tables)},
+	)
+	return rows
+}
+
+func withParts(total int, parts []string) string {
+	if len(parts) == 0 {
+		return fmt.Sprint(total)
+	}
+	return fmt.Sprintf("%d (%s)", total, strings.Join(parts, ", "))
+}
+
+// Text formats the statistics as aligned "label  value" lines.
+func (s Stats) Text() string {
+	var b strings.Builder
+	for _, r := range s.Rows() {
+		fmt.Fprintf(&b, "%-14s %s\n", r[0], r[1])
+	}
+	return b.String()
+}
+
+// JSON formats the statistics as indented JSON.
+func (s Stats) JSON() (string, error) {
+	out, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("encode stats: %w", err)
+	}
+	return string(out) + "\n", nil
+}
@@ -0,0 +1,62 @@
+package stats
+
+import "testing"
+
+func TestCountWords(t *testing.T) {
+	cases := []struct {
+		in   string
+		want int
+	}{
+		{"Hello, world!", 2},
+		{"don't re-use snake_case", 3},
+		{"│ ── │ 42 │", 1},
+		{"日本語の文章", 6},
+		{"Go言語", 3},
+	}
+	for _, c := range cases {
+		if got := CountWords(c.in); got != c.want {
+			t.Errorf("CountWords(%q) = %d, want %d", c.in, got, c.want)
+		}
+	}
+}
+
+func TestCompute_CountsStructure(t *testing.T) {
+	md := "---\ntitle: Post\n---\n# Title\n\nSome [link](x.md) and `code`.\n\n## Part\n\n" +
+		"```go\nfunc main() {}\n```\n\n```\nplain\n```\n\n![alt](a.png)\n\n| a | b |\n|---|---|\n| 1 | 2 |\n"
+	s := Compute(md)
+
+	if s.Words != 11 {
+		t.Errorf("Words = %d, want 11", s.Words)
+	}
+	if s.Headings != [6]int{1, 1} {
+		t.Errorf("Headings = %v", s.Headings)
+	}
+	if s.CodeBlocks["go"] != 1 || s.CodeBlocks["text"] != 1 || len(s.CodeBlocks) != 2 {
+		t.Errorf("CodeBlocks = %v", s.CodeBlocks)
+	}
+	if s.Links != 1 || s.Images != 1 || s.Tables != 1 {
+		t.Errorf("links/images/tables = %d/%d/%d", s.Links, s.Images, s.Tables)
+	}
+	if s.Minutes != 1 {
+		t.Errorf("Minutes = %d, want 1", s.Minutes)
+	}
+}
+
+func TestWordsByLine_MatchesCompute(t *testing.T) {
+	md := "# One two\n\nthree four\nfive\n\n```\nnot counted\n```\n\n- six <https://x.io>\n"
+	got := WordsByLine(md)
+	want := []int{2, 0, 2, 1, 0, 0, 0, 0, 0, 2, 0}
+	if len(got) != len(want) {
+		t.Fatalf("WordsByLine = %v, want %v", got, want)
+	}
+	total := 0
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("WordsByLine = %v, want %v", got, want)
+		}
+		total += got[i]
+	}
+	if total != Compute(md).Words {
+		t.Errorf("lines add up to %d, Compute says %d", total, Compute(md).Words)
+	}
+}
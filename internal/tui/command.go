@@ -0,0 +1,342 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/simota/md/internal/export"
+	"github.com/simota/md/internal/input"
+	"github.com/simota/md/internal/render"
+)
+
+// commandNames are the commands of the ":" command line, for completion.
+var commandNames = []string{"e", "fold", "goto", "q", "set", "w"}
+
+// fileCommands read or write files. An embedded viewer leaves files to the
+// program around it, so they are not available there.
+var fileCommands = map[string]bool{"e": true, "w": true, "w!": true}
+
+func (m *model) openCommandLine() {
+	m.cmdMode = true
+	m.cmdDraft = ""
+	m.cmdCompletions = nil
+	m.showHelp, m.showMeta, m.showStats = false, false, false
+}
+
+func (m *model) handleCommandKey(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		m.cmdMode = false
+		return nil
+	case "enter":
+		m.cmdMode = false
+		return m.runCommand(m.cmdDraft)
+	case "tab":
+		m.completeCommand()
+		return nil
+	case "backspace", "ctrl+h":
+		if m.cmdDraft == "" {
+			m.cmdMode = false
+			return nil
+		}
+	}
+	if editPrompt(&m.cmdDraft, msg) {
+		m.cmdCompletions = nil
+	}
+	return nil
+}
+
+// runCommand runs one command line and shows its result or error in the
+// footer.
+func (m *model) runCommand(line string) tea.Cmd {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return nil
+	}
+	if line == "q" || line == "q!" {
+		if m.embedded {
+			return nil
+		}
+		return tea.Quit
+	}
+	status, err := m.execCommand(line)
+	if err != nil {
+		status = err.Error()
+	}
+	if status == "" {
+		return nil
+	}
+	m.statusMessage = status
+	return m.statusTick()
+}
+
+func (m *model) execCommand(line string) (string, error) {
+	if pct, ok := strings.CutSuffix(line, "%"); ok {
+		n, err := strconv.Atoi(pct)
+		if err != nil || n < 0 || n > 100 {
+			return "", fmt.Errorf("invalid position %q (use 0%%-100%%)", line)
+		}
+		m.offset = m.maxOffset() * n / 100
+		return "", nil
+	}
+	if _, err := strconv.Atoi(line); err == nil {
+		return m.gotoSourceLine(line)
+	}
+
+	name, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+	if m.embedded && fileCommands[name] {
+		return "", fmt.Errorf(":%s is not available here", name)
+	}
+	switch name {
+	case "goto":
+		return m.gotoSourceLine(arg)
+	case "fold":
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 0 || n > 6 {
+			return "", fmt.Errorf("invalid fold level %q (use 0-6)", arg)
+		}
+		m.foldLevel = n
+		m.rebuildDisplay()
+		if n == 0 {
+			return "Outline: off", nil
+		}
+		return fmt.Sprintf("Outline: H%d", n), nil
+	case "set":
+		return m.setOption(arg)
+	case "w", "w!":
+		return m.writeDocument(arg, name == "w!")
+	case "e":
+		return m.openDocument(arg)
+	default:
+		return "", fmt.Errorf("unknown command %q (use goto, fold, set, w, e, q)", name)
+	}
+}
+
+// gotoSourceLine scrolls to a 1-based line of the Markdown source.
+func (m *model) gotoSourceLine(arg string) (string, error) {
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 1 {
+		return "", fmt.Errorf("invalid line %q", arg)
+	}
+	if m.diff != nil {
+		return "", errors.New("goto: source lines are not shown in a diff")
+	}
+	n = min(n, strings.Count(m.md, "\n")+1)
+	m.jumpToMarkdownLine(n - 1)
+	return fmt.Sprintf("Line %d", n), nil
+}
+
+// setOption handles ":set name=value"; without an argument it shows the
+// current settings.
+func (m *model) setOption(arg string) (string, error) {
+	if arg == "" {
+		width := "auto"
+		if m.renderOpts.Width > 0 {
+			width = strconv.Itoa(m.renderOpts.Width)
+		}
+		style := m.renderOpts.Style
+		if style == "" {
+			style = "auto"
+		}
+		return fmt.Sprintf("width=%s style=%s", width, style), nil
+	}
+	key, val, ok := strings.Cut(arg, "=")
+	if !ok {
+		return "", fmt.Errorf("use :set %s=VALUE", arg)
+	}
+	key, val = strings.TrimSpace(key), strings.ToLower(strings.TrimSpace(val))
+	switch key {
+	case "width":
+		n := 0
+		if val != "auto" {
+			var err error
+			if n, err = strconv.Atoi(val); err != nil || n < 20 {
+				return "", fmt.Errorf("invalid width %q (use auto or 20 and up)", val)
+			}
+		}
+		m.renderOpts.Width = n
+	case "style":
+		switch val {
+		case "auto", "dark", "light":
+		default:
+			return "", fmt.Errorf("invalid style %q (use auto|dark|light)", val)
+		}
+		m.renderOpts.Style = val
+		m.theme = themeFor(val)
+	default:
+		return "", fmt.Errorf("unknown option %q (use width, style)", key)
+	}
+	if m.ready {
+		m.resize(m.width, m.height)
+	}
+	return key + "=" + val, nil
+}
+
+// writeDocument exports the document; the format follows the extension
+// (.html, .json for the AST, otherwise Markdown). An existing file is only
+// replaced with :w!.
+func (m *model) writeDocument(path string, force bool) (string, error) {
+	if path == "" {
+		return "", errors.New("use :w FILE")
+	}
+	if _, err := os.Stat(path); err == nil && !force {
+		return "", fmt.Errorf("%s exists (use :w! to overwrite)", path)
+	}
+	var data []byte
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".html", ".htm":
+		page, err := export.HTML(m.source, export.HTMLOptions{Title: m.title, Style: m.renderOpts.Style})
+		if err != nil {
+			return "", err
+		}
+		data = []byte(page)
+	case ".json":
+		var err error
+		if data, err = export.ASTJSON(m.source); err != nil {
+			return "", err
+		}
+	default:
+		data = []byte(m.source)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", err
+	}
+	return "Wrote " + path, nil
+}
+
+// openDocument replaces the document with another file, keeping the
+// settings and window size.
+func (m *model) openDocument(path string) (string, error) {
+	if path == "" {
+		return "", errors.New("use :e FILE")
+	}
+	src, err := input.ResolveSource([]string{path}, nil)
+	if err != nil {
+		return "", err
+	}
+	raw, err := src.ReadAll()
+	if err != nil {
+		return "", err
+	}
+	if raw, err = input.Decode(raw, ""); err != nil {
+		return "", err
+	}
+	md := string(raw)
+	if ct := src.ContentType(); ct == input.TypeCSV || ct == input.TypeTSV {
+		md = render.CSVDocument(md, ct == input.TypeTSV)
+	}
+
+	opts := m.renderOpts
+	opts.BaseDir = src.Dir()
+	next := newModel(src.Title(), md, opts)
+	next.embedded = m.embedded
+	next.tocFuzzy = m.tocFuzzy
+	if m.ready {
+		next.resize(m.width, m.height)
+	}
+	*m = next
+	return "Opened " + path, nil
+}
+
+// completeCommand completes the word before the cursor: a command name,
+// a :set option or style, or a file path for :e and :w. With several
+// candidates it extends to their common prefix and lists them.
+func (m *model) completeCommand() {
+	name, arg, hasArg := strings.Cut(m.cmdDraft, " ")
+	var base, word string
+	var cands []string
+	switch {
+	case !hasArg:
+		word = name
+		for _, c := range commandNames {
+			if !m.embedded || !fileCommands[c] {
+				cands = append(cands, c+" ")
+			}
+		}
+	case name == "set" && strings.HasPrefix(arg, "style="):
+		base, word = "set style=", strings.TrimPrefix(arg, "style=")
+		cands = []string{"auto", "dark", "light"}
+	case name == "set":
+		base, word = "set ", arg
+		cands = []string{"style=", "width="}
+	case fileCommands[name] && !m.embedded:
+		base, word = name+" ", arg
+		cands = pathCandidates(arg)
+	default:
+		return
+	}
+
+	var matches []string
+	for _, c := range cands {
+		if strings.HasPrefix(c, word) {
+			matches = append(matches, c)
+		}
+	}
+	m.cmdCompletions = nil
+	switch len(matches) {
+	case 0:
+		return
+	case 1:
+		m.cmdDraft = base + matches[0]
+		return
+	}
+	m.cmdDraft = base + commonPrefix(matches)
+	for _, c := range matches {
+		m.cmdCompletions = append(m.cmdCompletions, strings.TrimSpace(filepath.Base(strings.TrimSuffix(c, "/"))+suffixSlash(c)))
+	}
+}
+
+// pathCandidates lists the entries of the directory part of prefix;
+// directories end in "/". Hidden entries are left out unless prefix names
+// one.
+func pathCandidates(prefix string) []string {
+	dir, file := filepath.Split(prefix)
+	readDir := dir
+	if readDir == "" {
+		readDir = "."
+	}
+	entries, err := os.ReadDir(readDir)
+	if err != nil {
+		return nil
+	}
+	var out []string
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") && !strings.HasPrefix(file, ".") {
+			continue
+		}
+		c := dir + e.Name()
+		if e.IsDir() {
+			c += "/"
+		}
+		out = append(out, c)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// commonPrefix is the longest run of whole grapheme clusters all of ss
+// start with.
+func commonPrefix(ss []string) string {
+	p := ss[0]
+	for _, s := range ss[1:] {
+		for !strings.HasPrefix(s, p) {
+			p = dropLastGrapheme(p)
+		}
+	}
+	return p
+}
+
+func suffixSlash(s string) string {
+	if strings.HasSuffix(s, "/") {
+		return "/"
+	}
+	return ""
+}
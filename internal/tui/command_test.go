@@ -0,0 +1,166 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/simota/md/internal/render"
+)
+
+func commandTestModel() model {
+	var md strings.Builder
+	md.WriteString("---\ntitle: Doc\n---\n\n")
+	for i := range 40 {
+		md.WriteString("## Section " + string(rune('A'+i%26)) + "\n\nText.\n\n")
+	}
+	m := newModel("doc", md.String(), render.Options{Style: "dark"})
+	m.resize(80, 12)
+	return m
+}
+
+func (m model) typeCommand(line string) model {
+	next, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(":")})
+	for _, r := range line {
+		next, _ = next.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	next, _ = next.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	return next.(model)
+}
+
+func TestCommand_GotoAndPercent(t *testing.T) {
+	m := commandTestModel()
+	m = m.typeCommand("goto 41")
+	if !strings.Contains(stripANSI(m.bodyView()), "Section J") {
+		t.Fatalf(":goto 41 did not show Section J:\n%s", stripANSI(m.bodyView()))
+	}
+	m = m.typeCommand("0%")
+	if m.offset != 0 {
+		t.Fatalf(":0%% offset = %d", m.offset)
+	}
+	m = m.typeCommand("100%")
+	if m.offset != m.maxOffset() {
+		t.Fatalf(":100%% offset = %d, want %d", m.offset, m.maxOffset())
+	}
+	m = m.typeCommand("goto x")
+	if m.statusMessage != `invalid line "x"` {
+		t.Fatalf("status = %q", m.statusMessage)
+	}
+}
+
+func TestCommand_SetAndFold(t *testing.T) {
+	m := commandTestModel()
+	m = m.typeCommand("set width=40 ")
+	if m.renderOpts.Width != 40 {
+		t.Fatalf("width = %d", m.renderOpts.Width)
+	}
+	m = m.typeCommand("set style=light")
+	if m.renderOpts.Style != "light" {
+		t.Fatalf("style = %q", m.renderOpts.Style)
+	}
+	m = m.typeCommand("set")
+	if m.statusMessage != "width=40 style=light" {
+		t.Fatalf("status = %q", m.statusMessage)
+	}
+	m = m.typeCommand("set style=sepia")
+	if !strings.HasPrefix(m.statusMessage, "invalid style") {
+		t.Fatalf("status = %q", m.statusMessage)
+	}
+	m = m.typeCommand("fold 2")
+	if m.foldLevel != 2 || m.display.Len() != 40 {
+		t.Fatalf("fold = %d, rows = %d", m.foldLevel, m.display.Len())
+	}
+}
+
+func TestCommand_Write(t *testing.T) {
+	dir := t.TempDir()
+	m := commandTestModel()
+	out := filepath.Join(dir, "out.html")
+	m = m.typeCommand("w " + out)
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "<h2") {
+		t.Fatalf("not HTML: %.80s", data)
+	}
+	m = m.typeCommand("w " + out)
+	if !strings.Contains(m.statusMessage, ":w!") {
+		t.Fatalf("status = %q", m.statusMessage)
+	}
+
+	md := filepath.Join(dir, "copy.md")
+	m.typeCommand("w " + md)
+	if data, _ := os.ReadFile(md); string(data) != m.source || !strings.HasPrefix(m.source, "---\n") {
+		t.Fatalf("copy.md = %.40q", data)
+	}
+}
+
+func TestCommand_Edit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "other.md")
+	if err := os.WriteFile(path, []byte("# Other\n\nBody.\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m := commandTestModel().typeCommand("e " + path)
+	if m.title != "other.md" || m.width != 80 || !strings.Contains(stripANSI(m.bodyView()), "Body.") {
+		t.Fatalf("title = %q, body:\n%s", m.title, stripANSI(m.bodyView()))
+	}
+}
+
+func TestCommand_Completion(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"notes.md", "notebook.md"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cases := []struct {
+		draft, want string
+		listed      int
+	}{
+		{"go", "goto ", 0},
+		{"set st", "set style=", 0},
+		{"set style=l", "set style=light", 0},
+		{"e " + dir + "/no", "e " + dir + "/note", 2},
+		{"e " + dir + "/notes", "e " + dir + "/notes.md", 0},
+	}
+	for _, c := range cases {
+		m := model{cmdMode: true, cmdDraft: c.draft}
+		m.completeCommand()
+		if m.cmdDraft != c.want || len(m.cmdCompletions) != c.listed {
+			t.Errorf("complete %q = %q %v, want %q", c.draft, m.cmdDraft, m.cmdCompletions, c.want)
+		}
+	}
+}
+
+func TestCommand_CompletionKeepsGraphemes(t *testing.T) {
+	if got := commonPrefix([]string{"café1.md", "cafè2.md"}); got != "caf" {
+		t.Errorf("commonPrefix = %q", got)
+	}
+	if got := commonPrefix([]string{"cafe\u03011.md", "cafe\u03002.md"}); got != "caf" {
+		t.Errorf("commonPrefix with combining marks = %q", got)
+	}
+}
+
+func TestCommand_EmbeddedHasNoFileCommands(t *testing.T) {
+	dir := t.TempDir()
+	m := commandTestModel()
+	m.embedded = true
+	out := filepath.Join(dir, "out.md")
+	m = m.typeCommand("w " + out)
+	if _, err := os.Stat(out); err == nil || !strings.Contains(m.statusMessage, "not available") {
+		t.Fatalf("status = %q, err = %v", m.statusMessage, err)
+	}
+	m = m.typeCommand("e " + out)
+	if m.title != "Doc" {
+		t.Fatalf("title = %q", m.title)
+	}
+	c := model{cmdMode: true, cmdDraft: "", embedded: true}
+	c.completeCommand()
+	if strings.Contains(strings.Join(c.cmdCompletions, " "), "w") {
+		t.Fatalf("completions = %v", c.cmdCompletions)
+	}
+}
@@ -0,0 +1,117 @@
+package tui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/simota/md/internal/outline"
+	"github.com/simota/md/internal/render"
+)
+
+// Model is the pager as a component of another Bubble Tea program. Unlike
+// ViewMarkdown it never quits the program, ignores tea.WindowSizeMsg (call
+// SetSize) and keeps images as half-block previews. Tab/Shift+Tab select
+// links and Enter sends a LinkMsg.
+type Model struct {
+	m model
+}
+
+// NewModel returns a viewer for md. It draws nothing until SetSize is called.
+// Hyperlinks are always on: link targets are read back from them.
+func NewModel(title, md string, opts render.Options) Model {
+	opts.Hyperlinks = true
+	m := newModel(title, md, opts)
+	m.embedded = true
+	return Model{m: m}
+}
+
+func (c Model) Init() tea.Cmd { return nil }
+
+func (c Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	next, cmd := c.m.Update(msg)
+	c.m = next.(model)
+	return c, cmd
+}
+
+func (c Model) View() string { return c.m.View() }
+
+// SetSize sets the width and height the viewer draws in, header and footer
+// included.
+func (c *Model) SetSize(width, height int) {
+	if width <= 0 || height <= 0 {
+		c.m.width, c.m.height, c.m.ready = width, height, false
+		return
+	}
+	c.m.resize(width, height)
+}
+
+// SetContent replaces the document, keeping the size and, as far as the new
+// document allows, the scroll position and search.
+func (c *Model) SetContent(md string) {
+	old := c.m
+	m := newModel(old.title, md, old.renderOpts)
+	m.embedded = true
+	m.offset = old.offset
+	m.foldLevel = old.foldLevel
+	m.searchQuery = old.searchQuery
+	m.searchIdx = old.searchIdx
+	if old.ready {
+		m.resize(old.width, old.height)
+	}
+	c.m = m
+}
+
+// Heading is a heading of the document.
+type Heading struct {
+	Level int
+	Text  string
+	Slug  string // GitHub-style anchor, unique within the document
+}
+
+// Headings returns the headings of the document in order.
+func (c Model) Headings() []Heading {
+	slugger := outline.NewSlugger()
+	out := make([]Heading, 0, len(c.m.headings))
+	for _, h := range c.m.headings {
+		text := strings.TrimSpace(h.Text)
+		out = append(out, Heading{Level: h.Level, Text: text, Slug: slugger.Slug(text)})
+	}
+	return out
+}
+
+// GotoHeading scrolls to the first heading whose text (case-insensitive) or
+// slug is name; a leading "#" is ignored. It reports whether one was found.
+func (c *Model) GotoHeading(name string) bool {
+	name = strings.TrimPrefix(strings.TrimSpace(name), "#")
+	want := normalizeText(name)
+	for i, h := range c.Headings() {
+		if normalizeText(h.Text) == want || h.Slug == strings.ToLower(name) {
+			c.m.jumpToMarkdownLine(c.m.headings[i].Line)
+			return true
+		}
+	}
+	return false
+}
+
+// Search highlights the lines matching query (case-insensitive unless it
+// has upper case letters), scrolls to the first match at or below the top
+// of the view and returns the number of matching lines. An empty query
+// clears the search.
+func (c *Model) Search(query string) int {
+	c.m.setSearchQuery(query)
+	return len(c.m.searchMatches)
+}
+
+// NextMatch and PrevMatch scroll to the next or previous match, wrapping.
+func (c *Model) NextMatch() { c.m.jumpNextMatch(+1) }
+func (c *Model) PrevMatch() { c.m.jumpNextMatch(-1) }
+
+// Match returns the 1-based number of the current match and the number of
+// matches; 0, 0 without a search.
+func (c Model) Match() (current, total int) {
+	return c.m.currentMatchNumber(), len(c.m.searchMatches)
+}
+
+// ScrollPercent returns how far the view is scrolled, 0-100.
+func (c Model) ScrollPercent() int { return c.m.progressPercent() }
@@ -0,0 +1,108 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	xansi "github.com/charmbracelet/x/ansi"
+
+	"github.com/simota/md/internal/render"
+)
+
+func TestFindLinks_SkipsWrappedContinuation(t *testing.T) {
+	link := func(u, text string) string { return xansi.SetHyperlink(u) + text + xansi.ResetHyperlink() }
+	lines := []string{
+		"see " + link("https://a.example", "a long") + " and " + link("https://b.example", "b"),
+		link("https://b.example", "wrapped b") + " then " + link("https://b.example", "b again"),
+		"plain",
+	}
+	got := findLinks(lines)
+	want := []linkRef{{0, "https://a.example"}, {0, "https://b.example"}, {1, "https://b.example"}}
+	if len(got) != len(want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %+v, want %+v", got, want)
+		}
+	}
+}
+
+func TestModel_EmbeddedSizeAndKeys(t *testing.T) {
+	md := "# Top\n\nSee [docs](https://example.com/docs).\n\n## Install\n\nRun it.\n"
+	c := NewModel("doc", md, render.Options{Style: "dark"})
+	if c.View() != "" {
+		t.Fatal("view before SetSize should be empty")
+	}
+
+	// Window size messages belong to the parent.
+	c, _ = c.Update(tea.WindowSizeMsg{Width: 120, Height: 50})
+	c.SetSize(40, 8)
+	rows := strings.Split(c.View(), "\n")
+	if len(rows) != 8 {
+		t.Fatalf("got %d rows, want 8", len(rows))
+	}
+	for i, r := range rows {
+		if w := xansi.StringWidth(r); w > 40 {
+			t.Fatalf("row %d is %d wide: %q", i, w, r)
+		}
+	}
+
+	if _, cmd := c.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")}); cmd != nil {
+		t.Fatal("q should not quit an embedded viewer")
+	}
+
+	c, _ = c.Update(tea.KeyMsg{Type: tea.KeyTab})
+	_, cmd := c.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatal("enter on a selected link sent nothing")
+	}
+	if msg, ok := cmd().(LinkMsg); !ok || msg.URL != "https://example.com/docs" {
+		t.Fatalf("got %#v", cmd())
+	}
+}
+
+func TestModel_GotoHeadingAndSearch(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("# Top\n\n")
+	for i := 0; i < 40; i++ {
+		b.WriteString("filler line\n\n")
+	}
+	b.WriteString("## Install Steps\n\nneedle\n")
+	c := NewModel("doc", b.String(), render.Options{Style: "dark"})
+	c.SetSize(60, 10)
+
+	if c.GotoHeading("nope") {
+		t.Fatal("found a missing heading")
+	}
+	if !c.GotoHeading("#install-steps") || !strings.Contains(xansi.Strip(c.View()), "Install Steps") {
+		t.Fatalf("heading not on screen:\n%s", c.View())
+	}
+	c.GotoHeading("top")
+	if n := c.Search("needle"); n != 1 {
+		t.Fatalf("Search = %d, want 1", n)
+	}
+	if cur, total := c.Match(); cur != 1 || total != 1 || !strings.Contains(xansi.Strip(c.View()), "needle") {
+		t.Fatalf("match %d/%d, view:\n%s", cur, total, c.View())
+	}
+	if hs := c.Headings(); len(hs) != 2 || hs[1].Slug != "install-steps" || hs[1].Level != 2 {
+		t.Fatalf("headings = %+v", hs)
+	}
+}
+
+func TestModel_ScrollsWideTablesSideways(t *testing.T) {
+	md := "```csv\n" + strings.Repeat("column,", 20) + "last\n" + strings.Repeat("1,", 20) + "END\n```\n"
+	c := NewModel("data", md, render.Options{Style: "dark"})
+	c.SetSize(60, 8)
+	if strings.Contains(xansi.Strip(c.View()), "END") {
+		t.Fatal("last column visible before scrolling")
+	}
+	for i := 0; i < 40; i++ {
+		c, _ = c.Update(tea.KeyMsg{Type: tea.KeyRight})
+	}
+	view := xansi.Strip(c.View())
+	if !strings.Contains(view, "END") || !strings.Contains(view, "col ") {
+		t.Fatalf("not scrolled to the last column:\n%s", view)
+	}
+}
@@ -0,0 +1,95 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/simota/md/internal/diff"
+	"github.com/simota/md/internal/render"
+)
+
+// ViewDiff pages through the rendered diff of two documents. Headings,
+// search and the TOC work on the new document; ]c and [c jump between
+// changes.
+func ViewDiff(title string, d diff.Diff, newMD string, opts render.Options, stdout *os.File) error {
+	m := newModel(title, newMD, opts)
+	m.diff = &d
+	return runModel(m, stdout)
+}
+
+// handleDiffKey handles the two-key change jumps. "]" and "[" still move
+// by heading right away; a following "c" undoes that and moves to the next
+// or previous change instead.
+func (m *model) handleDiffKey(msg tea.KeyMsg) (tea.Cmd, bool) {
+	key := msg.String()
+	pending := m.pendingBracket
+	m.pendingBracket = ""
+	if pending != "" && key == "c" {
+		m.offset = m.bracketOffset
+		delta := +1
+		if pending == "[" {
+			delta = -1
+		}
+		if !m.jumpChange(delta) {
+			m.statusMessage = "No more changes"
+			return m.statusTick(), true
+		}
+		return nil, true
+	}
+	if key == "]" || key == "[" {
+		m.pendingBracket = key
+		m.bracketOffset = m.offset
+	}
+	return nil, false
+}
+
+// jumpChange scrolls the next (or previous) change to the top of the view.
+// It reports false when there is none in that direction.
+func (m *model) jumpChange(delta int) bool {
+	if m.display.Len() == 0 {
+		return false
+	}
+	top := m.display.At(clamp(m.offset, 0, m.display.Len()-1))
+	target := -1
+	if delta > 0 {
+		for _, line := range m.changes {
+			if line > top {
+				target = line
+				break
+			}
+		}
+	} else {
+		for i := len(m.changes) - 1; i >= 0; i-- {
+			if m.changes[i] < top {
+				target = m.changes[i]
+				break
+			}
+		}
+	}
+	if target < 0 {
+		return false
+	}
+	m.setOffsetForRenderedLine(target)
+	return true
+}
+
+// changeCounter is shown in the header: the change at the top of the view
+// (0 above the first) and the number of changes.
+func (m model) changeCounter() string {
+	if len(m.changes) == 0 {
+		return "no changes"
+	}
+	top := 0
+	if m.display.Len() > 0 {
+		top = m.display.At(clamp(m.offset, 0, m.display.Len()-1))
+	}
+	cur := 0
+	for i, line := range m.changes {
+		if line <= top {
+			cur = i + 1
+		}
+	}
+	return fmt.Sprintf("change %d/%d", cur, len(m.changes))
+}
@@ -0,0 +1,77 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/simota/md/internal/diff"
+	"github.com/simota/md/internal/render"
+)
+
+func TestDiff_JumpsBetweenChanges(t *testing.T) {
+	var oldMD, newMD strings.Builder
+	for i := range 30 {
+		p := strings.Repeat("word ", i+1)
+		oldMD.WriteString(p + "\n\n")
+		if i == 10 || i == 25 {
+			p = "Replaced paragraph."
+		}
+		newMD.WriteString(p + "\n\n")
+	}
+	m := newModel("diff", newMD.String(), render.Options{Style: "dark"})
+	d := diff.Compare(oldMD.String(), newMD.String())
+	m.diff = &d
+	m.resize(80, 12)
+	if len(m.changes) != 2 {
+		t.Fatalf("changes = %v, want 2", m.changes)
+	}
+
+	press := func(keys ...string) {
+		for _, k := range keys {
+			next, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+			m = next.(model)
+		}
+	}
+	press("]", "c")
+	if got := m.display.At(m.offset); got != m.changes[0] {
+		t.Fatalf("]c: top line %d, want %d", got, m.changes[0])
+	}
+	press("]", "c")
+	if got := m.display.At(m.offset); got != m.changes[1] {
+		t.Fatalf("]c: top line %d, want %d", got, m.changes[1])
+	}
+	press("[", "c")
+	if got := m.display.At(m.offset); got != m.changes[0] {
+		t.Fatalf("[c: top line %d, want %d", got, m.changes[0])
+	}
+	if got := m.changeCounter(); got != "change 1/2" {
+		t.Fatalf("counter = %q", got)
+	}
+}
+
+func TestDiff_HeadingJumpsUseDiffLayout(t *testing.T) {
+	var oldMD, newMD strings.Builder
+	for i := range 20 {
+		oldMD.WriteString("Paragraph " + strings.Repeat("x", i) + ".\n\n")
+		newMD.WriteString("Paragraph " + strings.Repeat("x", i) + ".\n\n")
+		if i == 5 {
+			newMD.WriteString("An added paragraph.\n\n")
+		}
+	}
+	oldMD.WriteString("## Old Setup\n\nEnd.\n")
+	newMD.WriteString("## New Setup\n\nEnd.\n")
+	m := newModel("diff", newMD.String(), render.Options{Style: "dark"})
+	d := diff.Compare(oldMD.String(), newMD.String())
+	m.diff = &d
+	m.resize(80, 12)
+
+	if len(m.headingLocs) != 1 || !strings.Contains(m.plain[m.headingLocs[0].RenderedLine], "Old New Setup") {
+		t.Fatalf("headingLocs = %v", m.headingLocs)
+	}
+	m.jumpToMarkdownLine(m.headings[0].Line)
+	if !strings.Contains(stripANSI(m.bodyView()), "Old New Setup") {
+		t.Fatalf("jump did not reach the changed heading:\n%s", stripANSI(m.bodyView()))
+	}
+}
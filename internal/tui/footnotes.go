@@ -0,0 +1,144 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/simota/md/internal/render"
+)
+
+// computeFootnoteLines finds each footnote definition in the rendered output:
+// the numbered items that follow the last "Footnotes" title line. It returns
+// footnote number -> rendered line, and the line of the title (-1 if absent).
+func computeFootnoteLines(plain []string, notes []render.Footnote) (map[int]int, int) {
+	out := map[int]int{}
+	if len(notes) == 0 {
+		return out, -1
+	}
+	title := -1
+	for i := len(plain) - 1; i >= 0; i-- {
+		if normalizeText(plain[i]) == strings.ToLower(render.FootnotesTitle) {
+			title = i
+			break
+		}
+	}
+	if title < 0 {
+		return out, -1
+	}
+	next := 0
+	for i := title + 1; i < len(plain) && next < len(notes); i++ {
+		prefix := fmt.Sprintf("%d. ", notes[next].Number)
+		if strings.HasPrefix(strings.TrimSpace(plain[i]), prefix) {
+			out[notes[next].Number] = i
+			next++
+		}
+	}
+	return out, title
+}
+
+// visibleFootnotes lists the footnotes referenced in the viewport.
+func (m model) visibleFootnotes() []int {
+	if len(m.footnotes) == 0 || m.display.Len() == 0 {
+		return nil
+	}
+	seen := map[int]bool{}
+	var out []int
+	end := min(m.offset+m.pageSize(), m.display.Len())
+	for row := m.offset; row < end; row++ {
+		i := m.display.At(row)
+		if m.footnotesTitleLine >= 0 && i >= m.footnotesTitleLine {
+			break
+		}
+		for _, n := range m.footnoteRefs[i] {
+			if n >= 1 && n <= len(m.footnotes) && !seen[n] {
+				seen[n] = true
+				out = append(out, n)
+			}
+		}
+	}
+	return out
+}
+
+func (m *model) openFootnotes() tea.Cmd {
+	if len(m.footnotes) == 0 {
+		m.statusMessage = "No footnotes"
+		return m.statusTick()
+	}
+	m.footnoteList = m.visibleFootnotes()
+	if len(m.footnoteList) == 0 {
+		m.statusMessage = "No footnote references on screen"
+		return m.statusTick()
+	}
+	m.footnoteIdx = 0
+	m.showFootnotes = true
+	m.showHelp = false
+	m.showMeta = false
+	return nil
+}
+
+func (m *model) handleFootnoteKey(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "esc", "q", "F":
+		m.showFootnotes = false
+	case "j", "down":
+		m.footnoteIdx = clamp(m.footnoteIdx+1, 0, len(m.footnoteList)-1)
+	case "k", "up":
+		m.footnoteIdx = clamp(m.footnoteIdx-1, 0, len(m.footnoteList)-1)
+	case "enter":
+		m.showFootnotes = false
+		n := m.footnoteList[m.footnoteIdx]
+		line, ok := m.footnoteLines[n]
+		if !ok {
+			return nil
+		}
+		m.jumpBack = append(m.jumpBack, m.offset)
+		m.setOffsetForRenderedLine(line)
+		m.statusMessage = fmt.Sprintf("Footnote %d (ctrl+o to jump back)", n)
+		return m.statusTick()
+	}
+	return nil
+}
+
+// jumpBackOnce returns to the position saved by the last footnote jump.
+func (m *model) jumpBackOnce() tea.Cmd {
+	if len(m.jumpBack) == 0 {
+		m.statusMessage = "No jump to return from"
+		return m.statusTick()
+	}
+	m.offset = m.jumpBack[len(m.jumpBack)-1]
+	m.jumpBack = m.jumpBack[:len(m.jumpBack)-1]
+	return nil
+}
+
+func (m model) footnoteView() string {
+	innerW := max(20, min(m.width-12, 76))
+	lines := []string{"Footnotes on screen", ""}
+	for i, n := range m.footnoteList {
+		f := m.footnotes[n-1]
+		text := f.Marker() + " " + strings.Join(strings.Fields(f.Text), " ")
+		if i == m.footnoteIdx {
+			// The selected footnote is shown in full.
+			wrapped := lipgloss.NewStyle().Width(innerW - 2).Render(text)
+			for _, ln := range strings.Split(wrapped, "\n") {
+				lines = append(lines, m.theme.Styles.TOCItemSelected.Render(strings.TrimRight(ln, " ")))
+			}
+			continue
+		}
+		lines = append(lines, m.theme.Styles.TOCItemNormal.Render(truncateEnd(text, innerW-2)))
+	}
+	lines = append(lines, "", m.theme.Styles.TOCFooter.Render("j/k move  Enter jump  Esc close"))
+
+	box := m.theme.Styles.HelpBox.Render(strings.Join(lines, "\n"))
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		box,
+		lipgloss.WithWhitespaceBackground(m.theme.Colors.OverlayBg),
+	)
+}
@@ -0,0 +1,37 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/simota/md/internal/render"
+)
+
+func TestComputeFootnoteLines(t *testing.T) {
+	plain := []string{
+		"  Body¹ text²",
+		"",
+		"  Footnotes",
+		"",
+		"  1. First.",
+		"  2. Second.",
+	}
+	notes := []render.Footnote{{Label: "a", Number: 1}, {Label: "b", Number: 2}}
+
+	lines, title := computeFootnoteLines(plain, notes)
+	if title != 2 {
+		t.Fatalf("expected title on line 2, got %d", title)
+	}
+	if lines[1] != 4 || lines[2] != 5 {
+		t.Fatalf("unexpected footnote lines: %v", lines)
+	}
+}
+
+func TestVisibleFootnotes_IgnoresMathSuperscripts(t *testing.T) {
+	md := "Area is $x^2$, see[^a].\n\n" + strings.Repeat("Filler.\n\n", 30) + "Later[^b].\n\n[^a]: One.\n[^b]: Two.\n"
+	m := newModel("doc", md, render.Options{Style: "dark"})
+	m.resize(80, 10)
+	if got := m.visibleFootnotes(); len(got) != 1 || got[0] != 1 {
+		t.Fatalf("visible footnotes = %v, want [1]", got)
+	}
+}
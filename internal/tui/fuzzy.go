@@ -0,0 +1,156 @@
+package tui
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Fuzzy match scoring, after fzf: every matched character scores, more at
+// the start of a word, and gaps between matched characters cost.
+const (
+	scoreMatch        = 16
+	scoreGapStart     = -3
+	scoreGapExtension = -1
+
+	bonusBoundaryWhite = 10 // after a space or ">", or at the start
+	bonusBoundary      = 8  // after other punctuation
+	bonusCamel         = 7  // lower case followed by upper case
+	bonusConsecutive   = 4
+	bonusFirstCharMult = 2 // the first pattern character counts its bonus twice
+
+	scoreNone = -1 << 28
+)
+
+// fuzzyMatch matches pattern against text as fzf does: each
+// whitespace-separated term of pattern must appear in text in order, not
+// necessarily contiguously. Matching is smart-case, like search. It returns
+// the score (higher is better) and the rune indices of the matched
+// characters in norm.NFC.String(text).
+func fuzzyMatch(pattern, text string) (score int, positions []int, ok bool) {
+	pattern = norm.NFC.String(pattern)
+	terms := strings.Fields(pattern)
+	if len(terms) == 0 {
+		return 0, nil, true
+	}
+	t := []rune(norm.NFC.String(text))
+	bonus := boundaryBonuses(t)
+	sensitive := hasUpper(pattern)
+	if !sensitive {
+		t = lowerRunes(t)
+	}
+
+	seen := map[int]bool{}
+	for _, term := range terms {
+		p := []rune(term)
+		if !sensitive {
+			p = lowerRunes(p)
+		}
+		s, pos, ok := matchTerm(p, t, bonus)
+		if !ok {
+			return 0, nil, false
+		}
+		score += s
+		for _, i := range pos {
+			if !seen[i] {
+				seen[i] = true
+				positions = append(positions, i)
+			}
+		}
+	}
+	sort.Ints(positions)
+	return score, positions, true
+}
+
+// matchTerm finds the best-scoring alignment of p in t.
+// best[i][j] is the best score of p[:i+1] with p[i] matched at t[j], and
+// from[i][j] where p[i-1] was matched on that path.
+func matchTerm(p, t []rune, bonus []int) (int, []int, bool) {
+	if len(p) > len(t) {
+		return 0, nil, false
+	}
+	best := make([][]int, len(p))
+	from := make([][]int, len(p))
+	for i := range p {
+		best[i] = make([]int, len(t))
+		from[i] = make([]int, len(t))
+		// gap is the best score for p[:i] ending before t[j-1], less the
+		// gap up to j.
+		gap, gapFrom := scoreNone, -1
+		for j := range t {
+			if i > 0 && j >= 2 {
+				gap += scoreGapExtension
+				if c := best[i-1][j-2] + scoreGapStart; c > gap {
+					gap, gapFrom = c, j-2
+				}
+			}
+			best[i][j] = scoreNone
+			if p[i] != t[j] {
+				continue
+			}
+			sc := scoreMatch + bonus[j]
+			if i == 0 {
+				best[i][j] = sc + bonus[j]*(bonusFirstCharMult-1)
+				from[i][j] = -1
+				continue
+			}
+			prev, prevFrom := gap, gapFrom
+			if j >= 1 && best[i-1][j-1] > scoreNone/2 {
+				if c := best[i-1][j-1] + bonusConsecutive; c >= prev {
+					prev, prevFrom = c, j-1
+				}
+			}
+			if prev <= scoreNone/2 {
+				continue
+			}
+			best[i][j] = prev + sc
+			from[i][j] = prevFrom
+		}
+	}
+
+	last := len(p) - 1
+	end := -1
+	for j := range t {
+		if best[last][j] > scoreNone/2 && (end < 0 || best[last][j] > best[last][end]) {
+			end = j
+		}
+	}
+	if end < 0 {
+		return 0, nil, false
+	}
+	positions := make([]int, len(p))
+	for i, j := last, end; i >= 0; i-- {
+		positions[i] = j
+		j = from[i][j]
+	}
+	return best[last][end], positions, true
+}
+
+func boundaryBonuses(t []rune) []int {
+	bonus := make([]int, len(t))
+	prev := ' '
+	for j, r := range t {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			switch {
+			case unicode.IsSpace(prev) || prev == '>':
+				bonus[j] = bonusBoundaryWhite
+			case !unicode.IsLetter(prev) && !unicode.IsDigit(prev):
+				bonus[j] = bonusBoundary
+			case unicode.IsLower(prev) && unicode.IsUpper(r):
+				bonus[j] = bonusCamel
+			}
+		}
+		prev = r
+	}
+	return bonus
+}
+
+func lowerRunes(rs []rune) []rune {
+	out := make([]rune, len(rs))
+	for i, r := range rs {
+		out[i] = unicode.ToLower(r)
+	}
+	return out
+}
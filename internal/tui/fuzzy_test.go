@@ -0,0 +1,88 @@
+package tui
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	xansi "github.com/charmbracelet/x/ansi"
+
+	"github.com/simota/md/internal/render"
+)
+
+func TestFuzzyMatch_PrefersWordStarts(t *testing.T) {
+	_, pos, ok := fuzzyMatch("tok", "API > Auth > Tokens")
+	if !ok || !reflect.DeepEqual(pos, []int{13, 14, 15}) {
+		t.Fatalf("got %v, %v", pos, ok)
+	}
+	_, pos, ok = fuzzyMatch("aat", "API > Auth > Tokens")
+	if !ok || !reflect.DeepEqual(pos, []int{0, 6, 13}) {
+		t.Fatalf("got %v, %v", pos, ok)
+	}
+}
+
+func TestFuzzyMatch_TermsSmartCaseAndCJK(t *testing.T) {
+	cases := []struct {
+		pattern, text string
+		want          bool
+	}{
+		{"auth tok", "API > Auth > Tokens", true},
+		{"tok auth", "API > Auth > Tokens", true},
+		{"Auth", "api > auth", false},
+		{"auth", "API > AUTH", true},
+		{"xyz", "API > Auth", false},
+		{"設メ", "設計メモ", true},
+		{"", "anything", true},
+	}
+	for _, c := range cases {
+		if _, _, ok := fuzzyMatch(c.pattern, c.text); ok != c.want {
+			t.Errorf("fuzzyMatch(%q, %q) = %v, want %v", c.pattern, c.text, ok, c.want)
+		}
+	}
+}
+
+func TestFuzzyMatch_Ranking(t *testing.T) {
+	a, _, _ := fuzzyMatch("tok", "API > Auth > Tokens")
+	b, _, _ := fuzzyMatch("tok", "Setup > Network stack > Overview")
+	if a <= b {
+		t.Fatalf("contiguous word start scored %d, scattered match %d", a, b)
+	}
+}
+
+func TestHeadingPaths(t *testing.T) {
+	hs := parseHeadings("# API\n## Auth\n### Tokens\n## Errors\n# FAQ\n")
+	want := []string{"API", "API > Auth", "API > Auth > Tokens", "API > Errors", "FAQ"}
+	if got := headingPaths(hs); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestPalette_JumpsToBestMatch(t *testing.T) {
+	var md strings.Builder
+	md.WriteString("# API\n\n## Auth\n\n### Tokens\n\n")
+	md.WriteString(strings.Repeat("text\n\n", 40))
+	md.WriteString("## Errors\n\nbody\n")
+	m := newModel("doc", md.String(), render.Options{Style: "dark"})
+	m.resize(80, 12)
+
+	send := func(msg tea.KeyMsg) {
+		next, _ := m.Update(msg)
+		m = next.(model)
+	}
+	send(tea.KeyMsg{Type: tea.KeyCtrlP})
+	if !m.showPalette {
+		t.Fatal("ctrl+p should open the palette")
+	}
+	send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("err")})
+	if view := xansi.Strip(m.View()); !strings.Contains(view, "API > Errors") {
+		t.Fatalf("palette does not list the match:\n%s", view)
+	}
+	send(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.showPalette {
+		t.Fatal("enter should close the palette")
+	}
+	if body := xansi.Strip(m.bodyView()); !strings.Contains(body, "Errors") {
+		t.Fatalf("did not jump to Errors:\n%s", body)
+	}
+}
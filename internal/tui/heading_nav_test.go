@@ -1,6 +1,15 @@
 package tui
 
-import "testing"
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/lipgloss"
+	xansi "github.com/charmbracelet/x/ansi"
+
+	"github.com/simota/md/internal/render"
+)
 
 func TestComputeHeadingLocsFromRendered_InOrder(t *testing.T) {
 	plain := []string{
@@ -60,3 +69,22 @@ func TestBreadcrumbForIndex_ParentChain(t *testing.T) {
 		t.Fatalf("unexpected chain: %+v", chain)
 	}
 }
+
+func TestHeaderView_CJKTitleAndBreadcrumb(t *testing.T) {
+	md := "# 設計ドキュメント\n\n## 認証とセッション管理\n\n本文です。\n"
+	m := newModel("設計メモ_2024年度版.md", md, render.Options{Style: "dark"})
+	for _, w := range []int{24, 31, 40, 80} {
+		m.resize(w, 10)
+		m.offset = m.maxOffset()
+		header := m.headerView()
+		if got := lipgloss.Width(header); got != w {
+			t.Fatalf("width %d: header is %d wide: %q", w, got, xansi.Strip(header))
+		}
+		if plain := xansi.Strip(header); !utf8.ValidString(plain) || strings.ContainsRune(plain, utf8.RuneError) {
+			t.Fatalf("width %d: header has broken characters: %q", w, plain)
+		}
+	}
+	if plain := xansi.Strip(m.headerView()); !strings.Contains(plain, "設計メモ_2024年度版.md  ›  設計ドキュメント") {
+		t.Fatalf("breadcrumb missing: %q", plain)
+	}
+}
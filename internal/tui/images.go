@@ -0,0 +1,40 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/simota/md/internal/render"
+)
+
+// clearImages removes kitty placements, which live outside the text layer and
+// would otherwise survive repaints. Sixel and iTerm2 images are overwritten
+// by the text drawn over them.
+func (m model) clearImages() string {
+	if m.embedded || m.renderOpts.Images != render.ImagesKitty || len(m.images) == 0 {
+		return ""
+	}
+	return render.KittyClearImages
+}
+
+// imageOverlay draws the images that are fully inside the viewport. It is
+// appended to the footer: the cursor is saved, moved up to each image's top
+// row and restored afterwards. Partly visible images keep their half-block
+// preview. Embedded viewers do not know where they are on the screen, so
+// they keep the previews.
+func (m model) imageOverlay() string {
+	if m.embedded || len(m.images) == 0 || m.foldLevel > 0 || m.height <= 2 {
+		return ""
+	}
+	var b strings.Builder
+	for _, im := range m.images {
+		if im.Seq == "" || im.Line < m.offset || im.Line+im.Rows > m.offset+m.pageSize() {
+			continue
+		}
+		screenRow := 1 + im.Line - m.offset // below the header
+		up := (m.height - 1) - screenRow
+		col := len(leftGutterPad()) + im.Col + 1
+		fmt.Fprintf(&b, "\x1b7\x1b[%dA\x1b[%dG%s\x1b8", up, col, im.Seq)
+	}
+	return b.String()
+}
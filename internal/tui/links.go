@@ -0,0 +1,118 @@
+package tui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// LinkMsg is sent by an embedded viewer when the selected link is activated
+// with Enter. URL is the link target; relative links are file:// URLs.
+type LinkMsg struct {
+	URL string
+}
+
+type linkRef struct {
+	line int // rendered line
+	url  string
+}
+
+// findLinks reads link targets back from the OSC 8 sequences in the rendered
+// lines. A link that wraps is reopened on the next line; that continuation
+// is not counted again.
+func findLinks(lines []string) []linkRef {
+	var out []linkRef
+	for i, ln := range lines {
+		first := true
+		for {
+			j := strings.Index(ln, "\x1b]8;")
+			if j < 0 {
+				break
+			}
+			ln = ln[j+len("\x1b]8;"):]
+			end := strings.IndexAny(ln, "\x07\x1b")
+			if end < 0 {
+				break
+			}
+			seq := ln[:end]
+			ln = ln[end:]
+			_, uri, ok := strings.Cut(seq, ";") // params;uri
+			if !ok || uri == "" {
+				continue
+			}
+			cont := first && len(out) > 0 && out[len(out)-1].url == uri && out[len(out)-1].line == i-1
+			first = false
+			if !cont {
+				out = append(out, linkRef{line: i, url: uri})
+			}
+		}
+	}
+	return out
+}
+
+// handleLinkKey moves the link selection (Tab / Shift+Tab) and activates the
+// selected link (Enter). ok reports whether the key was a link key.
+func (m *model) handleLinkKey(msg tea.KeyMsg) (tea.Cmd, bool) {
+	switch msg.String() {
+	case "tab":
+		m.selectLink(+1)
+	case "shift+tab":
+		m.selectLink(-1)
+	case "enter":
+		if m.linkIdx < 0 || m.linkIdx >= len(m.links) {
+			return nil, true
+		}
+		url := m.links[m.linkIdx].url
+		return func() tea.Msg { return LinkMsg{URL: url} }, true
+	default:
+		return nil, false
+	}
+	return nil, true
+}
+
+// selectLink selects the next (or previous) link, starting from the top of
+// the viewport when the current selection is off screen.
+func (m *model) selectLink(delta int) {
+	if len(m.links) == 0 {
+		m.linkIdx = -1
+		return
+	}
+	top := m.display.At(clamp(m.offset, 0, max(0, m.display.Len()-1)))
+	bottom := m.display.At(clamp(m.offset+m.pageSize()-1, 0, max(0, m.display.Len()-1)))
+	visible := m.linkIdx >= 0 && m.linkIdx < len(m.links) &&
+		m.links[m.linkIdx].line >= top && m.links[m.linkIdx].line <= bottom
+
+	switch {
+	case visible:
+		m.linkIdx = (m.linkIdx + delta + len(m.links)) % len(m.links)
+	case delta > 0:
+		m.linkIdx = 0
+		for i, l := range m.links {
+			if l.line >= top {
+				m.linkIdx = i
+				break
+			}
+		}
+	default:
+		m.linkIdx = len(m.links) - 1
+		for i := len(m.links) - 1; i >= 0; i-- {
+			if m.links[i].line <= bottom {
+				m.linkIdx = i
+				break
+			}
+		}
+	}
+
+	line := m.links[m.linkIdx].line
+	if line < top || line > bottom {
+		m.setOffsetForRenderedLine(line)
+	}
+}
+
+// selectedLinkLine returns the rendered line of the selected link, or -1.
+func (m model) selectedLinkLine() int {
+	if m.linkIdx < 0 || m.linkIdx >= len(m.links) {
+		return -1
+	}
+	return m.links[m.linkIdx].line
+}
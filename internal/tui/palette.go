@@ -0,0 +1,181 @@
+package tui
+
+import (
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/rivo/uniseg"
+	"golang.org/x/text/unicode/norm"
+)
+
+// paletteItem is a heading offered by the jump palette.
+type paletteItem struct {
+	heading   heading
+	path      string // "API > Auth > Tokens", NFC
+	positions []int  // matched runes of path
+	score     int
+}
+
+// headingPaths returns each heading's breadcrumb path: its parents and
+// itself, joined with " > ".
+func headingPaths(hs []heading) []string {
+	out := make([]string, len(hs))
+	var stack []heading
+	for i, h := range hs {
+		for len(stack) > 0 && stack[len(stack)-1].Level >= h.Level {
+			stack = stack[:len(stack)-1]
+		}
+		stack = append(stack, h)
+		parts := make([]string, len(stack))
+		for k, s := range stack {
+			parts[k] = strings.TrimSpace(s.Text)
+		}
+		out[i] = strings.Join(parts, " > ")
+	}
+	return out
+}
+
+// paletteItems returns the headings whose path fuzzy-matches the query,
+// best first; ties keep document order. An empty query lists them all.
+func (m model) paletteItems() []paletteItem {
+	var out []paletteItem
+	for i, path := range headingPaths(m.headings) {
+		score, pos, ok := fuzzyMatch(m.paletteQuery, path)
+		if !ok {
+			continue
+		}
+		out = append(out, paletteItem{heading: m.headings[i], path: norm.NFC.String(path), positions: pos, score: score})
+	}
+	sort.SliceStable(out, func(a, b int) bool { return out[a].score > out[b].score })
+	return out
+}
+
+func (m *model) openPalette() {
+	m.showPalette = true
+	m.paletteQuery = ""
+	m.paletteIdx = 0
+	m.showHelp, m.showMeta, m.showStats, m.showTOC = false, false, false, false
+}
+
+func (m *model) handlePaletteKey(msg tea.KeyMsg) {
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		m.showPalette = false
+		return
+	case "enter":
+		items := m.paletteItems()
+		if m.paletteIdx >= 0 && m.paletteIdx < len(items) {
+			m.jumpToMarkdownLine(items[m.paletteIdx].heading.Line)
+		}
+		m.showPalette = false
+		return
+	case "up", "ctrl+p", "ctrl+k":
+		m.paletteIdx--
+	case "down", "ctrl+n", "ctrl+j", "tab":
+		m.paletteIdx++
+	default:
+		if editPrompt(&m.paletteQuery, msg) {
+			m.paletteIdx = 0
+		}
+	}
+	m.paletteIdx = clamp(m.paletteIdx, 0, max(0, len(m.paletteItems())-1))
+}
+
+func (m model) paletteView() string {
+	items := m.paletteItems()
+	titleBar := m.theme.Styles.TOCTitle.Render("Jump to heading")
+	filterBar := m.theme.Styles.TOCFilter.Render(truncateEnd("> "+m.paletteQuery, max(10, m.width-10)))
+	footer := m.theme.Styles.TOCFooter.Render("type to match  ↑/↓ move  Enter jump  Esc close")
+
+	innerW := max(20, min(m.width-8, 76))
+	innerH := max(3, min(m.height-7, 19))
+
+	var lines []string
+	switch {
+	case len(m.headings) == 0:
+		lines = []string{"  (no headings found)"}
+	case len(items) == 0:
+		lines = []string{"  (no matches)"}
+	}
+	start := clamp(m.paletteIdx-innerH/2, 0, max(0, len(items)-innerH))
+	for i := start; i < min(len(items), start+innerH); i++ {
+		lines = append(lines, m.paletteLine(items[i], innerW-2, i == m.paletteIdx))
+	}
+
+	box := m.theme.Styles.TOCBox.Render(titleBar + "\n" + filterBar + "\n" + strings.Join(lines, "\n") + "\n" + footer)
+	return m.placeOverlay(box)
+}
+
+// paletteLine renders an item's path with its matched characters
+// highlighted. Whole grapheme clusters are highlighted, so a combining mark
+// stays with its letter.
+func (m model) paletteLine(it paletteItem, width int, selected bool) string {
+	base := m.theme.Styles.TOCItemNormal
+	if selected {
+		base = m.theme.Styles.TOCItemSelected
+	}
+	hit := base.Bold(true).Underline(true)
+	if !selected {
+		hit = hit.Foreground(m.theme.Colors.Accent)
+	}
+	base, hit = base.UnsetPadding(), hit.UnsetPadding()
+
+	matched := map[int]bool{}
+	for _, p := range it.positions {
+		matched[p] = true
+	}
+	text := truncateEnd(it.path, width)
+
+	var b strings.Builder
+	b.WriteString(base.Render(" "))
+	var run strings.Builder
+	runHit := false
+	flush := func() {
+		if run.Len() == 0 {
+			return
+		}
+		if runHit {
+			b.WriteString(hit.Render(run.String()))
+		} else {
+			b.WriteString(base.Render(run.String()))
+		}
+		run.Reset()
+	}
+	idx, state := 0, -1
+	for rest := text; rest != ""; {
+		var cluster string
+		cluster, rest, _, state = uniseg.FirstGraphemeClusterInString(rest, state)
+		n := len([]rune(cluster))
+		isHit := false
+		for k := idx; k < idx+n; k++ {
+			isHit = isHit || matched[k]
+		}
+		idx += n
+		if isHit != runHit {
+			flush()
+			runHit = isHit
+		}
+		run.WriteString(cluster)
+	}
+	flush()
+	b.WriteString(base.Render(" "))
+	return b.String()
+}
+
+// placeOverlay centers a modal box over the dimmed screen.
+func (m model) placeOverlay(box string) string {
+	maxW := min(m.width-4, 80)
+	maxH := min(m.height-2, 24)
+	box = lipgloss.NewStyle().MaxWidth(maxW).MaxHeight(maxH).Render(box)
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		box,
+		lipgloss.WithWhitespaceBackground(m.theme.Colors.OverlayBg),
+	)
+}
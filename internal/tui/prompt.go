@@ -0,0 +1,22 @@
+package tui
+
+import tea "github.com/charmbracelet/bubbletea"
+
+// editPrompt applies a line-editing key to a one-line prompt (search, TOC
+// filter, palette, command line): Backspace deletes the last character,
+// Ctrl+U clears and typed text is appended. It reports whether the key
+// changed or could have changed the text.
+func editPrompt(s *string, msg tea.KeyMsg) bool {
+	switch msg.String() {
+	case "backspace", "ctrl+h":
+		*s = dropLastGrapheme(*s)
+	case "ctrl+u":
+		*s = ""
+	default:
+		if len(msg.Runes) == 0 {
+			return false
+		}
+		*s += string(msg.Runes)
+	}
+	return true
+}
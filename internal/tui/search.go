@@ -2,9 +2,12 @@ package tui
 
 import (
 	"strings"
+	"unicode"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/rivo/uniseg"
+	"golang.org/x/text/unicode/norm"
 )
 
 func (m *model) handleSearchKey(msg tea.KeyMsg) {
@@ -18,22 +21,9 @@ func (m *model) handleSearchKey(msg tea.KeyMsg) {
 		m.searchMode = false
 		m.setSearchQuery(m.searchDraft)
 		return
-	case "backspace", "ctrl+h":
-		if m.searchDraft == "" {
-			return
-		}
-		m.searchDraft = dropLastRune(m.searchDraft)
-		m.setSearchQueryNoJump(m.searchDraft)
-		return
-	case "ctrl+u":
-		m.searchDraft = ""
-		m.setSearchQueryNoJump(m.searchDraft)
-		return
 	}
 
-	// Best-effort: append printable runes.
-	if len(msg.Runes) > 0 {
-		m.searchDraft += string(msg.Runes)
+	if editPrompt(&m.searchDraft, msg) {
 		m.setSearchQueryNoJump(m.searchDraft)
 	}
 }
@@ -128,7 +118,7 @@ func (m model) currentMatchNumber() int {
 func (m model) markerGutter(lineIdx int) string {
 	// 3 columns: " <marker> "
 	// marker is:
-	// - '>' current match
+	// - '>' current match or selected link
 	// - '*' other match
 	// - '§' heading
 	// - ' ' none
@@ -136,6 +126,9 @@ func (m model) markerGutter(lineIdx int) string {
 	if m.isHeadingRenderedLine(lineIdx) {
 		marker = '§'
 	}
+	if lineIdx == m.selectedLinkLine() {
+		marker = '>'
+	}
 	if m.searchQuery != "" {
 		if lineIdx == m.searchCurrentLine {
 			marker = '>'
@@ -160,6 +153,9 @@ func (m model) markerGutter(lineIdx int) string {
 
 func leftGutterPad() string { return "   " }
 
+// searchMatcher is smart-case: case-insensitive unless the query has an
+// upper case letter. Query and text are compared in NFC, so "é" typed as one
+// code point matches "e" followed by a combining accent.
 type searchMatcher struct {
 	query     string
 	lower     string
@@ -167,15 +163,16 @@ type searchMatcher struct {
 }
 
 func newSearchMatcher(q string) searchMatcher {
-	s := searchMatcher{query: q}
-	s.sensitive = hasUpper(q)
+	s := searchMatcher{query: norm.NFC.String(q)}
+	s.sensitive = hasUpper(s.query)
 	if !s.sensitive {
-		s.lower = strings.ToLower(q)
+		s.lower = strings.ToLower(s.query)
 	}
 	return s
 }
 
 func (m searchMatcher) Contains(line string) bool {
+	line = norm.NFC.String(line)
 	if m.sensitive {
 		return strings.Contains(line, m.query)
 	}
@@ -184,7 +181,7 @@ func (m searchMatcher) Contains(line string) bool {
 
 func hasUpper(s string) bool {
 	for _, r := range s {
-		if r >= 'A' && r <= 'Z' {
+		if unicode.IsUpper(r) || unicode.IsTitle(r) {
 			return true
 		}
 	}
@@ -192,7 +189,8 @@ func hasUpper(s string) bool {
 }
 
 func stripANSI(s string) string {
-	// Minimal ANSI stripper for search indexing. Removes CSI sequences.
+	// Minimal ANSI stripper for search indexing. Removes CSI sequences and
+	// string sequences (OSC hyperlinks, DCS/APC graphics).
 	// Example: "\x1b[31mred\x1b[0m" -> "red".
 	var b strings.Builder
 	b.Grow(len(s))
@@ -207,31 +205,45 @@ func stripANSI(s string) string {
 		if i+1 >= len(s) {
 			break
 		}
-		if s[i+1] != '[' {
-			// Not CSI; skip ESC only.
-			continue
-		}
-		// Skip CSI until final byte in 0x40-0x7E range.
-		i += 2
-		for i < len(s) {
-			c := s[i]
-			if c >= 0x40 && c <= 0x7e {
-				break
+		switch s[i+1] {
+		case '[':
+			// Skip CSI until final byte in 0x40-0x7E range.
+			i += 2
+			for i < len(s) {
+				c := s[i]
+				if c >= 0x40 && c <= 0x7e {
+					break
+				}
+				i++
 			}
-			i++
+		case ']', 'P', '_', '^', 'X':
+			// Skip OSC/DCS/APC/PM/SOS until BEL or ST (ESC \).
+			i += 2
+			for i < len(s) {
+				if s[i] == 0x07 {
+					break
+				}
+				if s[i] == 0x1b && i+1 < len(s) && s[i+1] == '\\' {
+					i++
+					break
+				}
+				i++
+			}
+		default:
+			// Not CSI; skip ESC only.
 		}
 	}
 
 	return b.String()
 }
 
-func dropLastRune(s string) string {
-	if s == "" {
-		return ""
-	}
-	r := []rune(s)
-	if len(r) <= 1 {
-		return ""
+// dropLastGrapheme removes the last user-perceived character: a whole emoji
+// sequence, or a letter together with its combining marks.
+func dropLastGrapheme(s string) string {
+	last, state := 0, -1
+	for rest := s; rest != ""; {
+		last = len(s) - len(rest)
+		_, rest, _, state = uniseg.FirstGraphemeClusterInString(rest, state)
 	}
-	return string(r[:len(r)-1])
+	return s[:last]
 }
@@ -0,0 +1,73 @@
+package tui
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestStripANSI_HandlesOSCAndCSI(t *testing.T) {
+	in := "\x1b[1msee \x1b]8;;https://example.com\x07docs\x1b]8;;\x07 and \x1b]8;;file:///a\x1b\\b\x1b]8;;\x1b\\\x1b[0m"
+	if got := stripANSI(in); got != "see docs and b" {
+		t.Fatalf("unexpected: %q", got)
+	}
+}
+
+func TestTruncateEnd_WideAndGraphemes(t *testing.T) {
+	cases := []struct {
+		in    string
+		width int
+		want  string
+	}{
+		{"設計ドキュメント.md", 20, "設計ドキュメント.md"},
+		{"設計ドキュメント.md", 11, "設計ドキ..."},
+		{"設計ドキュメント.md", 9, "設計ド..."},
+		{"👩‍💻 dev notes", 6, "👩‍💻 ..."},
+		{"Cafe\u0301 menu", 7, "Cafe\u0301..."},
+		{"abc", 2, ".."},
+	}
+	for _, c := range cases {
+		got := truncateEnd(c.in, c.width)
+		if got != c.want {
+			t.Errorf("truncateEnd(%q, %d) = %q, want %q", c.in, c.width, got, c.want)
+		}
+		if w := lipgloss.Width(got); w > c.width {
+			t.Errorf("truncateEnd(%q, %d) is %d wide", c.in, c.width, w)
+		}
+	}
+}
+
+func TestDropLastGrapheme(t *testing.T) {
+	cases := map[string]string{
+		"":           "",
+		"a":          "",
+		"設計":         "設",
+		"dev 👩‍💻":    "dev ",
+		"flag 🇯🇵":    "flag ",
+		"cafe\u0301": "caf",
+		"ok 👍🏽":      "ok ",
+	}
+	for in, want := range cases {
+		if got := dropLastGrapheme(in); got != want {
+			t.Errorf("dropLastGrapheme(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSearchMatcher_SmartCaseUnicode(t *testing.T) {
+	cases := []struct {
+		query, line string
+		want        bool
+	}{
+		{"äpfel", "Äpfel und Birnen", true},
+		{"Äpfel", "äpfel und birnen", false},
+		{"Ωmega", "ωmega", false},
+		{"caf\u00e9", "Cafe\u0301 au lait", true},
+		{"設計", "## 設計メモ", true},
+	}
+	for _, c := range cases {
+		if got := newSearchMatcher(c.query).Contains(c.line); got != c.want {
+			t.Errorf("%q in %q = %v, want %v", c.query, c.line, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,307 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	xansi "github.com/charmbracelet/x/ansi"
+
+	"github.com/simota/md/internal/mdblock"
+	"github.com/simota/md/internal/render"
+)
+
+// Ways to split a document into slides.
+const (
+	SplitRule = "hr" // thematic breaks (---, ***, ___)
+	SplitH1   = "h1" // every H1
+	SplitH2   = "h2" // every H1 or H2
+)
+
+const maxSlideWidth = 100
+
+type slide struct {
+	md    string
+	title string
+	notes []string // from HTML comments, removed from md
+
+	lines []string // rendered, trimmed to content width
+	width int      // widest rendered line
+}
+
+var (
+	// Three or more of one character; "- * -" is not a break.
+	thematicBreakRe = regexp.MustCompile(`^ {0,3}(?:(?:-[ \t]*){3,}|(?:\*[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	htmlCommentRe   = regexp.MustCompile(`(?s)<!--(.*?)-->`)
+)
+
+// splitSlides cuts md into slides. With SplitRule a "---" line only counts
+// after a blank line, so setext heading underlines are left alone.
+func splitSlides(md, split string) []slide {
+	lines := strings.Split(strings.ReplaceAll(md, "\r\n", "\n"), "\n")
+
+	level := 0
+	switch split {
+	case SplitH1:
+		level = 1
+	case SplitH2:
+		level = 2
+	}
+	breaks := map[int]bool{} // line starts a new slide
+	sep := map[int]bool{}    // line is a separator and dropped
+	if level > 0 {
+		for _, h := range parseHeadings(md) {
+			if h.Level <= level {
+				breaks[h.Line] = true
+			}
+		}
+	} else {
+		var fence mdblock.FenceTracker
+		for i, ln := range lines {
+			if fence.Update(ln) || !thematicBreakRe.MatchString(ln) {
+				continue
+			}
+			if i == 0 || strings.TrimSpace(lines[i-1]) == "" {
+				sep[i] = true
+				breaks[i+1] = true
+			}
+		}
+	}
+
+	var (
+		out []slide
+		cur []string
+	)
+	flush := func() {
+		s := newSlide(strings.Join(cur, "\n"))
+		if strings.TrimSpace(s.md) != "" || len(s.notes) > 0 {
+			out = append(out, s)
+		}
+		cur = nil
+	}
+	for i, ln := range lines {
+		if breaks[i] {
+			flush()
+		}
+		if !sep[i] {
+			cur = append(cur, ln)
+		}
+	}
+	flush()
+	if len(out) == 0 {
+		out = append(out, slide{})
+	}
+	return out
+}
+
+// newSlide moves HTML comments out of md into speaker notes. A leading
+// "notes:" in a comment is dropped.
+func newSlide(md string) slide {
+	var s slide
+	md = outsideFences(md, func(text string) string {
+		return htmlCommentRe.ReplaceAllStringFunc(text, func(c string) string {
+			note := strings.TrimSpace(htmlCommentRe.FindStringSubmatch(c)[1])
+			if lower := strings.ToLower(note); strings.HasPrefix(lower, "notes:") {
+				note = strings.TrimSpace(note[len("notes:"):])
+			}
+			if note != "" {
+				s.notes = append(s.notes, note)
+			}
+			return ""
+		})
+	})
+	s.md = strings.Trim(md, "\n")
+	if hs := parseHeadings(s.md); len(hs) > 0 {
+		s.title = strings.TrimSpace(hs[0].Text)
+	}
+	return s
+}
+
+// outsideFences applies f to each run of lines outside fenced code blocks
+// and keeps the blocks, fence lines included, as they are.
+func outsideFences(md string, f func(string) string) string {
+	var (
+		out, run []string
+		fence    mdblock.FenceTracker
+	)
+	flush := func() {
+		if run != nil {
+			out = append(out, f(strings.Join(run, "\n")))
+			run = nil
+		}
+	}
+	for _, ln := range strings.Split(md, "\n") {
+		if fence.Update(ln) {
+			flush()
+			out = append(out, ln)
+			continue
+		}
+		run = append(run, ln)
+	}
+	flush()
+	return strings.Join(out, "\n")
+}
+
+// ViewSlides shows md one slide at a time.
+func ViewSlides(title string, md string, split string, opts render.Options, stdout *os.File) error {
+	m := newModel(title, md, opts)
+	m.slideMode = true
+	m.slides = splitSlides(m.md, split)
+	return runModel(m, stdout)
+}
+
+func (m *model) renderSlides() {
+	width := m.renderOpts.Width
+	if width <= 0 {
+		width = min(maxSlideWidth, max(20, m.width-4))
+	}
+	opts := m.renderOpts
+	opts.Width = width
+	opts.WideTables = false // slides do not scroll sideways
+	for i := range m.slides {
+		s := &m.slides[i]
+		out, _, err := render.RenderWithImages(s.md, opts)
+		if err != nil {
+			out = "(render error)\n" + err.Error()
+		}
+		s.lines, s.width = trimRendered(splitLines(out))
+	}
+	m.offset = 0
+}
+
+// trimRendered drops blank lines around the slide and glamour's padding on
+// the right, so the content can be centered. It returns the lines and the
+// width of the widest one.
+func trimRendered(lines []string) ([]string, int) {
+	for len(lines) > 0 && strings.TrimSpace(stripANSI(lines[0])) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(stripANSI(lines[len(lines)-1])) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	width := 0
+	for _, ln := range lines {
+		width = max(width, lipgloss.Width(strings.TrimRight(stripANSI(ln), " ")))
+	}
+	out := make([]string, len(lines))
+	for i, ln := range lines {
+		out[i] = xansi.Truncate(ln, width, "")
+	}
+	return out, width
+}
+
+func (m *model) handleSlideKey(msg tea.KeyMsg) tea.Cmd {
+	prev := m.slideIdx
+	switch msg.String() {
+	case "q", "esc", "ctrl+c":
+		return tea.Quit
+	case "?":
+		m.showHelp = !m.showHelp
+		return nil
+	}
+	if m.showHelp {
+		return nil
+	}
+	switch msg.String() {
+	case "n", "right", "l", " ", "enter", "pgdown":
+		m.slideIdx++
+	case "p", "left", "h", "backspace", "pgup":
+		m.slideIdx--
+	case "home", "g":
+		m.slideIdx = 0
+	case "end", "G":
+		m.slideIdx = len(m.slides) - 1
+	case "j", "down":
+		m.offset++
+	case "k", "up":
+		m.offset--
+	case "s":
+		m.showNotes = !m.showNotes
+	}
+	m.slideIdx = clamp(m.slideIdx, 0, len(m.slides)-1)
+	if m.slideIdx != prev {
+		m.offset = 0
+	}
+	m.offset = clamp(m.offset, 0, m.maxSlideOffset())
+	return nil
+}
+
+// slideArea returns the rows available for the slide and for the notes
+// panel (0 when hidden).
+func (m model) slideArea() (content, notes int) {
+	content = max(1, m.height-2)
+	if m.showNotes {
+		notes = min(content/3, max(3, len(m.notesLines())+1))
+		content = max(1, content-notes)
+	}
+	return content, notes
+}
+
+func (m model) maxSlideOffset() int {
+	if len(m.slides) == 0 {
+		return 0
+	}
+	content, _ := m.slideArea()
+	return max(0, len(m.slides[m.slideIdx].lines)-content)
+}
+
+func (m model) notesLines() []string {
+	s := m.slides[m.slideIdx]
+	if len(s.notes) == 0 {
+		return []string{"(no speaker notes)"}
+	}
+	wrap := lipgloss.NewStyle().Width(max(10, m.width-4))
+	return strings.Split(wrap.Render(strings.Join(s.notes, "\n\n")), "\n")
+}
+
+// slideBodyView centers the current slide in the body area; slides taller
+// than the screen scroll with j/k instead.
+func (m model) slideBodyView() string {
+	content, notesH := m.slideArea()
+	s := m.slides[m.slideIdx]
+
+	lines := s.lines
+	top := 0
+	if len(lines) > content {
+		start := clamp(m.offset, 0, m.maxSlideOffset())
+		lines = lines[start : start+content]
+	} else {
+		top = (content - len(lines)) / 2
+	}
+	pad := strings.Repeat(" ", max(0, (m.width-s.width)/2))
+
+	rows := make([]string, 0, content+notesH)
+	for i := 0; i < top; i++ {
+		rows = append(rows, "")
+	}
+	for _, ln := range lines {
+		rows = append(rows, pad+ln)
+	}
+	for len(rows) < content {
+		rows = append(rows, "")
+	}
+
+	if notesH > 0 {
+		rule := m.theme.Styles.Footer.Render(truncateEnd("── notes "+strings.Repeat("─", m.width), m.width))
+		rows = append(rows, rule)
+		notes := m.notesLines()
+		for i := 0; i < notesH-1; i++ {
+			ln := ""
+			if i < len(notes) {
+				ln = "  " + notes[i]
+			}
+			rows = append(rows, ln)
+		}
+	}
+	for i, r := range rows {
+		rows[i] = padOrTruncateANSI(r, m.width)
+	}
+	return strings.Join(rows, "\n")
+}
+
+func (m model) slideCounter() string {
+	return fmt.Sprintf("slide %d/%d", m.slideIdx+1, len(m.slides))
+}
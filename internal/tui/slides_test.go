@@ -0,0 +1,90 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/simota/md/internal/render"
+)
+
+func TestSplitSlides_ThematicBreaks(t *testing.T) {
+	md := "# One\n\n<!-- notes: say hi -->\n\n---\n\nSetext\n---\n\n```\n---\n```\n\n***\n\n# Three\n"
+	got := splitSlides(md, SplitRule)
+	if len(got) != 3 {
+		t.Fatalf("got %d slides: %+v", len(got), got)
+	}
+	if got[0].title != "One" || len(got[0].notes) != 1 || got[0].notes[0] != "say hi" {
+		t.Fatalf("slide 1 = %+v", got[0])
+	}
+	if strings.Contains(got[0].md, "<!--") {
+		t.Fatalf("notes left in slide: %q", got[0].md)
+	}
+	if want := "Setext\n---\n\n```\n---\n```"; got[1].md != want {
+		t.Fatalf("slide 2 = %q, want %q", got[1].md, want)
+	}
+	if got[2].title != "Three" {
+		t.Fatalf("slide 3 = %+v", got[2])
+	}
+}
+
+func TestNewSlide_KeepsCommentsInFences(t *testing.T) {
+	md := "# HTML\n\n```html\n<!-- a comment -->\n```\n\n~~~\n<!--\n```\n-->\n~~~\n\n<!-- notes: real -->\n"
+	s := newSlide(md)
+	if len(s.notes) != 1 || s.notes[0] != "real" {
+		t.Fatalf("notes = %q", s.notes)
+	}
+	if want := "# HTML\n\n```html\n<!-- a comment -->\n```\n\n~~~\n<!--\n```\n-->\n~~~"; s.md != want {
+		t.Fatalf("md = %q, want %q", s.md, want)
+	}
+}
+
+func TestSplitSlides_MixedMarkersAreNotBreaks(t *testing.T) {
+	md := "One\n\n- * -\n\n* - *\n\n_ _ -\n\nStill one\n\n* * *\n\nTwo\n"
+	got := splitSlides(md, SplitRule)
+	if len(got) != 2 || !strings.Contains(got[0].md, "Still one") {
+		t.Fatalf("got %d slides: %+v", len(got), got)
+	}
+}
+
+func TestSplitSlides_Headings(t *testing.T) {
+	md := "# Deck\n\n## A\n\ntext\n\n### A.1\n\n## B\n"
+	var titles []string
+	for _, s := range splitSlides(md, SplitH2) {
+		titles = append(titles, s.title)
+	}
+	if got := strings.Join(titles, ","); got != "Deck,A,B" {
+		t.Fatalf("titles = %s", got)
+	}
+}
+
+func TestSlideView_CentersAndCounts(t *testing.T) {
+	m := newModel("deck", "# Hello\n\n---\n\n# Bye\n", render.Options{Style: "dark"})
+	m.slideMode = true
+	m.slides = splitSlides(m.md, SplitRule)
+
+	next, _ := m.Update(tea.WindowSizeMsg{Width: 80, Height: 20})
+	m = next.(model)
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	m = next.(model)
+
+	view := m.View()
+	if !strings.Contains(view, "slide 2/2") {
+		t.Fatalf("missing counter in:\n%s", view)
+	}
+	rows := strings.Split(stripANSI(view), "\n")
+	found := -1
+	for i := 1; i < len(rows)-1; i++ { // skip header and footer
+		r := rows[i]
+		if strings.Contains(r, "Bye") {
+			found = i
+			if lead := len(r) - len(strings.TrimLeft(r, " ")); lead < 20 {
+				t.Fatalf("slide not centered horizontally: %q", r)
+			}
+		}
+	}
+	if found < 5 || found > 14 {
+		t.Fatalf("slide not centered vertically (row %d):\n%s", found, stripANSI(view))
+	}
+}
@@ -0,0 +1,118 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/simota/md/internal/stats"
+)
+
+// wordsFrom returns, for each Markdown line, the number of words from that
+// line to the end of the document (plus a trailing 0). Words are counted
+// from the source like stats.Compute, so code and HTML do not count.
+func wordsFrom(md string) []int {
+	byLine := stats.WordsByLine(md)
+	out := make([]int, len(byLine)+1)
+	for i := len(byLine) - 1; i >= 0; i-- {
+		out[i] = out[i+1] + byLine[i]
+	}
+	return out
+}
+
+// minutesLeft estimates the reading time from the top of the viewport to
+// the end of the document; 0 when nothing is left below.
+func (m model) minutesLeft() int {
+	if m.diff != nil || len(m.wordsLeft) == 0 || m.display.Len() == 0 {
+		return 0
+	}
+	top := m.display.At(clamp(m.offset, 0, m.display.Len()-1))
+	line := clamp(m.sourceLineAt(top), 0, len(m.wordsLeft)-1)
+	return stats.ReadingMinutes(m.wordsLeft[line])
+}
+
+// sourceLineAt estimates the Markdown line shown at a rendered line. Only
+// headings are placed exactly, so lines in between are interpolated from
+// the headings around them (or the start and end of the document).
+func (m model) sourceLineAt(rendered int) int {
+	lo, loMD := 0, 0
+	hi, hiMD := len(m.lines), len(m.wordsLeft)-1
+	for _, loc := range m.headingLocs {
+		if loc.RenderedLine > rendered {
+			hi, hiMD = loc.RenderedLine, loc.Heading.Line
+			break
+		}
+		lo, loMD = loc.RenderedLine, loc.Heading.Line
+	}
+	if hi <= lo {
+		return loMD
+	}
+	return loMD + (rendered-lo)*(hiMD-loMD)/(hi-lo)
+}
+
+// currentSection returns the heading the viewport is in and the markdown of
+// its section, down to the next heading of the same or a higher level.
+func (m model) currentSection() (heading, string, bool) {
+	mdLine, ok := m.currentHeadingMDLine()
+	if !ok {
+		return heading{}, "", false
+	}
+	idx := -1
+	for i, h := range m.headings {
+		if h.Line == mdLine {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return heading{}, "", false
+	}
+	h := m.headings[idx]
+	lines := strings.Split(m.md, "\n")
+	end := len(lines)
+	for _, next := range m.headings[idx+1:] {
+		if next.Level <= h.Level {
+			end = next.Line
+			break
+		}
+	}
+	return h, strings.Join(lines[h.Line:min(end, len(lines))], "\n"), true
+}
+
+func (m model) statsView() string {
+	rows := m.stats.Rows()
+	if h, section, ok := m.currentSection(); ok {
+		s := stats.Compute(section)
+		rows = append(rows, [2]string{"", ""}, [2]string{
+			"This section",
+			fmt.Sprintf("%d words, ~%d min", s.Words, max(1, s.Minutes)),
+		})
+		rows = append(rows, [2]string{"", truncateEnd(strings.TrimSpace(h.Text), 40)})
+	}
+
+	keyW := 0
+	for _, r := range rows {
+		keyW = max(keyW, lipgloss.Width(r[0]))
+	}
+	lines := []string{"Document stats", ""}
+	for _, r := range rows {
+		if r[0] == "" && r[1] == "" {
+			lines = append(lines, "")
+			continue
+		}
+		key := r[0] + strings.Repeat(" ", keyW-lipgloss.Width(r[0]))
+		lines = append(lines, "  "+key+"  "+truncateEnd(r[1], max(10, m.width-keyW-12)))
+	}
+
+	box := m.theme.Styles.HelpBox.Render(strings.Join(lines, "\n"))
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		box,
+		lipgloss.WithWhitespaceBackground(m.theme.Colors.OverlayBg),
+	)
+}
@@ -0,0 +1,67 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/simota/md/internal/render"
+	"github.com/simota/md/internal/stats"
+)
+
+func TestWordsFrom_CountsProseOnly(t *testing.T) {
+	md := "intro words\n\n# One two\n\nthree four\n\n```go\nfunc main() { println(1) }\n```\n"
+	got := wordsFrom(md)
+	want := []int{6, 4, 4, 2, 2, 0, 0, 0, 0, 0, 0}
+	if len(got) != len(want) {
+		t.Fatalf("wordsFrom = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("wordsFrom = %v, want %v", got, want)
+		}
+	}
+	if got[0] != stats.Compute(md).Words {
+		t.Fatalf("wordsFrom = %v, document has %d words", got, stats.Compute(md).Words)
+	}
+}
+
+func TestMinutesLeft_FollowsViewportWithoutHeadings(t *testing.T) {
+	var md strings.Builder
+	for range 100 {
+		md.WriteString(strings.Repeat("word ", 20) + "\n\n")
+	}
+	m := newModel("doc", md.String(), render.Options{Style: "dark", Width: 60})
+	m.resize(80, 12)
+	if got := m.minutesLeft(); got != 10 {
+		t.Fatalf("at the top: %d min left, want 10", got)
+	}
+	m.offset = m.maxOffset() / 2
+	if got := m.minutesLeft(); got < 4 || got > 6 {
+		t.Fatalf("halfway: %d min left, want about 5", got)
+	}
+	m.offset = m.maxOffset()
+	if got := m.minutesLeft(); got > 1 {
+		t.Fatalf("at the end: %d min left", got)
+	}
+}
+
+func TestCurrentSection_EndsAtSameLevel(t *testing.T) {
+	md := "# A\n\n## B\n\nbody\n\n### C\n\n## D\n"
+	m := model{md: md, headings: parseHeadings(md)}
+	m.headingLocs = []headingLoc{
+		{Heading: m.headings[0], RenderedLine: 0},
+		{Heading: m.headings[1], RenderedLine: 2},
+		{Heading: m.headings[2], RenderedLine: 6},
+		{Heading: m.headings[3], RenderedLine: 8},
+	}
+	m.display = newIdentityDisplayIndex(10)
+	m.offset = 2
+
+	h, section, ok := m.currentSection()
+	if !ok || h.Text != "B" {
+		t.Fatalf("heading = %+v, ok = %v", h, ok)
+	}
+	if want := "## B\n\nbody\n\n### C\n"; section != want {
+		t.Fatalf("section = %q, want %q", section, want)
+	}
+}
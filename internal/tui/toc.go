@@ -5,74 +5,14 @@ import (
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
 
+	"github.com/simota/md/internal/outline"
 	"github.com/simota/md/internal/render"
 )
 
-type heading struct {
-	Level int
-	Text  string
-	Line  int // 0-based line index in raw markdown
-}
-
-func parseHeadings(md string) []heading {
-	lines := strings.Split(strings.ReplaceAll(md, "\r\n", "\n"), "\n")
-
-	var hs []heading
-	inFence := false
-	var fence string
-
-	for i, ln := range lines {
-		t := strings.TrimSpace(ln)
-
-		if isFenceLine(t) {
-			// Toggle on ``` or ~~~. Keep fence type to avoid accidental toggles.
-			if !inFence {
-				inFence = true
-				fence = t[:3]
-			} else if fence != "" && strings.HasPrefix(t, fence) {
-				inFence = false
-				fence = ""
-			}
-			continue
-		}
-		if inFence {
-			continue
-		}
-
-		if !strings.HasPrefix(t, "#") {
-			continue
-		}
-		n := countPrefix(t, '#')
-		if n < 1 || n > 6 {
-			continue
-		}
-		rest := strings.TrimSpace(t[n:])
-		if rest == "" {
-			continue
-		}
-		hs = append(hs, heading{
-			Level: n,
-			Text:  rest,
-			Line:  i,
-		})
-	}
+type heading = outline.Heading
 
-	return hs
-}
-
-func isFenceLine(t string) bool {
-	return strings.HasPrefix(t, "```") || strings.HasPrefix(t, "~~~")
-}
-
-func countPrefix(s string, ch byte) int {
-	n := 0
-	for i := 0; i < len(s) && s[i] == ch; i++ {
-		n++
-	}
-	return n
-}
+func parseHeadings(md string) []heading { return outline.Parse(md) }
 
 func (m *model) handleTOCKey(msg tea.KeyMsg) {
 	if m.tocFilterMode {
@@ -84,6 +24,8 @@ func (m *model) handleTOCKey(msg tea.KeyMsg) {
 	case "esc", "q", "t":
 		m.showTOC = false
 		return
+	case "ctrl+f":
+		m.tocFuzzy = !m.tocFuzzy
 	case "/":
 		m.tocFilterMode = true
 		m.tocFilterDraft = m.tocFilter
@@ -116,17 +58,10 @@ func (m *model) handleTOCFilterKey(msg tea.KeyMsg) {
 		m.tocFilter = strings.TrimSpace(m.tocFilterDraft)
 		m.tocIdx = 0
 		return
-	case "backspace", "ctrl+h":
-		if m.tocFilterDraft == "" {
-			return
-		}
-		m.tocFilterDraft = dropLastRune(m.tocFilterDraft)
-	case "ctrl+u":
-		m.tocFilterDraft = ""
+	case "ctrl+f":
+		m.tocFuzzy = !m.tocFuzzy
 	default:
-		if len(msg.Runes) > 0 {
-			m.tocFilterDraft += string(msg.Runes)
-		}
+		editPrompt(&m.tocFilterDraft, msg)
 	}
 	// Keep selection stable-ish as the filter changes.
 	m.tocIdx = clamp(m.tocIdx, 0, max(0, len(m.tocFilteredHeadings())-1))
@@ -137,24 +72,35 @@ func (m *model) jumpToHeading() {
 	if len(hs) == 0 || m.tocIdx < 0 || m.tocIdx >= len(hs) {
 		return
 	}
-	h := hs[m.tocIdx]
+	m.jumpToMarkdownLine(hs[m.tocIdx].Line)
+}
 
+// jumpToMarkdownLine scrolls the heading on raw markdown line to the top.
+func (m *model) jumpToMarkdownLine(line int) {
+	if m.diff != nil {
+		// Rendering a prefix of the new document says nothing about where
+		// the line is in the diff; only headings are placed.
+		if off, ok := m.headingByMDLine[line]; ok {
+			m.setOffsetForRenderedLine(off)
+		}
+		return
+	}
 	// Prefer already-computed heading mapping from current render, if available.
 	if m.headingLocsWidth == m.currentRenderWidth() {
-		if off, ok := m.headingByMDLine[h.Line]; ok {
+		if off, ok := m.headingByMDLine[line]; ok {
 			m.setOffsetForRenderedLine(off)
 			return
 		}
 	}
 
-	off, ok := m.tocOffsetCache[h.Line]
+	off, ok := m.tocOffsetCache[line]
 	if !ok || m.tocOffsetCacheWidth != m.currentRenderWidth() {
 		m.tocOffsetCacheWidth = m.currentRenderWidth()
 		if m.tocOffsetCache == nil {
 			m.tocOffsetCache = map[int]int{}
 		}
-		off = m.computeOffsetForMarkdownLine(h.Line)
-		m.tocOffsetCache[h.Line] = off
+		off = m.computeOffsetForMarkdownLine(line)
+		m.tocOffsetCache[line] = off
 	}
 	m.setOffsetForRenderedLine(off)
 }
@@ -177,10 +123,11 @@ func (m model) computeOffsetForMarkdownLine(line int) int {
 	}
 	prefix := strings.Join(raw[:line+1], "\n") + "\n"
 
-	out, err := render.RenderMarkdown(prefix, render.Options{
-		Style: m.renderOpts.Style,
-		Width: m.currentRenderWidth(),
-	})
+	// Same options as the document, so blocks rendered by fence commands
+	// take as many lines here as they do there.
+	opts := m.renderOpts
+	opts.Width = m.currentRenderWidth()
+	out, err := render.RenderMarkdown(prefix, opts)
 	if err != nil {
 		return 0
 	}
@@ -203,13 +150,16 @@ func (m model) tocView() string {
 	} else if strings.TrimSpace(m.tocFilter) != "" {
 		filterText = fmt.Sprintf("/%s (%d/%d)", m.tocFilter, len(hs), len(m.headings))
 	}
+	if m.tocFuzzy {
+		filterText += "  [fuzzy]"
+	}
 	filterBar := m.theme.Styles.TOCFilter.Render(truncateEnd(filterText, max(10, m.width-10)))
 
 	header := titleBar + "\n" + filterBar
 
-	help := "j/k move  Enter jump  / filter  Esc close"
+	help := "j/k move  Enter jump  / filter  ^F fuzzy  Esc close"
 	if m.tocFilterMode {
-		help = "type to filter  Enter apply  Esc cancel"
+		help = "type to filter  ^F fuzzy  Enter apply  Esc cancel"
 	}
 	footer := m.theme.Styles.TOCFooter.Render(help)
 
@@ -217,19 +167,7 @@ func (m model) tocView() string {
 	body := strings.Join(bodyLines, "\n")
 
 	box := m.theme.Styles.TOCBox.Render(header + "\n" + body + "\n" + footer)
-
-	maxW := min(m.width-4, 80)
-	maxH := min(m.height-2, 24)
-	box = lipgloss.NewStyle().MaxWidth(maxW).MaxHeight(maxH).Render(box)
-
-	return lipgloss.Place(
-		m.width,
-		m.height,
-		lipgloss.Center,
-		lipgloss.Center,
-		box,
-		lipgloss.WithWhitespaceBackground(m.theme.Colors.OverlayBg),
-	)
+	return m.placeOverlay(box)
 }
 
 func (m model) tocBodyLines() []string {
@@ -284,8 +222,17 @@ func (m model) tocFilteredHeadings() []heading {
 		return m.headings
 	}
 
-	matcher := newSearchMatcher(active)
 	var out []heading
+	if m.tocFuzzy {
+		// Fuzzy filtering keeps document order; the palette ranks.
+		for i, path := range headingPaths(m.headings) {
+			if _, _, ok := fuzzyMatch(active, path); ok {
+				out = append(out, m.headings[i])
+			}
+		}
+		return out
+	}
+	matcher := newSearchMatcher(active)
 	for _, h := range m.headings {
 		if matcher.Contains(h.Text) {
 			out = append(out, h)
@@ -8,20 +8,28 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	xansi "github.com/charmbracelet/x/ansi"
 
+	"github.com/simota/md/internal/diff"
 	"github.com/simota/md/internal/render"
+	"github.com/simota/md/internal/stats"
 )
 
 type model struct {
 	title string
 
 	md         string
+	source     string // md as given, front matter included (for :w)
+	meta       render.FrontMatter
 	renderOpts render.Options
 	theme      Theme
 
-	lines  []string
-	plain  []string
-	offset int // display row offset (top of viewport)
+	lines   []string
+	plain   []string
+	images  []render.Image // drawn over their half-block previews when fully visible
+	offset  int            // display row offset (top of viewport)
+	xOffset int            // columns scrolled to the right (wide tables)
+	widest  int            // widest rendered line
 
 	foldLevel int // 0 = no fold (full), 1..6 = outline up to that heading level
 	display   displayIndex
@@ -30,8 +38,11 @@ type model struct {
 	height int
 	ready  bool
 
-	showHelp bool
-	showTOC  bool
+	showHelp      bool
+	showTOC       bool
+	showMeta      bool
+	showFootnotes bool
+	showStats     bool
 
 	headings         []heading
 	headingSet       map[string]int
@@ -43,6 +54,15 @@ type model struct {
 	tocFilterMode    bool
 	tocFilterDraft   string
 	tocFilter        string
+	tocFuzzy         bool // filter with fuzzyMatch on heading paths
+
+	showPalette  bool // Ctrl+P heading jump
+	paletteQuery string
+	paletteIdx   int
+
+	cmdMode        bool // ":" command line
+	cmdDraft       string
+	cmdCompletions []string // candidates listed after an ambiguous Tab
 
 	// Cache of raw markdown line -> rendered offset for current render width.
 	tocOffsetCache      map[int]int
@@ -57,6 +77,35 @@ type model struct {
 	searchSet         map[int]bool
 	searchCurrentLine int
 
+	footnotes          []render.Footnote
+	footnoteRefs       map[int][]int // rendered line -> footnotes referenced on it
+	footnoteLines      map[int]int   // footnote number -> rendered line
+	footnotesTitleLine int           // rendered line of the footnotes section, -1 if none
+	footnoteList       []int         // footnote numbers listed in the popup
+	footnoteIdx        int
+	jumpBack           []int // offsets to return to with ctrl+o
+
+	stats     stats.Stats
+	wordsLeft []int // markdown line -> words from there to the end
+
+	slideMode bool
+	slides    []slide
+	slideIdx  int
+	showNotes bool
+
+	// embedded is set for Model: the viewer is part of another program's
+	// screen, so it never quits, is sized by its parent and draws no images.
+	embedded bool
+	links    []linkRef // hyperlinks in the rendered lines, in order
+	linkIdx  int       // selected link, -1 if none
+
+	// diff is set by ViewDiff: the pager shows it instead of md.
+	diff           *diff.Diff
+	changes        []int       // rendered line where each change starts
+	diffHeadings   map[int]int // new document's heading line -> rendered line
+	pendingBracket string      // "]" or "[" waiting for a "c"
+	bracketOffset  int         // offset before that key
+
 	statusMessage string
 
 	lastErr error
@@ -65,9 +114,26 @@ type model struct {
 type clearStatusMsg struct{}
 
 func ViewMarkdown(title string, md string, opts render.Options, stdout *os.File) error {
+	return runModel(newModel(title, md, opts), stdout)
+}
+
+func newModel(title string, md string, opts render.Options) model {
+	source := md
+	// Front matter is stripped (line numbers preserved) and shown in its own panel.
+	meta, body, _ := render.SplitFrontMatter(md)
+	if t := meta.Title(); t != "" {
+		title = t
+	}
+	md = body
+
+	// The pager scrolls sideways, so CSV tables keep their columns.
+	opts.WideTables = true
+
 	m := model{
 		title:           title,
 		md:              md,
+		source:          source,
+		meta:            meta,
 		renderOpts:      opts,
 		theme:           themeFor(opts.Style),
 		headings:        parseHeadings(md),
@@ -75,12 +141,19 @@ func ViewMarkdown(title string, md string, opts render.Options, stdout *os.File)
 		headingLineSet:  map[int]bool{},
 		headingByMDLine: map[int]int{},
 		searchSet:       map[int]bool{},
+		footnotes:       render.ParseFootnotes(md),
+		stats:           stats.Compute(md),
+		linkIdx:         -1,
 	}
 	for _, h := range m.headings {
 		m.headingSet[normalizeText(h.Text)] = h.Level
 	}
+	m.wordsLeft = wordsFrom(md)
 	m.display = newIdentityDisplayIndex(0)
+	return m
+}
 
+func runModel(m model, stdout *os.File) error {
 	p := tea.NewProgram(
 		m,
 		tea.WithOutput(stdout),
@@ -95,6 +168,16 @@ func (m model) Init() tea.Cmd { return nil }
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.slideMode {
+			return m, m.handleSlideKey(msg)
+		}
+
+		if m.cmdMode {
+			cmd := m.handleCommandKey(msg)
+			m.offset = clamp(m.offset, 0, m.maxOffset())
+			return m, cmd
+		}
+
 		if m.searchMode {
 			m.handleSearchKey(msg)
 			m.offset = clamp(m.offset, 0, m.maxOffset())
@@ -107,15 +190,50 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 
+		if m.showPalette {
+			m.handlePaletteKey(msg)
+			m.offset = clamp(m.offset, 0, m.maxOffset())
+			return m, nil
+		}
+
+		if m.showFootnotes {
+			cmd := m.handleFootnoteKey(msg)
+			m.offset = clamp(m.offset, 0, m.maxOffset())
+			return m, cmd
+		}
+
 		switch msg.String() {
 		case "q", "esc", "ctrl+c":
+			if m.embedded {
+				// Quitting is the parent's call; Esc closes an overlay.
+				m.showHelp, m.showMeta, m.showStats = false, false, false
+				return m, nil
+			}
 			return m, tea.Quit
 		case "?":
 			m.showHelp = !m.showHelp
+			m.showMeta = false
+			m.showStats = false
+			return m, nil
+		case "m":
+			if m.meta.Empty() {
+				m.statusMessage = "No front matter"
+				return m, m.statusTick()
+			}
+			m.showMeta = !m.showMeta
+			m.showHelp = false
+			m.showStats = false
+			return m, nil
+		case "s":
+			m.showStats = !m.showStats
+			m.showHelp = false
+			m.showMeta = false
 			return m, nil
 		case "t":
 			m.showTOC = !m.showTOC
 			m.showHelp = false
+			m.showMeta = false
+			m.showStats = false
 			if m.showTOC {
 				m.tocIdx = clamp(m.tocIdx, 0, max(0, len(m.headings)-1))
 				m.syncTOCToCurrentHeading()
@@ -123,6 +241,12 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.tocFilterDraft = m.tocFilter
 			}
 			return m, nil
+		case "ctrl+p":
+			m.openPalette()
+			return m, nil
+		case ":":
+			m.openCommandLine()
+			return m, nil
 		case "/":
 			m.searchMode = true
 			m.searchSavedQuery = m.searchQuery
@@ -130,14 +254,29 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.setSearchQueryNoJump(m.searchDraft)
 			m.showHelp = false
 			m.showTOC = false
+			m.showMeta = false
+			m.showStats = false
 			return m, nil
 		}
 
-		if m.showHelp {
-			// While help is open, only allow closing with "?" or quit keys above.
+		if m.showHelp || m.showMeta || m.showStats {
+			// While an overlay is open, only allow closing it or quit keys above.
 			return m, nil
 		}
 
+		if m.embedded {
+			if cmd, ok := m.handleLinkKey(msg); ok {
+				return m, cmd
+			}
+		}
+
+		if m.diff != nil {
+			if cmd, ok := m.handleDiffKey(msg); ok {
+				m.offset = clamp(m.offset, 0, m.maxOffset())
+				return m, cmd
+			}
+		}
+
 		switch msg.String() {
 		case "0":
 			m.foldLevel = 0
@@ -148,6 +287,10 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.offset++
 		case "k", "up":
 			m.offset--
+		case "l", "right":
+			m.xOffset = clamp(m.xOffset+8, 0, m.maxXOffset())
+		case "h", "left":
+			m.xOffset = clamp(m.xOffset-8, 0, m.maxXOffset())
 		case "1", "2", "3", "4", "5", "6":
 			m.foldLevel = int(msg.String()[0] - '0')
 			m.rebuildDisplay()
@@ -176,11 +319,17 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "c":
 			// Clear search.
 			m.setSearchQuery("")
+		case "F":
+			return m, m.openFootnotes()
+		case "ctrl+o":
+			cmd := m.jumpBackOnce()
+			m.offset = clamp(m.offset, 0, m.maxOffset())
+			return m, cmd
 		}
 	case tea.MouseMsg:
 		// Keep mouse handling minimal and reliable:
 		// wheel up/down scrolls content.
-		if m.showHelp {
+		if m.showHelp || m.showMeta || m.showStats || m.showFootnotes || m.showPalette {
 			return m, nil
 		}
 		switch msg.Type {
@@ -189,13 +338,19 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case tea.MouseWheelDown:
 			m.offset += 3
 		}
+		if m.slideMode {
+			m.offset = clamp(m.offset, 0, m.maxSlideOffset())
+			return m, nil
+		}
 	case tea.WindowSizeMsg:
-		m.width = msg.Width
-		m.height = msg.Height
-		m.ready = true
-		m.tocOffsetCache = map[int]int{}
-		m.tocOffsetCacheWidth = 0
-		m.reRender()
+		if m.embedded {
+			// The parent sizes an embedded viewer with SetSize.
+			return m, nil
+		}
+		m.resize(msg.Width, msg.Height)
+		if m.slideMode {
+			return m, nil
+		}
 	case clearStatusMsg:
 		m.statusMessage = ""
 	}
@@ -204,16 +359,43 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+func (m *model) resize(width, height int) {
+	m.width = width
+	m.height = height
+	m.ready = true
+	m.tocOffsetCache = map[int]int{}
+	m.tocOffsetCacheWidth = 0
+	if m.slideMode {
+		m.renderSlides()
+		return
+	}
+	m.reRender()
+}
+
 func (m *model) reRender() {
 	renderWidth := m.renderOpts.Width
 	if renderWidth <= 0 {
 		renderWidth = m.bodyTextWidth()
 	}
 
-	out, err := render.RenderMarkdown(m.md, render.Options{
-		Style: m.renderOpts.Style,
-		Width: renderWidth,
-	})
+	opts := m.renderOpts
+	opts.Width = renderWidth
+	var (
+		out    string
+		images []render.Image
+		refs   map[int][]int
+		err    error
+	)
+	if m.diff != nil {
+		var layout diff.Layout
+		out, layout, err = m.diff.Render(opts)
+		m.changes, m.diffHeadings = layout.Changes, layout.Headings
+	} else {
+		var r render.Rendered
+		r, err = render.Render(m.md, opts)
+		out, images, refs = r.Text, r.Images, r.FootnoteRefs
+	}
+	m.images, m.footnoteRefs = images, refs
 	if err != nil {
 		m.lastErr = err
 		m.lines = []string{"(render error)", err.Error()}
@@ -221,6 +403,8 @@ func (m *model) reRender() {
 		m.headingLocs = nil
 		m.headingLineSet = map[int]bool{}
 		m.headingByMDLine = map[int]int{}
+		m.footnoteLines, m.footnotesTitleLine = map[int]int{}, -1
+		m.links, m.linkIdx = nil, -1
 		return
 	}
 	m.lastErr = nil
@@ -230,7 +414,17 @@ func (m *model) reRender() {
 		m.plain = append(m.plain, stripANSI(ln))
 	}
 
+	m.widest = 0
+	for _, ln := range m.plain {
+		m.widest = max(m.widest, lipgloss.Width(ln))
+	}
+	m.xOffset = clamp(m.xOffset, 0, m.maxXOffset())
+	m.links = findLinks(m.lines)
+	if m.linkIdx >= len(m.links) {
+		m.linkIdx = -1
+	}
 	m.refreshHeadingLocs(renderWidth)
+	m.footnoteLines, m.footnotesTitleLine = computeFootnoteLines(m.plain, m.footnotes)
 	m.rebuildDisplay()
 
 	// Refresh search matches after rerender (e.g. resize changes wrapping).
@@ -245,16 +439,37 @@ func (m model) View() string {
 	}
 
 	if m.showHelp {
-		return m.helpView()
+		return m.clearImages() + m.helpView()
+	}
+
+	if m.showMeta {
+		return m.clearImages() + m.metaView()
+	}
+
+	if m.showStats {
+		return m.clearImages() + m.statsView()
+	}
+
+	if m.showFootnotes {
+		return m.clearImages() + m.footnoteView()
 	}
 
 	if m.showTOC {
-		return m.tocView()
+		return m.clearImages() + m.tocView()
+	}
+
+	if m.showPalette {
+		return m.clearImages() + m.paletteView()
 	}
 
 	header := m.headerView()
-	body := m.bodyView()
 	footer := m.footerView()
+	var body string
+	if m.slideMode {
+		body = m.slideBodyView()
+	} else {
+		body = m.bodyView()
+	}
 
 	// If terminal is too short, fall back gracefully.
 	if m.height <= 2 {
@@ -274,12 +489,24 @@ func (m model) headerView() string {
 	if m.foldLevel > 0 {
 		rightText = fmt.Sprintf("%3d%%  H%d", pct, m.foldLevel)
 	}
+	if mins := m.minutesLeft(); mins > 0 {
+		rightText = fmt.Sprintf("~%d min left  %s", mins, rightText)
+	}
+	if m.diff != nil {
+		rightText = fmt.Sprintf("%s  %3d%%", m.changeCounter(), pct)
+	}
+	if m.slideMode {
+		rightText = m.slideCounter()
+	}
 	right := m.theme.Styles.HeaderRight.Render(rightText)
 
 	label := title
-	if bc := m.currentBreadcrumb(); bc != "" {
+	if bc := m.currentBreadcrumb(); bc != "" && !m.slideMode {
 		label = title + "  \u203a  " + bc
 	}
+	if m.slideMode && m.slides[m.slideIdx].title != "" {
+		label = title + "  \u203a  " + m.slides[m.slideIdx].title
+	}
 
 	leftStyle := m.theme.Styles.HeaderLeft
 
@@ -300,10 +527,27 @@ func (m model) footerView() string {
 		startOL, endOL, totalOL := m.visibleOutlineRange()
 		meta = fmt.Sprintf("doc %d-%d/%d | ol %d-%d/%d", startDoc, endDoc, totalDoc, startOL, endOL, totalOL)
 	}
+	if m.xOffset > 0 {
+		meta = fmt.Sprintf("col %d | %s", m.xOffset+1, meta)
+	}
 
 	help := "q quit  ? help  / search  t toc  [ ] section  1-6 fold 0 all"
+	if m.slideMode {
+		meta = m.slideCounter()
+		help = "q quit  n/p slide  s notes  ? help"
+	}
+
+	if m.embedded {
+		help = "? help  / search  t toc  tab link  [ ] section"
+	}
+	if m.diff != nil {
+		help = "q quit  ? help  / search  ]c [c change  [ ] section"
+	}
 
 	leftText := help
+	if m.selectedLinkLine() >= 0 {
+		leftText = "link: " + m.links[m.linkIdx].url + "  (Enter open)"
+	}
 	if m.statusMessage != "" && !m.searchMode && !m.showTOC {
 		leftText = m.statusMessage
 	}
@@ -321,6 +565,12 @@ func (m model) footerView() string {
 			len(m.searchMatches),
 		)
 	}
+	if m.cmdMode {
+		leftText = ":" + m.cmdDraft
+		if len(m.cmdCompletions) > 0 {
+			leftText += "   " + strings.Join(m.cmdCompletions, "  ")
+		}
+	}
 
 	left := m.theme.Styles.Footer.Render(truncateEnd(leftText, max(10, m.width-20)))
 
@@ -329,7 +579,12 @@ func (m model) footerView() string {
 	space := max(0, m.width-lipgloss.Width(left)-lipgloss.Width(right))
 	mid := strings.Repeat(" ", space)
 
-	return left + mid + right
+	if m.slideMode {
+		return left + mid + right
+	}
+	// The footer changes whenever the viewport moves, so it is repainted
+	// exactly when images need redrawing.
+	return m.clearImages() + left + mid + right + m.imageOverlay()
 }
 
 func (m model) bodyView() string {
@@ -346,7 +601,11 @@ func (m model) bodyView() string {
 	for row := start; row < end; row++ {
 		i := m.display.At(row)
 		b.WriteString(m.markerGutter(i))
-		text := padOrTruncateANSI(m.lines[i], textWidth)
+		line := m.lines[i]
+		if m.xOffset > 0 {
+			line = xansi.Cut(line, m.xOffset, m.xOffset+textWidth)
+		}
+		text := padOrTruncateANSI(line, textWidth)
 		if m.isHeadingRenderedLine(i) {
 			text = m.theme.Styles.HeadingLine.Render(text)
 		}
@@ -379,14 +638,41 @@ func (m model) helpView() string {
 		"  PgUp/PgDn      page",
 		"  u/d            half page",
 		"  g/G            top / bottom",
+		"  h/l            scroll wide tables sideways",
 		"  1-6 / 0        fold outline by heading level",
 		"  [ / ]          previous/next heading",
 		"  /              search (n/N to navigate, c to clear)",
 		"  t              table of contents",
-		"  / (in TOC)     filter headings",
+		"  ctrl+p         jump to a heading (fuzzy)",
+		"  m              front matter metadata",
+		"  s              document stats (words, reading time)",
+		"  F              footnotes on screen (Enter jump)",
+		"  ctrl+o         jump back from a footnote",
+		"  :              command (:goto N, :50%, :set, :fold N, :w FILE, :e FILE)",
+		"  / (in TOC)     filter headings (ctrl+f: fuzzy)",
 		"  ?              toggle this help",
 		"  mouse wheel    scroll",
 	}
+	if m.embedded {
+		lines[2] = "  Esc            close this help"
+		lines = append(lines, "  Tab/Shift+Tab  select next/previous link", "  Enter          open the selected link")
+	}
+	if m.diff != nil {
+		lines = append(lines[:10], append([]string{"  ]c / [c        next/previous change"}, lines[10:]...)...)
+	}
+	if m.slideMode {
+		lines = []string{
+			"Keys",
+			"",
+			"  q / Esc              quit",
+			"  n, Right, Space      next slide",
+			"  p, Left, Backspace   previous slide",
+			"  g/G                  first / last slide",
+			"  j/k                  scroll a tall slide",
+			"  s                    speaker notes",
+			"  ?                    toggle this help",
+		}
+	}
 
 	box := m.theme.Styles.HelpBox.Render(strings.Join(lines, "\n"))
 
@@ -401,6 +687,39 @@ func (m model) helpView() string {
 	)
 }
 
+func (m model) metaView() string {
+	keyW := 0
+	for _, f := range m.meta.Fields {
+		keyW = max(keyW, lipgloss.Width(f.Key))
+	}
+	keyW = min(keyW, 24)
+	valW := max(10, min(m.width-12, 80)-keyW-2)
+
+	lines := []string{"Front matter (" + m.meta.Format + ")", ""}
+	for _, f := range m.meta.Fields {
+		key := truncateEnd(f.Key, keyW)
+		key += strings.Repeat(" ", max(0, keyW-lipgloss.Width(key)))
+		lines = append(lines, "  "+key+"  "+truncateEnd(f.Value, valW))
+	}
+
+	box := m.theme.Styles.HelpBox.Render(strings.Join(lines, "\n"))
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		box,
+		lipgloss.WithWhitespaceBackground(m.theme.Colors.OverlayBg),
+	)
+}
+
+// maxXOffset is how far lines wider than the body (CSV tables) can be
+// scrolled sideways.
+func (m model) maxXOffset() int {
+	return max(0, m.widest-m.bodyTextWidth())
+}
+
 func (m model) pageSize() int {
 	return max(1, m.height-2)
 }
@@ -465,7 +784,18 @@ func (m *model) refreshHeadingLocs(renderWidth int) {
 		return
 	}
 
-	locs := computeHeadingLocsFromRendered(m.plain, m.headings)
+	var locs []headingLoc
+	if m.diff != nil {
+		// Changed headings carry a gutter and word marks, so the diff says
+		// where they are rather than their text.
+		for _, h := range m.headings {
+			if line, ok := m.diffHeadings[h.Line]; ok {
+				locs = append(locs, headingLoc{Heading: h, RenderedLine: line})
+			}
+		}
+	} else {
+		locs = computeHeadingLocsFromRendered(m.plain, m.headings)
+	}
 	lineSet := map[int]bool{}
 	byMD := map[int]int{}
 	for _, loc := range locs {
@@ -701,6 +1031,9 @@ func (m model) scrollbar(visible int) scrollbarModel {
 	}
 }
 
+// truncateEnd shortens s to width terminal columns, ending in "..." when it
+// is cut. Wide (CJK) characters take two columns, and grapheme clusters such
+// as emoji sequences or letters with combining marks are never split.
 func truncateEnd(s string, width int) string {
 	if width <= 0 {
 		return ""
@@ -711,8 +1044,7 @@ func truncateEnd(s string, width int) string {
 	if width <= 3 {
 		return strings.Repeat(".", width)
 	}
-	// Best-effort: assume ASCII titles. (Good enough for file basenames.)
-	return s[:max(0, width-3)] + "..."
+	return xansi.Truncate(s, width, "...")
 }
 
 func padOrTruncateANSI(s string, width int) string {
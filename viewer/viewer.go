@@ -0,0 +1,123 @@
+// Package viewer embeds md's Markdown pager in other programs.
+//
+// Render formats Markdown for a terminal, the way `md` prints it. Model is
+// the interactive pager as a Bubble Tea component: it draws in the area its
+// parent gives it with SetSize and never quits the program, so it can sit
+// next to other components.
+//
+//	v := viewer.New(doc, viewer.Options{Title: "README.md"})
+//	v.SetSize(width, height)
+//
+//	// in the parent's Update:
+//	switch msg := msg.(type) {
+//	case viewer.LinkMsg:
+//		open(msg.URL)
+//	}
+//	v, cmd = v.Update(msg)
+package viewer
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/simota/md/internal/render"
+	"github.com/simota/md/internal/tui"
+)
+
+// Options configures Render and New.
+type Options struct {
+	// Title is shown in the viewer's header; a front matter title wins.
+	Title string
+	// Style is auto|dark|light ("" = auto).
+	Style string
+	// Width wraps the text at this many columns. 0 = 80 for Render and the
+	// viewer's width for Model.
+	Width int
+	// BaseDir resolves relative links and images (the document's directory).
+	BaseDir string
+	// Hyperlinks makes links clickable with OSC 8 escape sequences in Render
+	// output. Model always uses them.
+	Hyperlinks bool
+	// HideURLs drops the URL printed after link text.
+	HideURLs bool
+}
+
+func (o Options) render() render.Options {
+	return render.Options{
+		Style:      o.Style,
+		Width:      o.Width,
+		BaseDir:    o.BaseDir,
+		Hyperlinks: o.Hyperlinks,
+		HideURLs:   o.HideURLs,
+	}
+}
+
+// Render formats md for a terminal with ANSI styles.
+func Render(md string, opts Options) (string, error) {
+	ro := opts.render()
+	if ro.Width <= 0 {
+		ro.Width = 80
+	}
+	return render.RenderMarkdown(md, ro)
+}
+
+// LinkMsg is sent when the user activates a link in a Model (Tab to select,
+// Enter to activate). Relative links arrive as file:// URLs.
+type LinkMsg = tui.LinkMsg
+
+// Heading is a heading of the document shown in a Model.
+type Heading = tui.Heading
+
+// Model is the pager as a Bubble Tea component. Forward messages to Update
+// and place View in your layout; the zero value is not usable, use New.
+type Model struct {
+	v tui.Model
+}
+
+// New returns a viewer for md. It draws nothing until SetSize is called.
+func New(md string, opts Options) Model {
+	return Model{v: tui.NewModel(opts.Title, md, opts.render())}
+}
+
+func (m Model) Init() tea.Cmd { return m.v.Init() }
+
+// Update handles keys and mouse wheel events. Esc closes the viewer's own
+// overlays; q and ctrl+c are left to the parent.
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	var cmd tea.Cmd
+	m.v, cmd = m.v.Update(msg)
+	return m, cmd
+}
+
+// View renders the viewer, exactly the size given to SetSize.
+func (m Model) View() string { return m.v.View() }
+
+// SetSize sets the area the viewer draws in, header and footer included.
+func (m *Model) SetSize(width, height int) { m.v.SetSize(width, height) }
+
+// SetContent replaces the document and keeps the scroll position where
+// possible.
+func (m *Model) SetContent(md string) { m.v.SetContent(md) }
+
+// Headings returns the document's headings in order.
+func (m Model) Headings() []Heading { return m.v.Headings() }
+
+// GotoHeading scrolls to the heading with this text or slug ("#install" or
+// "Install"). It reports whether the heading exists.
+func (m *Model) GotoHeading(name string) bool { return m.v.GotoHeading(name) }
+
+// Search highlights lines containing query (smart case) and jumps to the
+// first match from the top of the view. It returns the number of matching
+// lines; "" clears the search.
+func (m *Model) Search(query string) int { return m.v.Search(query) }
+
+// NextMatch jumps to the next search match, wrapping around.
+func (m *Model) NextMatch() { m.v.NextMatch() }
+
+// PrevMatch jumps to the previous search match, wrapping around.
+func (m *Model) PrevMatch() { m.v.PrevMatch() }
+
+// Match returns the current match number (1-based) and the match count.
+func (m Model) Match() (current, total int) { return m.v.Match() }
+
+// ScrollPercent returns how far the view is scrolled, 0-100.
+func (m Model) ScrollPercent() int { return m.v.ScrollPercent() }
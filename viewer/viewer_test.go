@@ -0,0 +1,34 @@
+package viewer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestRender(t *testing.T) {
+	out, err := Render("# Title\n\nSome *text*.\n", Options{Style: "light"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain := ansi.Strip(out)
+	if !strings.Contains(plain, "Title") || !strings.Contains(plain, "Some text.") {
+		t.Fatalf("unexpected output:\n%s", plain)
+	}
+}
+
+func TestModel_SetContent(t *testing.T) {
+	m := New("# One\n", Options{Title: "doc", Style: "dark"})
+	m.SetSize(50, 6)
+	if !strings.Contains(m.View(), "One") {
+		t.Fatalf("view:\n%s", m.View())
+	}
+	m.SetContent("# Two\n\nmatch here\n")
+	if !strings.Contains(m.View(), "Two") || m.Search("match") != 1 {
+		t.Fatalf("view after SetContent:\n%s", m.View())
+	}
+	if got := len(strings.Split(m.View(), "\n")); got != 6 {
+		t.Fatalf("got %d rows, want 6", got)
+	}
+}